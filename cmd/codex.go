@@ -2,29 +2,52 @@ package cmd
 
 import (
 	"context"
+	"database/sql"
 	_ "embed"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/PullRequestInc/go-gpt3"
 )
 
 //go:embed codex-prompt-context.sql
-var promptPrefix string
+var promptExamples string
 
-// codexToSQL generates SQL from a natural language prompt
-func codexToSQL(ctx context.Context, prompt string) (string, error) {
+// codexToSQL generates SQL from a natural language prompt, with a prompt describing the git tables registered with db
+func codexToSQL(ctx context.Context, db *sql.DB, prompt string) (string, error) {
 	apiKey := os.Getenv("OPENAI_API_KEY")
 	if apiKey == "" {
 		return "", fmt.Errorf("missing OPENAI_API_KEY environment variable")
 	}
 
+	entries, err := loadSchema(ctx, db)
+	if err != nil {
+		return "", err
+	}
+
+	var promptPrefix strings.Builder
+	promptPrefix.WriteString("-- The following is SQLite SQL.\n")
+	for _, e := range entries {
+		if e.kind != "table" || e.extension != "git" {
+			continue
+		}
+		var columns []string
+		for _, c := range e.columns {
+			if !c.Hidden {
+				columns = append(columns, c.Name)
+			}
+		}
+		fmt.Fprintf(&promptPrefix, "-- Table valued function %s, columns = [%s]\n", e.signature(), strings.Join(columns, ", "))
+	}
+	promptPrefix.WriteString(promptExamples)
+
 	client := gpt3.NewClient(apiKey)
 	var temp float32 = 0
 	var topP float32 = 1
 	var maxTokens = 512
 	res, err := client.CompletionWithEngine(ctx, "code-davinci-002", gpt3.CompletionRequest{
-		Prompt:           []string{promptPrefix + prompt + "SELECT"},
+		Prompt:           []string{promptPrefix.String() + prompt + "SELECT"},
 		Temperature:      &temp,
 		TopP:             &topP,
 		FrequencyPenalty: 1,
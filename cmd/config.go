@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/mergestat/mergestat-lite/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show the effective configuration",
+	Long: `Use this command to show the config files that were read, and the effective configuration, with tokens and passwords redacted.
+The configuration is read from ~/.config/mergestat/config.yaml, then from the .mergestat/config.yaml file of the repository (see --repo),
+which can only set format, extensions and timeout, then from the environment (GITHUB_TOKEN, SOURCEGRAPH_TOKEN, GIT_SSL_NO_VERIFY...), each overriding the previous ones. Flags override them all.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		effective := cfg.Redacted()
+		if effective.Extensions == nil {
+			effective.Extensions = config.Extensions
+		}
+
+		b, err := yaml.Marshal(effective)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		files := "none"
+		if len(cfg.Files) > 0 {
+			files = strings.Join(cfg.Files, ", ")
+		}
+		fmt.Printf("# config files: %s\n%s", files, b)
+	},
+}
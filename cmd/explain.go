@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/extensions/trace"
+	"github.com/spf13/cobra"
+)
+
+var tracer *trace.Tracer // records the activity of the virtual tables, set for the explain command only
+var planOnly bool        // whether to only plan the query, without running it
+
+func init() {
+	explainCmd.Flags().BoolVar(&planOnly, "plan-only", false, "only plan the query, without running it (so the rows produced and the repositories opened are not reported)")
+	addParamFlags(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [query]",
+	Short: "Explain how a query is planned and which constraints the virtual tables accept",
+	Long: `Use this command to explain a query: the query plan of SQLite (as with EXPLAIN QUERY PLAN), followed by a report
+on every virtual table the query uses. The report lists the plans the table offered SQLite, with the constraints
+it accepted (which it applies itself, such as WHERE hash = '...' on commits, instead of scanning every row),
+its index and estimated cost. The query is then run (unless --plan-only is set), and the report shows the plans
+SQLite chose, the rows each table produced and the repositories that were opened.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var db *sql.DB
+		openPath := ":memory:"
+		if dbPath != "" {
+			if openPath, err = filepath.Abs(dbPath); err != nil {
+				handleExitError(err)
+			}
+		}
+		if db, err = sql.Open("sqlite3", openPath); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+		db.SetMaxOpenConns(1) // plan and run the query on the same connection (and database, if in memory)
+
+		var params []interface{}
+		if params, err = queryArgs(); err != nil {
+			handleExitError(err)
+		}
+
+		var b strings.Builder
+		if err = explainQueryPlan(cmd, db, args[0], params, &b); err != nil {
+			handleExitError(fmt.Errorf("failed to explain query: %v", err))
+		}
+
+		if !planOnly {
+			start := time.Now()
+			var n int
+			if n, err = countRows(cmd, db, args[0], params); err != nil {
+				handleExitError(fmt.Errorf("query execution failed: %v", err))
+			}
+			fmt.Fprintf(&b, "\nRESULT\n%d rows in %s\n", n, time.Since(start).Round(time.Millisecond))
+		}
+
+		explainTables(&b)
+		fmt.Print(b.String())
+	},
+}
+
+// explainQueryPlan writes the query plan of query to b, as a tree
+func explainQueryPlan(cmd *cobra.Command, db *sql.DB, query string, params []interface{}, b *strings.Builder) error {
+	rows, err := db.QueryContext(cmd.Context(), "EXPLAIN QUERY PLAN "+query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// each step has a parent (0 for the top level ones), listed before it
+	var depths = map[int]int{0: 0}
+	b.WriteString("QUERY PLAN\n")
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err = rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			return err
+		}
+		depths[id] = depths[parent] + 1
+		fmt.Fprintf(b, "%s%s\n", strings.Repeat("  ", depths[id]-1), detail)
+	}
+	return rows.Err()
+}
+
+// countRows runs query, and returns the number of rows it returned
+func countRows(cmd *cobra.Command, db *sql.DB, query string, params []interface{}) (int, error) {
+	rows, err := db.QueryContext(cmd.Context(), query, params...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int
+	for rows.Next() {
+		n++
+	}
+	return n, rows.Err()
+}
+
+// explainTables writes what the tracer recorded to b: the plans offered by each virtual table, with the constraints
+// they accepted, the plans that were chosen and the rows produced, followed by the repositories that were opened
+func explainTables(b *strings.Builder) {
+	for _, table := range tracer.Tables() {
+		fmt.Fprintf(b, "\nTABLE %s\n", table.Name)
+		if !planOnly {
+			fmt.Fprintf(b, "filtered %d times, %d rows produced\n", table.Filters, table.Rows)
+		}
+
+		for i, plan := range table.Plans {
+			fmt.Fprintf(b, "plan %d: index %d %q, estimated cost %g", i+1, plan.IndexNumber, plan.IndexString, plan.EstimatedCost)
+			if plan.EstimatedRows > 0 {
+				fmt.Fprintf(b, ", estimated rows %d", plan.EstimatedRows)
+			}
+			if plan.OrderByConsumed {
+				b.WriteString(", ordered by the table")
+			}
+			if plan.Chosen > 0 {
+				fmt.Fprintf(b, " (chosen %d times)", plan.Chosen)
+			}
+			b.WriteString("\n")
+
+			for _, c := range plan.Constraints {
+				fmt.Fprintf(b, "  %s %s: ", c.Column, c.Op)
+				switch {
+				case !c.Usable:
+					b.WriteString("not usable in this plan\n")
+				case c.Argv == 0:
+					b.WriteString("not accepted, checked by SQLite on every row\n")
+				case c.Omit:
+					fmt.Fprintf(b, "accepted (argument %d)\n", c.Argv)
+				default:
+					fmt.Fprintf(b, "accepted (argument %d), and checked by SQLite\n", c.Argv)
+				}
+			}
+			if len(plan.Constraints) == 0 {
+				b.WriteString("  no constraints, every row is scanned\n")
+			}
+		}
+	}
+
+	if planOnly {
+		return
+	}
+	var repos = tracer.Repos()
+	fmt.Fprintf(b, "\nREPOSITORIES (%d opened)\n", len(repos))
+	for _, repo := range repos {
+		if repo.Err != nil {
+			fmt.Fprintf(b, "%s: %v\n", repo.Path, repo.Err)
+		} else {
+			fmt.Fprintln(b, repo.Path)
+		}
+	}
+}
@@ -1,16 +1,21 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/mergestat/mergestat-lite/pkg/display"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exports []string
-	append  bool
+	exports      []string
+	appendMode   bool
+	exportFormat string
 )
 
 type export struct {
@@ -20,14 +25,17 @@ type export struct {
 
 func init() {
 	exportCmd.Flags().StringArrayVarP(&exports, "exports", "e", []string{}, "queries to export, supplied as string pairs")
-	exportCmd.Flags().BoolVarP(&append, "append", "a", false, "append mode: insert into tables rather than creating new ones")
+	exportCmd.Flags().BoolVarP(&appendMode, "append", "a", false, "append mode: insert into tables rather than creating new ones")
+	addParamFlags(exportCmd)
+	exportCmd.Flags().StringVar(&exportFormat, "format", "sqlite", "export into tables of a SQLite db file with 'sqlite', or into a directory, as a parquet file per table, with 'parquet'")
 }
 
 var exportCmd = &cobra.Command{
-	Use:   "export [sqlite db file]",
-	Short: "Export queries into a SQLite db file",
-	Long:  `Use this command to export queries into a SQLite database file on disk`,
-	Args:  cobra.ExactArgs(1),
+	Use:   "export [sqlite db file | directory]",
+	Short: "Export queries into a SQLite db file, or parquet files",
+	Long: `Use this command to export queries into a SQLite database file on disk,
+or with --format parquet into a directory, as a <table>.parquet file per query`,
+	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
 
@@ -41,11 +49,26 @@ var exportCmd = &cobra.Command{
 
 		pairs := make([]export, len(exports)/2)
 		for e := 0; e < len(exports)-1; e += 2 {
-			if e == 0 {
-				pairs[e] = export{exports[e], exports[e+1]}
-			} else {
-				pairs[e-1] = export{exports[e], exports[e+1]}
+			pairs[e/2] = export{exports[e], exports[e+1]}
+		}
+
+		var params []interface{}
+		if params, err = queryArgs(); err != nil {
+			handleExitError(err)
+		}
+
+		switch exportFormat {
+		case "sqlite":
+		case "parquet":
+			if appendMode {
+				handleExitError(fmt.Errorf("append mode is not supported with the parquet format"))
+			}
+			if err = exportParquet(cmd.Context(), args[0], pairs, params); err != nil {
+				handleExitError(err)
 			}
+			return
+		default:
+			handleExitError(fmt.Errorf("unknown export format %s, expected sqlite or parquet", exportFormat))
 		}
 
 		var fileName string
@@ -59,7 +82,7 @@ var exportCmd = &cobra.Command{
 		}
 
 		for _, pair := range pairs {
-			if append {
+			if appendMode {
 				var tableAlreadyExists bool
 				if row := db.QueryRow("SELECT EXISTS (SELECT * FROM sqlite_master WHERE type='table' AND name = ?)", pair.table); row.Err() != nil {
 					handleExitError(fmt.Errorf("failed to execute query: %v", err))
@@ -70,7 +93,7 @@ var exportCmd = &cobra.Command{
 				}
 
 				if !tableAlreadyExists {
-					if _, err = db.Exec(fmt.Sprintf("CREATE TABLE %s AS %s", pair.table, pair.query)); err != nil {
+					if _, err = db.Exec(fmt.Sprintf("CREATE TABLE %s AS %s", pair.table, pair.query), params...); err != nil {
 						handleExitError(fmt.Errorf("failed to execute query: %v", err))
 					}
 				} else {
@@ -79,7 +102,7 @@ var exportCmd = &cobra.Command{
 						handleExitError(fmt.Errorf("failed to start transaction: %v", err))
 					}
 
-					if _, err = tx.Exec(fmt.Sprintf("INSERT INTO %s %s", pair.table, pair.query)); err != nil {
+					if _, err = tx.Exec(fmt.Sprintf("INSERT INTO %s %s", pair.table, pair.query), params...); err != nil {
 						handleExitError(fmt.Errorf("failed to execute query: %v", err))
 					}
 
@@ -89,7 +112,7 @@ var exportCmd = &cobra.Command{
 				}
 
 			} else {
-				if _, err = db.Exec(fmt.Sprintf("CREATE TABLE %s AS %s", pair.table, pair.query)); err != nil {
+				if _, err = db.Exec(fmt.Sprintf("CREATE TABLE %s AS %s", pair.table, pair.query), params...); err != nil {
 					handleExitError(fmt.Errorf("failed to execute query: %v", err))
 				}
 			}
@@ -97,3 +120,57 @@ var exportCmd = &cobra.Command{
 
 	},
 }
+
+// exportParquet writes the results of each query of pairs, with params bound, to a parquet file named after its table, in dir
+func exportParquet(ctx context.Context, dir string, pairs []export, params []interface{}) error {
+	var err error
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	var db *sql.DB
+	openPath := ":memory:"
+	if dbPath != "" {
+		if openPath, err = filepath.Abs(dbPath); err != nil {
+			return err
+		}
+	}
+	if db, err = sql.Open("sqlite3", openPath); err != nil {
+		return fmt.Errorf("failed to initialize database connection: %v", err)
+	}
+	defer db.Close()
+
+	for _, pair := range pairs {
+		if pair.table == "" || strings.ContainsAny(pair.table, `/\`) {
+			return fmt.Errorf("invalid table name %q, it is used as a file name", pair.table)
+		}
+		if err = exportParquetFile(ctx, db, filepath.Join(dir, pair.table+".parquet"), pair.query, params); err != nil {
+			return fmt.Errorf("failed to export %s: %v", pair.table, err)
+		}
+	}
+	return nil
+}
+
+func exportParquetFile(ctx context.Context, db *sql.DB, fileName, query string, params []interface{}) (err error) {
+	var rows *sql.Rows
+	if rows, err = db.QueryContext(ctx, query, params...); err != nil {
+		return fmt.Errorf("failed to execute query: %v", err)
+	}
+	defer rows.Close()
+
+	var f *os.File
+	if f, err = os.Create(fileName); err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// a partially written file is not a valid parquet file
+		if err != nil {
+			_ = os.Remove(fileName)
+		}
+	}()
+
+	return display.WriteTo(rows, f, "parquet", false)
+}
@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	"github.com/spf13/cobra"
+)
+
+var (
+	multiReposFile string // path to a file listing the repos to query
+	multiLocalRoot string // path to a directory to search for repos on disk
+	multiGitHubOrg string // name of a GitHub org whose repos should be queried
+	multiWorkers   int    // number of repos to query concurrently
+)
+
+func init() {
+	multiCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. "+formatOptions())
+	multiCmd.Flags().StringVar(&templateText, "template", "", "Go template executed for each row with the template format, e.g. '{{.repository}}: {{.count}}'")
+	multiCmd.Flags().StringVarP(&multiReposFile, "repos", "i", "", "path to a file listing repositories (paths or URLs) to query, one per line. Use '-' to read the list from stdin")
+	multiCmd.Flags().StringVar(&multiLocalRoot, "local-root", "", "path to a directory on disk to search for git repositories (including bare ones) to query")
+	multiCmd.Flags().StringVar(&multiGitHubOrg, "github-org", "", "name of a GitHub organization whose repositories should be queried (requires GITHUB_TOKEN)")
+	multiCmd.Flags().IntVarP(&multiWorkers, "workers", "w", runtime.NumCPU(), "number of repositories to query concurrently")
+}
+
+// multiResult holds the output of running the query against a single repository
+type multiResult struct {
+	repo    string
+	columns []string
+	types   []string // declared types of the columns, empty for expressions
+	rows    [][]interface{}
+	err     error
+}
+
+var multiCmd = &cobra.Command{
+	Use:   `multi "SELECT count(*) FROM commits($repository)"`,
+	Short: "Run a query against many repositories in parallel",
+	Long: `Use this command to run the same query against a list of repositories, using a pool of workers.
+Repositories can be listed in a file (--repos), discovered on disk under a root directory (--local-root)
+or fetched from a GitHub organization (--github-org). These sources may be combined.
+
+The repository being queried is bound to the $repository (or :repository, @repository) parameter,
+so use it wherever a table expects a repository argument, e.g. commits($repository) or stats($repository, commits.hash).
+Results from all repositories are merged into a single output, with an additional leading "repository" column.
+Columns keep the declared types of the first repository's results (so booleans and JSON stay typed in JSON output),
+and names that appear more than once (such as a "repository" column of the query) get a _2, _3... suffix.
+Errors for individual repositories are reported on stderr and do not stop the rest of the run.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		query := args[0]
+		ctx := cmd.Context()
+
+		if multiWorkers < 1 {
+			handleExitError(fmt.Errorf("workers must be at least 1, got %d", multiWorkers))
+		}
+
+		var err error
+		var db *sql.DB
+		openPath := ":memory:"
+		if dbPath != "" {
+			if openPath, err = filepath.Abs(dbPath); err != nil {
+				handleExitError(err)
+			}
+		}
+		if db, err = sql.Open("sqlite3", openPath); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+		db.SetMaxOpenConns(multiWorkers)
+
+		var repos []string
+		if repos, err = multiRepoList(ctx, db); err != nil {
+			handleExitError(err)
+		}
+		if len(repos) == 0 {
+			handleExitError(fmt.Errorf("no repositories to query, use --repos, --local-root or --github-org"))
+		}
+		logger.Info().Msgf("running query against %d repositories with %d workers", len(repos), multiWorkers)
+
+		// results are collected into a separate in-memory database so that they
+		// can be written out through the same display routines as any other query
+		var out *sql.DB
+		if out, err = sql.Open("sqlite3", ":memory:"); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer out.Close()
+		out.SetMaxOpenConns(1)
+
+		queue := make(chan string)
+		results := make(chan *multiResult)
+
+		var wg sync.WaitGroup
+		for w := 0; w < multiWorkers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for r := range queue {
+					results <- runMultiQuery(ctx, db, query, r)
+				}
+			}()
+		}
+
+		go func() {
+			for _, r := range repos {
+				queue <- r
+			}
+			close(queue)
+			wg.Wait()
+			close(results)
+		}()
+
+		var failed int
+		var columns []string
+		for res := range results {
+			if res.err != nil {
+				failed++
+				logger.Error().Str("repository", res.repo).Msgf("query failed: %v", res.err)
+				continue
+			}
+
+			if columns == nil {
+				columns = res.columns
+				if err = createMultiResultsTable(out, columns, res.types); err != nil {
+					handleExitError(err)
+				}
+			} else if len(columns) != len(res.columns) {
+				failed++
+				logger.Error().Str("repository", res.repo).Msgf("query returned %d columns, expected %d", len(res.columns), len(columns))
+				continue
+			}
+
+			if err = insertMultiResult(out, res); err != nil {
+				handleExitError(err)
+			}
+			logger.Info().Str("repository", res.repo).Msgf("collected %d rows", len(res.rows))
+		}
+
+		if columns != nil {
+			var rows *sql.Rows
+			if rows, err = out.Query("SELECT * FROM multi_results ORDER BY rowid"); err != nil {
+				handleExitError(fmt.Errorf("query execution failed: %v", err))
+			}
+			defer rows.Close()
+
+			if err = display.Write(rows, os.Stdout, format, &display.Options{Template: templateText}); err != nil {
+				handleExitError(fmt.Errorf("failed to output resultset: %v", err))
+			}
+		}
+
+		if failed > 0 {
+			handleExitError(fmt.Errorf("query failed for %d of %d repositories", failed, len(repos)))
+		}
+	},
+}
+
+// multiRepoList assembles the list of repositories to query from all the supplied sources
+func multiRepoList(ctx context.Context, db *sql.DB) ([]string, error) {
+	var repos []string
+	seen := make(map[string]bool)
+	add := func(r string) {
+		if !seen[r] {
+			seen[r] = true
+			repos = append(repos, r)
+		}
+	}
+
+	if multiReposFile != "" {
+		var in io.Reader = os.Stdin
+		if multiReposFile != "-" {
+			f, err := os.Open(multiReposFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open repository list: %v", err)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") { // ignore blank lines and comments
+				continue
+			}
+			add(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read repository list: %v", err)
+		}
+	}
+
+	if multiLocalRoot != "" {
+		err := filepath.WalkDir(multiLocalRoot, func(path string, d fs.DirEntry, err error) error {
+			// skip the directories that can't be read, rather than giving up on all the others
+			if errors.Is(err, fs.ErrPermission) && path != multiLocalRoot {
+				logger.Warn().Err(err).Msgf("skipping %s", path)
+				return filepath.SkipDir
+			}
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			// a directory with a .git entry (or a bare repository, such as a mirror) is a repository,
+			// don't descend any further into it
+			if _, err := os.Stat(filepath.Join(path, ".git")); err == nil || locator.IsBareRepo(path) {
+				add(path)
+				return filepath.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for local repositories: %v", err)
+		}
+	}
+
+	if multiGitHubOrg != "" {
+		rows, err := db.QueryContext(ctx, "SELECT name FROM github_org_repos(?) ORDER BY name", multiGitHubOrg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of GitHub org %q: %v", multiGitHubOrg, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var name string
+			if err = rows.Scan(&name); err != nil {
+				return nil, err
+			}
+			add(fmt.Sprintf("https://github.com/%s/%s", multiGitHubOrg, name))
+		}
+		if err = rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to list repositories of GitHub org %q: %v", multiGitHubOrg, err)
+		}
+	}
+
+	return repos, nil
+}
+
+// runMultiQuery executes the query against a single repository and buffers the results
+func runMultiQuery(ctx context.Context, db *sql.DB, query, repo string) *multiResult {
+	res := &multiResult{repo: repo}
+
+	rows, err := db.QueryContext(ctx, query, sql.Named("repository", repo))
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer rows.Close()
+
+	if res.columns, err = rows.Columns(); err != nil {
+		res.err = err
+		return res
+	}
+
+	var columnTypes []*sql.ColumnType
+	if columnTypes, err = rows.ColumnTypes(); err != nil {
+		res.err = err
+		return res
+	}
+	for _, columnType := range columnTypes {
+		res.types = append(res.types, columnType.DatabaseTypeName())
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(res.columns))
+		pointers := make([]interface{}, len(res.columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err = rows.Scan(pointers...); err != nil {
+			res.err = err
+			return res
+		}
+
+		res.rows = append(res.rows, values)
+	}
+
+	res.err = rows.Err()
+	return res
+}
+
+// quoteIdent quotes a column name for use in a SQLite statement
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// declaredTypePattern matches the declared types of columns that are copied to the results table,
+// which are names with an optional size, such as VARCHAR(10)
+var declaredTypePattern = regexp.MustCompile(`^[\w ]+(\([\d, ]+\))?$`)
+
+// uniqueNames returns names where each name that appeared before gets the first _2, _3... suffix that makes it unique
+func uniqueNames(names []string) []string {
+	var unique = make([]string, len(names))
+	var seen = make(map[string]bool, len(names))
+	for i, name := range names {
+		unique[i] = name
+		for n := 2; seen[strings.ToLower(unique[i])]; n++ { // column names are case insensitive
+			unique[i] = fmt.Sprintf("%s_%d", name, n)
+		}
+		seen[strings.ToLower(unique[i])] = true
+	}
+	return unique
+}
+
+// createMultiResultsTable creates the table results are collected into, with a leading repository column followed by
+// columns, of the given declared types, so that the results keep their types when they're read back
+func createMultiResultsTable(out *sql.DB, columns, types []string) error {
+	names := uniqueNames(append([]string{"repository"}, columns...))
+	cols := make([]string, len(names))
+	for i, name := range names {
+		cols[i] = quoteIdent(name)
+		if i > 0 && declaredTypePattern.MatchString(types[i-1]) {
+			cols[i] += " " + types[i-1]
+		}
+	}
+
+	if _, err := out.Exec(fmt.Sprintf("CREATE TABLE multi_results (%s)", strings.Join(cols, ", "))); err != nil {
+		return fmt.Errorf("failed to create results table: %v", err)
+	}
+	return nil
+}
+
+func insertMultiResult(out *sql.DB, res *multiResult) error {
+	tx, err := out.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(res.columns)+1), ", ")
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO multi_results VALUES (%s)", placeholders))
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, row := range res.rows {
+		if _, err = stmt.Exec(append([]interface{}{res.repo}, row...)...); err != nil {
+			return fmt.Errorf("failed to insert results: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
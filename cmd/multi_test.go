@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+)
+
+func TestUniqueNames(t *testing.T) {
+	var cases = []struct {
+		names, expected []string
+	}{
+		{[]string{"repository", "hash", "count"}, []string{"repository", "hash", "count"}},
+		{[]string{"repository", "repository"}, []string{"repository", "repository_2"}},
+		{[]string{"repository", "name", "name", "Name"}, []string{"repository", "name", "name_2", "Name_3"}},
+		{[]string{"repository", "name_2", "name", "name"}, []string{"repository", "name_2", "name", "name_3"}},
+	}
+	for _, c := range cases {
+		if unique := uniqueNames(c.names); !reflect.DeepEqual(unique, c.expected) {
+			t.Errorf("uniqueNames(%v) = %v, want %v", c.names, unique, c.expected)
+		}
+	}
+}
+
+func TestMultiResults(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err = db.Exec(`CREATE TABLE prs (repository TEXT, title TEXT, merged BOOLEAN, labels JSON, created_at DATETIME);
+		INSERT INTO prs VALUES ('upstream', 'Fix tests', 1, '["bug"]', '2024-01-02 03:04:05'), ('upstream', 'Add docs', 0, NULL, NULL)`); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	out.SetMaxOpenConns(1)
+
+	var query = "SELECT repository, title, merged, labels, created_at, title, count(*) OVER () FROM prs ORDER BY rowid"
+	for i, repo := range []string{"a", "b"} {
+		res := runMultiQuery(context.Background(), db, query, repo)
+		if res.err != nil {
+			t.Fatal(res.err)
+		}
+		if len(res.rows) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(res.rows))
+		}
+
+		if i == 0 {
+			if err = createMultiResultsTable(out, res.columns, res.types); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = insertMultiResult(out, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := out.Query("SELECT * FROM multi_results ORDER BY rowid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var b bytes.Buffer
+	if err = display.Write(rows, &b, "json", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []map[string]interface{}
+	if err = json.Unmarshal(b.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v: %s", err, b.String())
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+
+	first := results[0]
+	var expected = map[string]interface{}{
+		"repository":       "a",
+		"repository_2":     "upstream",
+		"title":            "Fix tests",
+		"title_2":          "Fix tests",
+		"merged":           true,
+		"labels":           []interface{}{"bug"},
+		"count(*) OVER ()": float64(2),
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(first[key], value) {
+			t.Errorf("expected %s to be %#v, got %#v", key, value, first[key])
+		}
+	}
+	if first["created_at"] == nil {
+		t.Error("expected created_at to be set")
+	}
+
+	second := results[1]
+	if second["merged"] != false || second["labels"] != nil || second["created_at"] != nil {
+		t.Errorf("expected a false merged, and NULL labels and created_at, got: %v", second)
+	}
+	if results[2]["repository"] != "b" {
+		t.Errorf("expected the results of the second repository to follow, got: %v", results[2])
+	}
+}
+
+func TestMultiRepoListLocalRoot(t *testing.T) {
+	var root = t.TempDir()
+	for _, dir := range []string{"a/.git", "a/nested/.git", "mirrors/b.git/objects", "mirrors/b.git/refs", "locked/c/.git", "empty"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "mirrors/b.git/HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// the locked directory can't be read (unless running as root), and is skipped
+	if err := os.Chmod(filepath.Join(root, "locked"), 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(root, "locked"), 0755) })
+
+	defer func(localRoot string) { multiLocalRoot = localRoot }(multiLocalRoot)
+	multiLocalRoot = root
+
+	repos, err := multiRepoList(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = []string{filepath.Join(root, "a"), filepath.Join(root, "mirrors/b.git")}
+	if os.Geteuid() == 0 {
+		expected = append(expected[:1], filepath.Join(root, "locked/c"), expected[1])
+	}
+	if !reflect.DeepEqual(repos, expected) {
+		t.Fatalf("expected %v, got %v", expected, repos)
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+var textParams []string // query parameters, bound as text
+var intParams []string  // query parameters, bound as integers
+var jsonParams []string // query parameters holding JSON, bound as (compacted) text
+
+// addParamFlags adds the flags binding parameters to the query of cmd
+func addParamFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&textParams, "param", nil, "bind a parameter of the query, as name=value, to its :name (or $name, @name) placeholders. Can be repeated")
+	cmd.Flags().StringArrayVar(&intParams, "param-int", nil, "bind an integer parameter of the query, as name=value. Can be repeated")
+	cmd.Flags().StringArrayVar(&jsonParams, "param-json", nil, "bind a JSON parameter of the query, as name=value, to use with the JSON functions. Can be repeated")
+}
+
+// queryArgs returns the parameters set with the flags of addParamFlags, as named arguments of a query
+func queryArgs() ([]interface{}, error) {
+	var args []interface{}
+	var seen = make(map[string]bool)
+
+	add := func(params []string, parse func(string) (interface{}, error)) error {
+		for _, param := range params {
+			name, value, ok := strings.Cut(param, "=")
+			// the name may be given with the prefix of its placeholder
+			name = strings.TrimLeft(name, ":$@")
+			if !ok || name == "" {
+				return fmt.Errorf("invalid parameter %q, expected name=value", param)
+			}
+			if seen[name] {
+				return fmt.Errorf("parameter %s is set more than once", name)
+			}
+			seen[name] = true
+
+			v, err := parse(value)
+			if err != nil {
+				return fmt.Errorf("invalid value of parameter %s: %v", name, err)
+			}
+			args = append(args, sql.Named(name, v))
+		}
+		return nil
+	}
+
+	if err := add(textParams, func(s string) (interface{}, error) { return s, nil }); err != nil {
+		return nil, err
+	}
+	if err := add(intParams, func(s string) (interface{}, error) { return query.ParseValue("int", s) }); err != nil {
+		return nil, err
+	}
+	if err := add(jsonParams, func(s string) (interface{}, error) { return query.ParseValue("json", s) }); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
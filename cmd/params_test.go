@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+// setParams sets the parameter flags for the duration of the test
+func setParams(t *testing.T, text, ints, jsons []string) {
+	t.Helper()
+
+	oldText, oldInts, oldJSON := textParams, intParams, jsonParams
+	t.Cleanup(func() { textParams, intParams, jsonParams = oldText, oldInts, oldJSON })
+	textParams, intParams, jsonParams = text, ints, jsons
+}
+
+func TestQueryArgs(t *testing.T) {
+	var cases = []struct {
+		name              string
+		text, ints, jsons []string
+		expected          []interface{}
+	}{
+		{"none", nil, nil, nil, nil},
+		{"name=value", []string{"author=Jane Doe"}, nil, nil, []interface{}{sql.Named("author", "Jane Doe")}},
+		{"empty value", []string{"author="}, nil, nil, []interface{}{sql.Named("author", "")}},
+		{"value holding =", []string{"where=a=b"}, nil, nil, []interface{}{sql.Named("where", "a=b")}},
+		{"colon prefix", []string{":author=Jane"}, nil, nil, []interface{}{sql.Named("author", "Jane")}},
+		{"dollar prefix", []string{"$author=Jane"}, nil, nil, []interface{}{sql.Named("author", "Jane")}},
+		{"at prefix", []string{"@author=Jane"}, nil, nil, []interface{}{sql.Named("author", "Jane")}},
+		{"int", nil, []string{"limit= 10 "}, nil, []interface{}{sql.Named("limit", int64(10))}},
+		{"negative int", nil, []string{":offset=-3"}, nil, []interface{}{sql.Named("offset", int64(-3))}},
+		{"json", nil, nil, []string{`labels=[ "bug", {"a": 1} ]`}, []interface{}{sql.Named("labels", `["bug",{"a":1}]`)}},
+		{
+			"every kind, text first",
+			[]string{"a=1", "b=2"}, []string{"c=3"}, []string{"d={}"},
+			[]interface{}{sql.Named("a", "1"), sql.Named("b", "2"), sql.Named("c", int64(3)), sql.Named("d", "{}")},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setParams(t, c.text, c.ints, c.jsons)
+			args, err := queryArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, c.expected) {
+				t.Fatalf("expected %v, got %v", c.expected, args)
+			}
+		})
+	}
+}
+
+func TestQueryArgsInvalid(t *testing.T) {
+	var cases = []struct {
+		name              string
+		text, ints, jsons []string
+	}{
+		{"no value", []string{"author"}, nil, nil},
+		{"no name", []string{"=Jane"}, nil, nil},
+		{"only a prefix", []string{":=Jane"}, nil, nil},
+		{"duplicate", []string{"author=Jane", "author=Bob"}, nil, nil},
+		{"duplicate with prefixes", []string{":author=Jane", "@author=Bob"}, nil, nil},
+		{"duplicate across kinds", []string{"limit=10"}, []string{"limit=10"}, nil},
+		{"not an int", nil, []string{"limit=ten"}, nil},
+		{"not an integer", nil, []string{"limit=1.5"}, nil},
+		{"not json", nil, nil, []string{"labels=[bug]"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			setParams(t, c.text, c.ints, c.jsons)
+			if args, err := queryArgs(); err == nil {
+				t.Fatalf("expected an error, got %v", args)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,12 +16,22 @@ import (
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 )
 
+func init() {
+	addParamFlags(pgsyncCmd)
+}
+
 var pgsyncCmd = &cobra.Command{
 	Use:  "pgsync [tableName] [query]",
 	Long: `Use this command to sync the results of a mergestat query into a Postgres table`,
 	Args: cobra.ExactArgs(2),
+	// TODO(patrickdevivo) hidden unless enabled (with PGSYNC, or in the config) until the behavior stabilizes
+	Hidden: os.Getenv("PGSYNC") == "",
 	Run: func(cmd *cobra.Command, args []string) {
-		registerExt()
+		if !cfg.Postgres.Enabled {
+			handleExitError(fmt.Errorf("pgsync is not enabled, set PGSYNC or postgres.enabled in the config"))
+		}
+
+		registerExt(cmd.Context())
 
 		var schemaName string
 		tableName := args[0]
@@ -36,7 +47,7 @@ var pgsyncCmd = &cobra.Command{
 		var mergestat *sql.DB
 		var err error
 
-		if postgres, err = sql.Open("postgres", os.Getenv("POSTGRES_CONNECTION")); err != nil {
+		if postgres, err = sql.Open("postgres", cfg.Postgres.Connection); err != nil {
 			logger.Error().Msgf("could not open postgres connection: %v", err)
 			return
 		}
@@ -62,23 +73,29 @@ var pgsyncCmd = &cobra.Command{
 			}
 		}()
 
-		ctx, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
 		// TODO(patrickdevivo) hacky way of adding a SQL statement to run ahead of sync...
 		// added mainly so we can run `SET statement_timeout 0` for connections to bit.io
-		if preamble := os.Getenv("PGSYNC_PREAMBLE"); preamble != "" {
+		if preamble := cfg.Postgres.Preamble; preamble != "" {
 			if _, err := postgres.ExecContext(ctx, preamble); err != nil {
 				logger.Error().Msgf("could not execute preamble: %v", err)
 			}
 		}
 
+		var params []interface{}
+		if params, err = queryArgs(); err != nil {
+			handleExitError(err)
+		}
+
 		options := &pgsync.SyncOptions{
 			Postgres:   postgres,
 			MergeStat:  mergestat,
 			SchemaName: schemaName,
 			TableName:  tableName,
 			Query:      query,
+			Args:       params,
 			Logger:     &logger,
 		}
 
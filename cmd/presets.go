@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mergestat/mergestat-lite/pkg/query"
+	"github.com/spf13/cobra"
+)
+
+var presetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "Browse the preset queries",
+	Long: `Use this command to browse the preset queries, which are run with --preset.
+Besides the built in presets, presets are loaded from the .sql files of ~/.config/mergestat/queries,
+and of the .mergestat/queries directory of the repository (see --repo), which override presets of the same name.
+A preset file holds a query, optionally preceded by a YAML front-matter between --- lines, such as:
+
+---
+name: commits-since
+description: Count commits per author since a date
+params:
+  - name: since
+    default: "2023-01-01"
+---
+SELECT author_email, count(*) FROM commits WHERE author_when > :since GROUP BY author_email
+
+Parameters are set with --param (e.g. --param since=2024-01-01), and those without a default are required.`,
+}
+
+var presetsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the preset queries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		library, err := query.Load(query.DefaultDirs(repo)...)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tDESCRIPTION\tSOURCE")
+		for _, preset := range library.Presets() {
+			source := preset.Source
+			if source == "" {
+				source = "built in"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", preset.Name, preset.Description, source)
+		}
+		if err = w.Flush(); err != nil {
+			handleExitError(err)
+		}
+	},
+}
+
+var presetsShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show the description, parameters and query of a preset",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		library, err := query.Load(query.DefaultDirs(repo)...)
+		if err != nil {
+			handleExitError(err)
+		}
+
+		preset, found := library.Find(args[0])
+		if !found {
+			handleExitError(fmt.Errorf("unknown preset query: %s", args[0]))
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Name: %s\n", preset.Name)
+		if preset.Description != "" {
+			fmt.Fprintf(&b, "Description: %s\n", preset.Description)
+		}
+		if preset.Source != "" {
+			fmt.Fprintf(&b, "Source: %s\n", preset.Source)
+		}
+		if len(preset.Params) > 0 {
+			fmt.Fprintln(&b, "Parameters:")
+			for _, param := range preset.Params {
+				fmt.Fprintf(&b, "  %s (%s", param.Name, param.Type)
+				if param.Default != nil {
+					fmt.Fprintf(&b, ", default %q", *param.Default)
+				} else {
+					b.WriteString(", required")
+				}
+				b.WriteString(")")
+				if param.Description != "" {
+					fmt.Fprintf(&b, ": %s", param.Description)
+				}
+				b.WriteString("\n")
+			}
+		}
+		fmt.Fprintf(&b, "\n%s\n", preset.SQL)
+
+		fmt.Print(b.String())
+	},
+}
+
+func init() {
+	presetsCmd.AddCommand(presetsListCmd, presetsShowCmd)
+}
@@ -6,53 +6,71 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/mergestat/mergestat-lite/extensions/trace"
+	"github.com/mergestat/mergestat-lite/pkg/config"
 	"github.com/mergestat/mergestat-lite/pkg/display"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
 	. "github.com/mergestat/mergestat-lite/pkg/query"
+	"github.com/mergestat/mergestat-lite/pkg/shell"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 )
 
-var format string                                     // output format flag
-var presetQuery string                                // named / preset query flag
-var dbPath string                                     // path to sqlite db file on disk to mount on
-var repo string                                       // path to repo on disk
-var cloneDir string                                   // path to directory to clone repos in
-var skipMailmap bool                                  // whether to skip usage of the .mailmap file when querying commit history
-var gitSSLNoVerify = os.Getenv("GIT_SSL_NO_VERIFY")   // if set to anything, will not verify SSL when cloning
-var githubToken = os.Getenv("GITHUB_TOKEN")           // GitHub auth token for GitHub tables
-var sourcegraphToken = os.Getenv("SOURCEGRAPH_TOKEN") // Sourcegraph auth token for Sourcegraph queries
-var verbose bool                                      // whether or not to print logs to stderr
-var codex bool                                        // whether or not to use codex for query execution
-var logger = zerolog.Nop()                            // By default use a NOOP logger
+var format string                                // output format flag
+var templateText string                          // template of the template output format
+var presetQuery string                           // named / preset query flag
+var scriptFile string                            // path to a file of SQL statements to run
+var transaction bool                             // whether to run the statements in a transaction
+var continueOnError bool                         // whether to run the statements following a failed one
+var dbPath string                                // path to sqlite db file on disk to mount on
+var repo string                                  // path to repo on disk
+var cloneDir string                              // path to directory to clone repos in
+var skipMailmap bool                             // whether to skip usage of the mailmap when querying commits, blame and tags
+var identitiesFile string                        // path to a file mapping contributors to people, orgs and bots
+var cfg = &config.Config{}                       // configuration from the config files and env vars, see setupConfig
+var verbose bool                                 // whether or not to print logs to stderr
+var codex bool                                   // whether or not to use codex for query execution
+var timeout time.Duration                        // maximum duration of command execution, 0 means no limit
+var cancelTimeout context.CancelFunc = func() {} // releases the timeout context, if one was set
+var logger = zerolog.Nop()                       // By default use a NOOP logger
 
 func init() {
 	// local (root command only) flags
-	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. Options are 'csv' 'csv-noheader' 'tsv' 'tsv-noheader' 'table' 'single' 'ndjson' and 'json'")
-	rootCmd.Flags().StringVarP(&presetQuery, "preset", "p", "", "used to pick a preset query")
+	rootCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the output format. "+formatOptions())
+	rootCmd.Flags().StringVar(&templateText, "template", "", "Go template executed for each row with the template format, e.g. '{{.author_email}}: {{.count}}'")
+	rootCmd.Flags().StringVarP(&presetQuery, "preset", "p", "", "used to pick a preset query, see \"mergestat presets list\". Its parameters are set with --param")
+	rootCmd.Flags().StringVar(&scriptFile, "file", "", "path to a file of SQL statements (separated by semicolons) to run in order, instead of a query argument")
+	rootCmd.Flags().BoolVar(&transaction, "transaction", false, "run the statements in a transaction, which is rolled back if one fails")
+	rootCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "run the statements following a failed one (with --transaction, what succeeded is committed)")
+	addParamFlags(rootCmd)
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "db", "d", "", "specify a db file on disk to mount when executing queries")
 	rootCmd.PersistentFlags().StringVarP(&repo, "repo", "r", ".", "specify a path to a default repo on disk. This will be used if no repo is supplied as an argument to a git table")
 	rootCmd.PersistentFlags().StringVarP(&cloneDir, "clone-dir", "c", "", "specify a path to a directory on disk to use when cloning repos, instead of a tmp dir. Should be empty to avoid path conflicts.")
-	rootCmd.PersistentFlags().BoolVar(&skipMailmap, "skip-mailmap", false, "skip usage of .mailmap file when querying commit history.")
+	rootCmd.PersistentFlags().BoolVar(&skipMailmap, "skip-mailmap", false, "skip usage of the mailmap (.mailmap file, mailmap.blob and mailmap.file config) when querying commits, blame and tags.")
+	rootCmd.PersistentFlags().StringVar(&identitiesFile, "identities", "", "specify a path to a YAML (or JSON) file mapping contributor emails to people and orgs, and flagging bots. Used by the author_identity, author_org and is_bot functions, and by the summarize commands to group authors (by name and email without it)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "whether or not to print query execution logs to stderr")
 	rootCmd.PersistentFlags().BoolVarP(&codex, "codex", "x", false, "whether or not to use codex for query execution")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "maximum duration of execution (e.g. 30s, 5m) after which cloning, history walks and API calls are cancelled. For serve, this applies to each request")
 
 	// register the sqlite extension ahead of any command
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		setupLogger()
-		registerExt()
+		setupConfig(cmd)
+		setupContext(cmd)
+		if cmd == explainCmd {
+			tracer = trace.New()
+		}
+		registerExt(cmd.Context())
 	}
 
 	// add sub commands
-	rootCmd.AddCommand(exportCmd, serveCmd, summarizeCmd)
-
-	// conditionally add the pgsync sub command
-	// TODO(patrickdevivo) "conditional" for now until the behavior stabilizes
-	if os.Getenv("PGSYNC") != "" {
-		rootCmd.AddCommand(pgsyncCmd)
-	}
+	rootCmd.AddCommand(exportCmd, serveCmd, summarizeCmd, multiCmd, shellCmd, presetsCmd, configCmd, schemaCmd, explainCmd, pgsyncCmd)
 }
 
 // setupLogger sets the global logger variable according to whether the verbose flag is used
@@ -72,20 +90,85 @@ func setupLogger() {
 	logger = l
 }
 
+// setupConfig loads the config files of the repository (see --repo) and the env vars into cfg, and applies them
+// to the flags that were not set on the command line. cfg is then updated with the values of the flags,
+// so that it holds the effective configuration.
+func setupConfig(cmd *cobra.Command) {
+	var err error
+	if cfg, err = config.Load(repo); err != nil {
+		handleExitError(err)
+	}
+
+	flags := cmd.Flags()
+	if !flags.Changed("clone-dir") {
+		cloneDir = cfg.CloneDir
+	}
+	if !flags.Changed("identities") {
+		identitiesFile = cfg.Identities
+	}
+	if !flags.Changed("skip-mailmap") {
+		skipMailmap = cfg.SkipMailmap
+	}
+	if !flags.Changed("timeout") && cfg.Timeout != "" {
+		if timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+			handleExitError(fmt.Errorf("invalid timeout in config: %v", err))
+		}
+	}
+	// only these commands output with the shared format flag, others have format flags of their own
+	sharedFormat := cmd == rootCmd || cmd == multiCmd || cmd == shellCmd
+	if sharedFormat && !flags.Changed("format") && cfg.Format != "" {
+		format = cfg.Format
+	}
+
+	// pgsync is only shown once enabled (with PGSYNC, or in the config)
+	pgsyncCmd.Hidden = !cfg.Postgres.Enabled
+
+	cfg.CloneDir, cfg.Identities, cfg.SkipMailmap = cloneDir, identitiesFile, skipMailmap
+	if timeout > 0 {
+		cfg.Timeout = timeout.String()
+	}
+	if sharedFormat {
+		cfg.Format = format
+	}
+}
+
+// setupContext applies the --timeout flag to the command's context. The serve and shell commands are exempt,
+// as they apply the timeout to each request or statement they handle instead.
+func setupContext(cmd *cobra.Command) {
+	if timeout <= 0 || cmd == serveCmd || cmd == shellCmd {
+		return
+	}
+	var ctx context.Context
+	ctx, cancelTimeout = context.WithTimeout(cmd.Context(), timeout)
+	cmd.SetContext(ctx)
+}
+
 // handleExitError should be used for any errors that should stop execution of the CLI (exit)
 // it will report an error with the logger and exit with code 1
 func handleExitError(err error) {
 	if err != nil {
 		logger.Error().Msgf(err.Error())
+		removeTempDirs()
 		os.Exit(1)
 	}
 }
 
+// removeTempDirs removes the temporary directories repositories were cloned, unpacked or copied to,
+// which is done on exit (see locator.RemoveTempDirs)
+func removeTempDirs() {
+	if err := locator.RemoveTempDirs(); err != nil {
+		logger.Warn().Err(err).Msg("failed to clean up")
+	}
+}
+
 var rootCmd = &cobra.Command{
 	Use:  `mergestat "SELECT * FROM commits"`,
 	Args: cobra.MaximumNArgs(2),
 	Long: `mergestat is a CLI for querying git repositories with SQL, using SQLite virtual tables.
-Example queries can be found in the GitHub repo: https://github.com/mergestat/mergestat`,
+Example queries can be found in the GitHub repo: https://github.com/mergestat/mergestat
+The query may be a script of several statements separated by semicolons (also from a file with --file, or piped),
+which run in order on the same connection, with the results of each statement that returns rows.
+Run "mergestat shell" to run queries interactively.`,
 	Short: `Query git repositories with SQL`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var err error
@@ -95,9 +178,20 @@ Example queries can be found in the GitHub repo: https://github.com/mergestat/me
 			handleExitError(fmt.Errorf("failed to read stdin stat: %v", err))
 		}
 
+		if len(args) > 0 && scriptFile != "" {
+			handleExitError(fmt.Errorf("--file and a query argument are mutually exclusive"))
+		}
+
 		var query string
+		var preset *Preset
 		if len(args) > 0 {
 			query = args[0]
+		} else if scriptFile != "" {
+			var script []byte
+			if script, err = os.ReadFile(scriptFile); err != nil {
+				handleExitError(fmt.Errorf("failed to read script: %v", err))
+			}
+			query = string(script)
 		} else if isPiped(info) {
 			var stdin []byte
 			if stdin, err = io.ReadAll(os.Stdin); err != nil {
@@ -105,10 +199,15 @@ Example queries can be found in the GitHub repo: https://github.com/mergestat/me
 			}
 			query = string(stdin)
 		} else if presetQuery != "" {
+			var library *Library
+			if library, err = Load(DefaultDirs(repo)...); err != nil {
+				handleExitError(err)
+			}
 			var found bool
-			if query, found = Find(presetQuery); !found {
+			if preset, found = library.Find(presetQuery); !found {
 				handleExitError(fmt.Errorf("unknown preset query: %s", presetQuery))
 			}
+			query = preset.SQL
 		} else {
 			if err = cmd.Help(); err != nil {
 				handleExitError(err)
@@ -128,7 +227,7 @@ Example queries can be found in the GitHub repo: https://github.com/mergestat/me
 		}
 
 		if codex {
-			generatedSQL, err := codexToSQL(context.TODO(), query)
+			generatedSQL, err := codexToSQL(cmd.Context(), db, query)
 			if err != nil {
 				handleExitError(fmt.Errorf("failed to translate prompt to SQL: %v", err))
 			}
@@ -136,23 +235,50 @@ Example queries can be found in the GitHub repo: https://github.com/mergestat/me
 			query = generatedSQL
 		}
 
-		var rows *sql.Rows
-		if rows, err = db.Query(query); err != nil {
-			handleExitError(fmt.Errorf("query execution failed: %v", err))
+		var params []interface{}
+		if params, err = queryArgs(); err != nil {
+			handleExitError(err)
+		}
+		if preset != nil {
+			if params, err = preset.Bind(params); err != nil {
+				handleExitError(err)
+			}
 		}
-		defer rows.Close()
 
-		if err = display.WriteTo(rows, os.Stdout, format, false); err != nil {
-			handleExitError(fmt.Errorf("failed to output resultset: %v", err))
+		// the query may be a script of several statements, such as ones creating temporary tables and selecting from them
+		opts := shell.ScriptOptions{
+			Format:          format,
+			Template:        templateText,
+			Args:            params,
+			Transaction:     transaction,
+			ContinueOnError: continueOnError,
+			Errors:          os.Stderr,
+		}
+		if err = shell.RunScript(cmd.Context(), db, shell.ParseScript(query), os.Stdout, opts); err != nil {
+			if scriptFile != "" {
+				err = fmt.Errorf("%s: %v", scriptFile, err)
+			}
+			handleExitError(fmt.Errorf("query execution failed: %v", err))
 		}
 	},
 }
 
+// formatOptions lists the output formats, for the help of the format flags
+func formatOptions() string {
+	return fmt.Sprintf("Options are '%s'", strings.Join(display.Formats(), "' '"))
+}
+
 func isPiped(info os.FileInfo) bool { return info.Mode()&os.ModeCharDevice == 0 }
 
 // Execute executes the root command
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	// cancel any in-flight work (clones, API calls) when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	defer func() { cancelTimeout() }()
+	defer removeTempDirs()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		handleExitError(fmt.Errorf("execution failed: %v", err))
 	}
 }
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [name]",
+	Short: "List the tables and functions that can be queried, or describe one of them",
+	Long: `Use this command to list the tables (and table-valued functions) and the functions registered by mergestat,
+with their arguments, or to describe a table or function: its columns, arguments and the extension it belongs to.
+Arguments in brackets are optional, such as the repository of commits([repository], [ref]).
+The same information can be queried from the mergestat_tables and mergestat_functions tables, in any output format.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := sql.Open("sqlite3", ":memory:")
+		if err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+
+		var entries []*schemaEntry
+		if entries, err = loadSchema(cmd.Context(), db); err != nil {
+			handleExitError(err)
+		}
+
+		if len(args) == 0 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KIND\tNAME\tEXTENSION\tDESCRIPTION")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.kind, e.signature(), e.extension, e.description)
+			}
+			if err = w.Flush(); err != nil {
+				handleExitError(err)
+			}
+			return
+		}
+
+		// a name may be both a table and a function (such as str_split), in which case both are described
+		var b strings.Builder
+		for _, e := range entries {
+			if !strings.EqualFold(e.name, args[0]) {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n")
+			}
+			e.describe(&b)
+		}
+		if b.Len() == 0 {
+			handleExitError(fmt.Errorf("unknown table or function: %s", args[0]))
+		}
+		fmt.Print(b.String())
+	},
+}
+
+// schemaEntry is a table or function, as described by mergestat_tables and mergestat_functions
+type schemaEntry struct {
+	kind, name, extension, description string
+
+	numArgs int // of functions, -1 if variadic
+	columns []struct {
+		Name   string `json:"name"`
+		Type   string `json:"type"`
+		Hidden bool   `json:"hidden"`
+	}
+	args []struct {
+		Name        string `json:"name"`
+		Type        string `json:"type"`
+		Required    bool   `json:"required"`
+		Description string `json:"description"`
+	}
+}
+
+// loadSchema returns the tables (first) and functions registered with db, sorted by name
+func loadSchema(ctx context.Context, db *sql.DB) ([]*schemaEntry, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT 'table', name, extension, description, 0, columns, arguments FROM mergestat_tables
+		UNION ALL
+		SELECT 'function', name, extension, description, num_args, '[]', arguments FROM mergestat_functions
+		ORDER BY 1 DESC, 2`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tables and functions: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*schemaEntry
+	for rows.Next() {
+		var e schemaEntry
+		var description sql.NullString
+		var columns, args string
+		if err = rows.Scan(&e.kind, &e.name, &e.extension, &description, &e.numArgs, &columns, &args); err != nil {
+			return nil, err
+		}
+		e.description = description.String
+
+		if err = json.Unmarshal([]byte(columns), &e.columns); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(args), &e.args); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// signature returns the name of e with its arguments, the optional ones in brackets
+func (e *schemaEntry) signature() string {
+	var args = make([]string, len(e.args))
+	for i, arg := range e.args {
+		if args[i] = arg.Name; !arg.Required {
+			args[i] = "[" + arg.Name + "]"
+		}
+	}
+	return fmt.Sprintf("%s(%s)", e.name, strings.Join(args, ", "))
+}
+
+// describe writes the description, arguments and columns of e to b
+func (e *schemaEntry) describe(b *strings.Builder) {
+	fmt.Fprintf(b, "%s: %s\n", strings.ToUpper(e.kind[:1])+e.kind[1:], e.signature())
+	fmt.Fprintf(b, "Extension: %s\n", e.extension)
+	if e.description != "" {
+		fmt.Fprintf(b, "Description: %s\n", e.description)
+	}
+	if e.kind == "function" && e.numArgs < 0 {
+		b.WriteString("Arguments (variable number):\n")
+	} else if len(e.args) > 0 {
+		b.WriteString("Arguments:\n")
+	}
+	for _, arg := range e.args {
+		fmt.Fprintf(b, "  %s", arg.Name)
+		if arg.Type != "" {
+			fmt.Fprintf(b, " %s", arg.Type)
+		}
+		if arg.Required {
+			b.WriteString(" (required)")
+		}
+		if arg.Description != "" {
+			fmt.Fprintf(b, ": %s", arg.Description)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(e.columns) > 0 {
+		b.WriteString("Columns:\n")
+	}
+	for _, c := range e.columns {
+		var isArg bool
+		for _, arg := range e.args {
+			isArg = isArg || arg.Name == c.Name
+		}
+		if isArg {
+			continue
+		}
+		// hidden columns that are not arguments are only returned when selected by name, such as the tag of refs
+		column := strings.TrimSpace(c.Name + " " + c.Type)
+		if c.Hidden {
+			column += " HIDDEN"
+		}
+		fmt.Fprintf(b, "  %s\n", column)
+	}
+}
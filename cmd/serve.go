@@ -1,12 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
+	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/pkg/display"
 	"github.com/spf13/cobra"
 )
@@ -82,10 +84,34 @@ func (h *queryServiceHandler) httpHandler(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	if rows, err := h.DB.QueryContext(req.Context(), serviceQueryRequest.Query); err != nil {
+	ctx := req.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// the query runs on a connection bound to the context of the request, so that cancelling the request
+	// (or its timeout) also aborts the clones and API calls of the query, not only the query itself
+	var conn *sql.Conn
+	if conn, err = h.DB.Conn(ctx); err != nil {
+		h.handleErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer conn.Close()
+
+	var unbind func()
+	if unbind, err = extensions.BindContext(ctx, conn); err != nil {
+		h.handleErr(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer unbind()
+
+	if rows, err := conn.QueryContext(ctx, serviceQueryRequest.Query); err != nil {
 		h.handleErr(w, http.StatusInternalServerError, err)
 		return
 	} else {
+		defer rows.Close()
 		if err = display.WriteTo(rows, w, "json", false); err != nil {
 			h.handleErr(w, http.StatusInternalServerError, err)
 			return
@@ -1,11 +1,13 @@
 package cmd
 
 import (
-	"os"
+	"context"
+	"strconv"
 
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/mergestat/mergestat-lite/extensions"
 	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/identities"
 	"github.com/mergestat/mergestat-lite/pkg/locator"
 	"go.riyazali.net/sqlite"
 
@@ -14,13 +16,27 @@ import (
 	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
 )
 
-func registerExt() {
+func registerExt(ctx context.Context) {
 	multiLocOpt := &locator.MultiLocatorOptions{
 		CloneDir:        cloneDir,
-		InsecureSkipTLS: gitSSLNoVerify != "",
+		InsecureSkipTLS: cfg.InsecureSkipTLS,
 	}
-	if githubToken != "" {
-		multiLocOpt.HTTPAuth = &http.BasicAuth{Username: githubToken}
+	if cfg.GitHub.Token != "" {
+		multiLocOpt.HTTPAuth = &http.BasicAuth{Username: cfg.GitHub.Token}
+	}
+	for host, creds := range cfg.Hosts {
+		if multiLocOpt.HostAuth == nil {
+			multiLocOpt.HostAuth = make(map[string]*http.BasicAuth)
+		}
+		multiLocOpt.HostAuth[host] = &http.BasicAuth{Username: creds.Username, Password: creds.Password}
+	}
+
+	var ids *identities.Identities
+	if identitiesFile != "" {
+		var err error
+		if ids, err = identities.Load(identitiesFile); err != nil {
+			handleExitError(err)
+		}
 	}
 
 	var skipMailmapCtx string
@@ -28,23 +44,45 @@ func registerExt() {
 		skipMailmapCtx = "true"
 	}
 
+	var optional []options.OptionFn
+	if cfg.ExtensionEnabled("github") {
+		optional = append(optional, options.WithGitHub())
+	}
+	if cfg.ExtensionEnabled("sourcegraph") {
+		optional = append(optional, options.WithSourcegraph())
+	}
+	if cfg.ExtensionEnabled("npm") {
+		optional = append(optional, options.WithNPM())
+	}
+
+	var repoLocator = locator.LoggingLocator(&logger, locator.MultiLocator(multiLocOpt))
+	if tracer != nil {
+		repoLocator = tracer.Locator(repoLocator)
+		optional = append(optional, options.WithTracer(tracer))
+	}
+
+	var githubPerPage, githubRateLimit string
+	if cfg.GitHub.PerPage > 0 {
+		githubPerPage = strconv.Itoa(cfg.GitHub.PerPage)
+	}
+	if cfg.GitHub.RateLimit > 0 {
+		githubRateLimit = strconv.Itoa(cfg.GitHub.RateLimit)
+	}
+
 	sqlite.Register(
-		extensions.RegisterFn(
+		extensions.RegisterFn(append([]options.OptionFn{
 			options.WithExtraFunctions(),
-			options.WithRepoLocator(locator.CachedLocator(locator.LoggingLocator(
-				&logger,
-				locator.MultiLocator(multiLocOpt),
-			))),
+			options.WithIdentities(ids),
+			options.WithRepoLocator(locator.CachedLocator(repoLocator)),
+			options.WithRefLister(locator.RemoteRefLister(multiLocOpt)),
 			options.WithContextValue("defaultRepoPath", repo),
 			options.WithContextValue("skipMailmap", skipMailmapCtx),
-			options.WithGitHub(),
-			options.WithContextValue("githubToken", githubToken),
-			options.WithContextValue("githubPerPage", os.Getenv("GITHUB_PER_PAGE")),
-			options.WithContextValue("githubRateLimit", os.Getenv("GITHUB_RATE_LIMIT")),
-			options.WithSourcegraph(),
-			options.WithContextValue("sourcegraphToken", sourcegraphToken),
-			options.WithNPM(),
+			options.WithContextValue("githubToken", cfg.GitHub.Token),
+			options.WithContextValue("githubPerPage", githubPerPage),
+			options.WithContextValue("githubRateLimit", githubRateLimit),
+			options.WithContextValue("sourcegraphToken", cfg.Sourcegraph.Token),
 			options.WithLogger(&logger),
-		),
+			options.WithBaseContext(ctx),
+		}, optional...)...),
 	)
 }
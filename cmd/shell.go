@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/pkg/shell"
+	"github.com/spf13/cobra"
+)
+
+var shellHistoryFile string // path to the file the history of the shell is saved to
+
+func init() {
+	var history string
+	if home, err := os.UserHomeDir(); err == nil {
+		history = filepath.Join(home, ".mergestat_history")
+	}
+
+	shellCmd.Flags().StringVarP(&format, "format", "f", "table", "specify the initial output format (change it with .format). "+formatOptions())
+	shellCmd.Flags().StringVar(&templateText, "template", "", "Go template executed for each row with the template format (change it with .format template)")
+	shellCmd.Flags().StringVar(&shellHistoryFile, "history", history, "path to the file the history of the shell is saved to, history is not saved if empty")
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Start an interactive SQL shell",
+	Long: `Starts an interactive shell to run queries one after the other, on a single connection, so that repositories
+are only opened (or cloned) once and temporary tables are kept until the shell exits.
+Statements end with a semicolon and may span several lines. Press tab to complete the names of tables,
+columns (including the arguments of table-valued functions, such as repository) and functions,
+ctrl+c to interrupt a running statement, and ctrl+d (or enter .quit) to exit.
+Enter .help to list the meta-commands, such as .tables, .schema commits, .format json and .timer on.
+With --timeout, each statement is cancelled after the timeout.
+`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		var db *sql.DB
+		openPath := ":memory:"
+		if dbPath != "" {
+			if openPath, err = filepath.Abs(dbPath); err != nil {
+				handleExitError(err)
+			}
+		}
+		if db, err = sql.Open("sqlite3", openPath); err != nil {
+			handleExitError(fmt.Errorf("failed to initialize database connection: %v", err))
+		}
+		defer db.Close()
+
+		var sh *shell.Shell
+		opts := shell.Options{
+			Format:      format,
+			Template:    templateText,
+			HistoryFile: shellHistoryFile,
+			Timeout:     timeout,
+			BindContext: extensions.BindContext,
+		}
+		if sh, err = shell.New(cmd.Context(), db, opts); err != nil {
+			handleExitError(err)
+		}
+		defer sh.Close()
+
+		if err = sh.Run(cmd.Context(), os.Stdin, os.Stdout); err != nil {
+			handleExitError(err)
+		}
+	},
+}
@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 )
 
+var summarizeFormat string
+
 func init() {
-	summarizeCmd.AddCommand(summarizeCommitsCmd, summarizeBlameCmd)
+	summarizeCmd.PersistentFlags().StringVar(&summarizeFormat, "format", "text", "output format (text, json, markdown or html). HTML reports are self-contained, with charts drawn inline")
+	summarizeCmd.AddCommand(summarizeCommitsCmd, summarizeBlameCmd, summarizeHotspotsCmd, summarizeOwnershipCmd)
 }
 
 var summarizeCmd = &cobra.Command{
@@ -13,3 +20,41 @@ var summarizeCmd = &cobra.Command{
 	Short:   "Generate various summary reports",
 	Aliases: []string{"summary"},
 }
+
+// summaryUI is implemented by the TermUI of every summarize command
+type summaryUI interface {
+	tea.Model
+	PrintNoTTY() string
+	PrintJSON() string
+	PrintMarkdown() string
+	PrintHTML() string
+}
+
+// printSummary prints ui in the format selected with --format, where outputJSON is the --json flag of the command, kept as an alias of --format json.
+// As text, the summary is displayed interactively if the output is a terminal.
+func printSummary(ui summaryUI, outputJSON bool) {
+	format := summarizeFormat
+	if outputJSON {
+		format = "json"
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(ui.PrintJSON())
+	case "markdown", "md":
+		fmt.Print(ui.PrintMarkdown())
+	case "html":
+		fmt.Print(ui.PrintHTML())
+	case "text", "":
+		// check if output is a terminal (https://rosettacode.org/wiki/Check_output_device_is_a_terminal#Go)
+		if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
+			if _, err := tea.NewProgram(ui).Run(); err != nil {
+				handleExitError(err)
+			}
+		} else {
+			fmt.Print(ui.PrintNoTTY())
+		}
+	default:
+		handleExitError(fmt.Errorf("unknown summary format %q, expected one of text, json, markdown or html", format))
+	}
+}
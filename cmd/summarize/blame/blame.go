@@ -20,32 +20,46 @@ import (
 	"golang.org/x/text/message"
 )
 
+// preloadBlameSQL blames every matching file at $rev, in every repository in summarized_repositories (see preloadBlame),
+// skipping the files matching a glob in excluded_paths.
+// Authors are keyed by their identity with $by_identity (see pkg/identities), by their name and email otherwise.
+// A line is new if it was authored since $since, is_new is NULL if there is no $since. Lines authored after $until are left out.
 const preloadBlameSQL = `
 CREATE TABLE preloaded_blame AS
 SELECT
+    repositories.repository,
     files.path,
     blame.line_no,
     commits.hash,
-	commits.author_name,
-	commits.author_email,
-	commits.author_when,
+	blame.author_name,
+	blame.author_email,
+	blame.author_when,
+	author_identity(blame.author_name, blame.author_email) AS author_identity,
+	CASE WHEN $by_identity THEN author_identity(blame.author_name, blame.author_email) ELSE blame.author_name || ' <' || blame.author_email || '>' END AS author_key,
+	author_org(blame.author_name, blame.author_email) AS author_org,
 	commits.committer_name,
 	commits.committer_email,
-	commits.committer_when
-FROM files, blame('', '', files.path)
-JOIN commits ON commits.hash = blame.commit_hash
-WHERE path LIKE ?
+	commits.committer_when,
+	date(blame.author_when) >= date($since, $since_mod) AS is_new
+FROM summarized_repositories AS repositories, files(repositories.repository, $rev) AS files, blame(repositories.repository, $rev, files.path) AS blame
+JOIN commits(repositories.repository, $rev) AS commits ON commits.hash = blame.commit_hash
+WHERE path LIKE $file_path AND ($include_bots OR NOT is_bot(blame.author_name, blame.author_email))
+	AND NOT EXISTS (SELECT 1 FROM excluded_paths WHERE files.path GLOB excluded_paths.pattern)
+	AND NOT ($exclude_vendored AND enry_is_vendor(files.path))
+	AND NOT ($exclude_generated AND enry_is_generated(files.path, files.contents))
+	AND ($until = '' OR date(blame.author_when) <= date($until, $until_mod))
 `
 
 const blameSummarySQL = `
 SELECT
 	count(*) AS loc,
-	count(distinct path) AS files,
-	count(distinct(author_email || author_name)) AS authors,
+	count(distinct repository || ':' || path) AS files,
+	count(distinct(author_key)) AS authors,
 	MAX(author_when) AS latest,
 	MIN(author_when) AS oldest,
 	AVG(julianday('now') - julianday(author_when)) AS avg_age,
-	count(distinct hash) AS commits
+	count(distinct repository || ':' || hash) AS commits,
+	sum(is_new) AS new_loc
 FROM preloaded_blame
 `
 
@@ -57,44 +71,138 @@ type BlameSummary struct {
 	Oldest  sql.NullString  `db:"oldest"`
 	AvgAge  sql.NullFloat64 `db:"avg_age"`
 	Commits int             `db:"commits"`
+
+	// NewLines is the number of lines authored since the --since date, NULL without one
+	NewLines sql.NullInt64 `db:"new_loc"`
 }
 
 const blameAuthorSummarySQL = `
 SELECT
-	author_name, author_email,
+	max(author_identity) AS author_name, max(author_email) AS author_email, max(author_org) AS author_org,
 	count(*) AS loc,
 	MAX(author_when) AS latest,
 	MIN(author_when) AS oldest,
 	AVG(julianday('now') - julianday(author_when)) AS avg_age,
-	count(distinct hash) AS commits,
+	count(distinct repository || ':' || hash) AS commits,
+	sum(is_new) AS new_loc,
 	json_group_array(path) AS files
 FROM preloaded_blame
-GROUP BY author_name, author_email
+GROUP BY author_key
+ORDER BY loc DESC
+`
+
+const blameRepositorySummarySQL = `
+SELECT
+	repository,
+	count(*) AS loc,
+	count(distinct path) AS files,
+	count(distinct(author_key)) AS authors,
+	AVG(julianday('now') - julianday(author_when)) AS avg_age,
+	count(distinct hash) AS commits
+FROM preloaded_blame
+GROUP BY repository
 ORDER BY loc DESC
 `
 
+type BlameRepositorySummary struct {
+	Repository string          `db:"repository"`
+	Lines      int             `db:"loc"`
+	Files      int             `db:"files"`
+	Authors    int             `db:"authors"`
+	AvgAge     sql.NullFloat64 `db:"avg_age"`
+	Commits    int             `db:"commits"`
+}
+
+// blameAgeSQL counts lines by how long ago they were authored, in the buckets of blameAgeBuckets
+const blameAgeSQL = `
+SELECT
+	CASE
+		WHEN age < 30 THEN 0
+		WHEN age < 90 THEN 1
+		WHEN age < 180 THEN 2
+		WHEN age < 365 THEN 3
+		WHEN age < 730 THEN 4
+		WHEN age < 1825 THEN 5
+		ELSE 6
+	END AS bucket,
+	count(*) AS loc
+FROM (SELECT julianday('now') - julianday(author_when) AS age FROM preloaded_blame)
+GROUP BY bucket
+ORDER BY bucket
+`
+
+// blameAgeBuckets labels the buckets of blameAgeSQL
+var blameAgeBuckets = []string{"< 1 month", "1-3 months", "3-6 months", "6-12 months", "1-2 years", "2-5 years", "5+ years"}
+
+type BlameAge struct {
+	Bucket int `db:"bucket"`
+	Lines  int `db:"loc"`
+}
+
+// Options configures what a TermUI summarizes
+type Options struct {
+	// PathPattern only includes files matching it (with LIKE), all files if empty
+	PathPattern string
+
+	// ExcludeBots excludes lines authored by bots (see pkg/identities)
+	ExcludeBots bool
+
+	// ByIdentity groups authors by identity (set when identities are configured), rather than by name and email
+	ByIdentity bool
+
+	// Repos are the repositories to summarize (paths or URLs), the default repository if empty.
+	// The text and report outputs only break the summary down by repository if there is more than one.
+	Repos []string
+
+	// Rev is the revision (branch, tag or commit) to blame, HEAD if empty
+	Rev string
+
+	// Since classifies lines authored since then as new, and the others as legacy.
+	// Lines authored after Until are left out.
+	// Both are either a YYYY-MM-DD date or a SQLite date modifier relative to 'now'.
+	Since, Until string
+
+	// Exclude skips the files matching any of these globs (with GLOB)
+	Exclude []string
+
+	// ExcludeVendored and ExcludeGenerated skip vendored and generated files (as detected by enry)
+	ExcludeVendored, ExcludeGenerated bool
+}
+
 type BlameAuthorSummary struct {
 	AuthorName  string          `db:"author_name"`
 	AuthorEmail string          `db:"author_email"`
+	AuthorOrg   sql.NullString  `db:"author_org"`
 	Lines       int             `db:"loc"`
 	Latest      sql.NullString  `db:"latest"`
 	Oldest      sql.NullString  `db:"oldest"`
 	AvgAge      sql.NullFloat64 `db:"avg_age"`
 	Commits     int             `db:"commits"`
+	NewLines    sql.NullInt64   `db:"new_loc"`
 	Files       string          `db:"files"`
 }
 
 type TermUI struct {
 	db                   *sqlx.DB
 	pathPattern          string
+	excludeBots          bool
+	byIdentity           bool
+	repos                []string
+	rev                  string
+	since, until         dateFilter
+	exclude              []string
+	excludeVendored      bool
+	excludeGenerated     bool
 	err                  error
 	spinner              spinner.Model
 	blamePreloaded       bool
 	blameSummary         *BlameSummary
 	blameAuthorSummaries *[]*BlameAuthorSummary
+	repoSummaries        *[]*BlameRepositorySummary
+	ages                 []int // lines per bucket of blameAgeBuckets
 }
 
-func NewTermUI(pathPattern string) (*TermUI, error) {
+func NewTermUI(opts Options) (*TermUI, error) {
 	var db *sqlx.DB
 	var err error
 	if db, err = sqlx.Open("sqlite3", "file::memory:?cache=shared"); err != nil {
@@ -108,28 +216,89 @@ func NewTermUI(pathPattern string) (*TermUI, error) {
 		FPS:    300 * time.Millisecond,
 	}
 
+	pathPattern := opts.PathPattern
 	if pathPattern == "" {
 		pathPattern = "%"
 	}
 
+	// an empty repository is the default one
+	repos := opts.Repos
+	if len(repos) == 0 {
+		repos = []string{""}
+	}
+
 	return &TermUI{
-		db:          db,
-		pathPattern: pathPattern,
-		spinner:     s,
+		db:               db,
+		pathPattern:      pathPattern,
+		excludeBots:      opts.ExcludeBots,
+		byIdentity:       opts.ByIdentity,
+		repos:            repos,
+		rev:              opts.Rev,
+		since:            newDateFilter(opts.Since),
+		until:            newDateFilter(opts.Until),
+		exclude:          opts.Exclude,
+		excludeVendored:  opts.ExcludeVendored,
+		excludeGenerated: opts.ExcludeGenerated,
+		spinner:          s,
 	}, nil
 }
 
+type dateFilter struct {
+	date string
+	mod  string
+}
+
+// newDateFilter returns a filter on a YYYY-MM-DD date, or on a date modifier relative to 'now' if d cannot be parsed.
+// An empty d is an empty filter, for which date() returns NULL.
+func newDateFilter(d string) dateFilter {
+	if _, err := time.Parse("2006-01-02", d); err != nil && d != "" {
+		return dateFilter{date: "now", mod: d}
+	}
+	return dateFilter{date: d, mod: "0 days"}
+}
+
 func (t *TermUI) Init() tea.Cmd {
 	return tea.Batch(
 		t.spinner.Tick,
 		t.preloadBlame,
 		t.loadBlameSummary,
 		t.loadBlameAuthorSummary,
+		t.loadBlameRepositorySummary,
 	)
 }
 
 func (t *TermUI) preloadBlame() tea.Msg {
-	if _, err := t.db.Exec(preloadBlameSQL, t.pathPattern); err != nil {
+	if _, err := t.db.Exec("CREATE TABLE summarized_repositories (repository TEXT)"); err != nil {
+		return err
+	}
+	for _, repo := range t.repos {
+		if _, err := t.db.Exec("INSERT INTO summarized_repositories VALUES (?)", repo); err != nil {
+			return err
+		}
+	}
+
+	if _, err := t.db.Exec("CREATE TABLE excluded_paths (pattern TEXT)"); err != nil {
+		return err
+	}
+	for _, pattern := range t.exclude {
+		if _, err := t.db.Exec("INSERT INTO excluded_paths VALUES (?)", pattern); err != nil {
+			return err
+		}
+	}
+
+	args := []interface{}{
+		sql.Named("rev", t.rev),
+		sql.Named("file_path", t.pathPattern),
+		sql.Named("include_bots", !t.excludeBots),
+		sql.Named("by_identity", t.byIdentity),
+		sql.Named("exclude_vendored", t.excludeVendored),
+		sql.Named("exclude_generated", t.excludeGenerated),
+		sql.Named("since", t.since.date),
+		sql.Named("since_mod", t.since.mod),
+		sql.Named("until", t.until.date),
+		sql.Named("until_mod", t.until.mod),
+	}
+	if _, err := t.db.Exec(preloadBlameSQL, args...); err != nil {
 		return err
 	}
 
@@ -163,6 +332,19 @@ func (t *TermUI) loadBlameAuthorSummary() tea.Msg {
 	return nil
 }
 
+func (t *TermUI) loadBlameRepositorySummary() tea.Msg {
+	for !t.blamePreloaded {
+		time.Sleep(300 * time.Millisecond)
+	}
+	var repoSummaries []*BlameRepositorySummary
+	if err := t.db.Select(&repoSummaries, blameRepositorySummarySQL); err != nil {
+		return err
+	}
+
+	t.repoSummaries = &repoSummaries
+	return nil
+}
+
 func (t *TermUI) renderDurationString(d time.Duration) string {
 	f := timediff.WithCustomFormatters(locale.Formatters{
 		time.Second:           func(_ time.Duration) string { return "<none>" },
@@ -193,10 +375,11 @@ func (t *TermUI) renderBlameSummaryTable(boldHeader bool) string {
 	p := message.NewPrinter(language.English)
 	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
 
-	var files, authors, commits, avgAge, lines string
+	var files, authors, commits, avgAge, lines, newLines, legacyLines string
 	firstCommit, lastCommit := "<none>", "<none>"
 
 	if t.blameSummary != nil {
+		newLines, legacyLines = t.renderNewAndLegacyLines(t.blameSummary.Lines, t.blameSummary.NewLines.Int64)
 		files = p.Sprintf("%d", t.blameSummary.Files)
 		authors = p.Sprintf("%d", t.blameSummary.Authors)
 		commits = p.Sprintf("%d", t.blameSummary.Commits)
@@ -224,6 +407,8 @@ func (t *TermUI) renderBlameSummaryTable(boldHeader bool) string {
 		commits = t.spinner.View()
 		avgAge = t.spinner.View()
 		lines = t.spinner.View()
+		newLines = t.spinner.View()
+		legacyLines = t.spinner.View()
 		firstCommit = t.spinner.View()
 		lastCommit = t.spinner.View()
 	}
@@ -233,12 +418,20 @@ func (t *TermUI) renderBlameSummaryTable(boldHeader bool) string {
 	rows := []string{
 		strings.Join([]string{headingStyle.Render("Matched Files"), files}, "\t"),
 		strings.Join([]string{headingStyle.Render("Total Lines"), lines}, "\t"),
+	}
+	if t.since.date != "" {
+		rows = append(rows,
+			strings.Join([]string{headingStyle.Render("New Lines"), newLines}, "\t"),
+			strings.Join([]string{headingStyle.Render("Legacy Lines"), legacyLines}, "\t"),
+		)
+	}
+	rows = append(rows, []string{
 		strings.Join([]string{headingStyle.Render("Distinct Authors"), authors}, "\t"),
 		strings.Join([]string{headingStyle.Render("Commits"), commits}, "\t"),
 		strings.Join([]string{headingStyle.Render("Avg. Age of Lines"), avgAge}, "\t"),
 		strings.Join([]string{headingStyle.Render("Oldest Line"), firstCommit}, "\t"),
 		strings.Join([]string{headingStyle.Render("Newest Line"), lastCommit}, "\t"),
-	}
+	}...)
 
 	p.Fprintln(w, strings.Join(rows, "\n"))
 	if err := w.Flush(); err != nil {
@@ -262,15 +455,11 @@ func (t *TermUI) renderBlameAuthorSummary(limit int) string {
 			return "<no authors>"
 		}
 
-		r := strings.Join([]string{
-			"Author",
-			"Blameable Lines",
-			"Line %",
-			"Commits",
-			"Avg. Age",
-			"First Commit",
-			"Latest Commit",
-		}, "\t")
+		headings := []string{"Author", "Blameable Lines", "Line %", "Commits", "Avg. Age", "First Commit", "Latest Commit"}
+		if t.since.date != "" {
+			headings = append(headings, "New Lines")
+		}
+		r := strings.Join(headings, "\t")
 
 		p.Fprintln(w, r)
 
@@ -301,15 +490,25 @@ func (t *TermUI) renderBlameAuthorSummary(limit int) string {
 				avgAgeDur = time.Duration((authorRow.AvgAge.Float64 * 24 * float64(time.Hour.Nanoseconds())))
 			}
 
-			r := strings.Join([]string{
-				authorRow.AuthorName,
+			author := authorRow.AuthorName
+			if authorRow.AuthorOrg.Valid {
+				author = fmt.Sprintf("%s (%s)", author, authorRow.AuthorOrg.String)
+			}
+
+			cells := []string{
+				author,
 				p.Sprintf("%d", authorRow.Lines),
 				p.Sprintf("%.2f%%", linesPercent),
 				p.Sprintf("%d", authorRow.Commits),
 				p.Sprintf("%s", t.renderDurationString(avgAgeDur)),
 				p.Sprintf("%s (%s)", timediff.TimeDiff(firstCommit), firstCommit.Format("2006-01-02")),
 				p.Sprintf("%s (%s)", timediff.TimeDiff(lastCommit), lastCommit.Format("2006-01-02")),
-			}, "\t")
+			}
+			if t.since.date != "" {
+				newLines, _ := t.renderNewAndLegacyLines(authorRow.Lines, authorRow.NewLines.Int64)
+				cells = append(cells, newLines)
+			}
+			r := strings.Join(cells, "\t")
 
 			p.Fprintln(w, r)
 		}
@@ -333,6 +532,72 @@ func (t *TermUI) renderBlameAuthorSummary(limit int) string {
 	return b.String()
 }
 
+// renderNewAndLegacyLines renders how many of lines are new (authored since --since) and how many are legacy
+func (t *TermUI) renderNewAndLegacyLines(lines int, newLines int64) (string, string) {
+	p := message.NewPrinter(language.English)
+	if lines == 0 {
+		return "0", "0"
+	}
+	legacyLines := int64(lines) - newLines
+	return p.Sprintf("%d (%.2f%%)", newLines, float64(newLines)/float64(lines)*100.0),
+		p.Sprintf("%d (%.2f%%)", legacyLines, float64(legacyLines)/float64(lines)*100.0)
+}
+
+func (t *TermUI) renderBlameRepositorySummary() string {
+	var b bytes.Buffer
+	p := message.NewPrinter(language.English)
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	// the breakdown is only useful when summarizing more than one repository
+	if len(t.repos) < 2 {
+		return ""
+	}
+
+	if t.repoSummaries != nil && t.blameSummary != nil {
+		r := strings.Join([]string{
+			"Repository",
+			"Blameable Lines",
+			"Line %",
+			"Files",
+			"Authors",
+			"Commits",
+			"Avg. Age",
+		}, "\t")
+
+		p.Fprintln(w, r)
+
+		for _, repoRow := range *t.repoSummaries {
+			linesPercent := (float32(repoRow.Lines) / float32(t.blameSummary.Lines)) * 100.0
+
+			var avgAgeDur time.Duration
+			if repoRow.AvgAge.Valid {
+				avgAgeDur = time.Duration((repoRow.AvgAge.Float64 * 24 * float64(time.Hour.Nanoseconds())))
+			}
+
+			r := strings.Join([]string{
+				repoRow.Repository,
+				p.Sprintf("%d", repoRow.Lines),
+				p.Sprintf("%.2f%%", linesPercent),
+				p.Sprintf("%d", repoRow.Files),
+				p.Sprintf("%d", repoRow.Authors),
+				p.Sprintf("%d", repoRow.Commits),
+				p.Sprintf("%s", t.renderDurationString(avgAgeDur)),
+			}, "\t")
+
+			p.Fprintln(w, r)
+		}
+
+		if err := w.Flush(); err != nil {
+			return err.Error()
+		}
+	} else {
+		p.Fprintln(&b, "Loading repositories", t.spinner.View())
+	}
+
+	p.Fprintln(&b)
+	return b.String()
+}
+
 func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case error:
@@ -347,7 +612,7 @@ func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	default:
-		if t.blameSummary != nil && t.blameAuthorSummaries != nil && t.blamePreloaded {
+		if t.blameSummary != nil && t.blameAuthorSummaries != nil && t.repoSummaries != nil && t.blamePreloaded {
 			return t, tea.Quit
 		}
 		var cmd tea.Cmd
@@ -365,6 +630,7 @@ func (t *TermUI) View() string {
 
 	var b bytes.Buffer
 	fmt.Fprint(&b, t.renderBlameSummaryTable(true))
+	fmt.Fprint(&b, t.renderBlameRepositorySummary())
 	fmt.Fprint(&b, t.renderBlameAuthorSummary(25))
 
 	return b.String()
@@ -375,6 +641,7 @@ func (t *TermUI) PrintNoTTY() string {
 	t.preloadBlame()
 	t.loadBlameSummary()
 	t.loadBlameAuthorSummary()
+	t.loadBlameRepositorySummary()
 
 	if t.err != nil {
 		return t.err.Error()
@@ -382,6 +649,7 @@ func (t *TermUI) PrintNoTTY() string {
 
 	var b bytes.Buffer
 	fmt.Fprint(&b, t.renderBlameSummaryTable(false))
+	fmt.Fprint(&b, t.renderBlameRepositorySummary())
 	fmt.Fprint(&b, t.renderBlameAuthorSummary(0))
 
 	return b.String()
@@ -392,6 +660,7 @@ func (t *TermUI) PrintJSON() string {
 	t.preloadBlame()
 	t.loadBlameSummary()
 	t.loadBlameAuthorSummary()
+	t.loadBlameRepositorySummary()
 
 	if t.err != nil {
 		return t.err.Error()
@@ -419,6 +688,12 @@ func (t *TermUI) PrintJSON() string {
 		output["newestLine"] = t.blameSummary.Latest.String
 	}
 
+	// lines are only classified as new or legacy with --since
+	if t.since.date != "" {
+		output["newLines"] = t.blameSummary.NewLines.Int64
+		output["legacyLines"] = int64(t.blameSummary.Lines) - t.blameSummary.NewLines.Int64
+	}
+
 	authorSummaries := make([]map[string]interface{}, len(*t.blameAuthorSummaries))
 
 	for i, authorSummary := range *t.blameAuthorSummaries {
@@ -447,6 +722,7 @@ func (t *TermUI) PrintJSON() string {
 		authorSummaries[i] = map[string]interface{}{
 			"name":           authorSummary.AuthorName,
 			"email":          authorSummary.AuthorEmail,
+			"org":            nil,
 			"blameableLines": authorSummary.Lines,
 			"linePercent":    linesPercent,
 			"commits":        authorSummary.Commits,
@@ -455,10 +731,34 @@ func (t *TermUI) PrintJSON() string {
 			"oldestLine":     firstCommit.Format(time.RFC3339),
 			"newestLine":     lastCommit.Format(time.RFC3339),
 		}
+		if authorSummary.AuthorOrg.Valid {
+			authorSummaries[i]["org"] = authorSummary.AuthorOrg.String
+		}
+		if t.since.date != "" {
+			authorSummaries[i]["newLines"] = authorSummary.NewLines.Int64
+		}
 	}
 
 	output["authors"] = authorSummaries
 
+	repoSummaries := make([]map[string]interface{}, len(*t.repoSummaries))
+
+	for i, repoSummary := range *t.repoSummaries {
+		repoSummaries[i] = map[string]interface{}{
+			"repository":      repoSummary.Repository,
+			"totalLines":      repoSummary.Lines,
+			"matchedFiles":    repoSummary.Files,
+			"distinctAuthors": repoSummary.Authors,
+			"commits":         repoSummary.Commits,
+			"avgAgeLines":     nil,
+		}
+		if repoSummary.AvgAge.Valid {
+			repoSummaries[i]["avgAgeLines"] = repoSummary.AvgAge.Float64
+		}
+	}
+
+	output["repositories"] = repoSummaries
+
 	if o, err := json.MarshalIndent(output, "", "  "); err != nil {
 		return err.Error()
 	} else {
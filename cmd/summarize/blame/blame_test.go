@@ -0,0 +1,141 @@
+package blame
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/cmd/summarize/internal/summarizetest"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+func TestNewDateFilter(t *testing.T) {
+	var cases = []struct {
+		d        string
+		expected dateFilter
+	}{
+		{"", dateFilter{date: "", mod: "0 days"}},
+		{"2024-01-02", dateFilter{date: "2024-01-02", mod: "0 days"}},
+		{"-1 year", dateFilter{date: "now", mod: "-1 year"}},
+		{"-6 months", dateFilter{date: "now", mod: "-6 months"}},
+		{"2024-13-01", dateFilter{date: "now", mod: "2024-13-01"}}, // not a date, so left for SQLite to reject
+	}
+	for _, c := range cases {
+		if filter := newDateFilter(c.d); filter != c.expected {
+			t.Errorf("newDateFilter(%q) = %+v, want %+v", c.d, filter, c.expected)
+		}
+	}
+}
+
+// blameFixture creates a repository where Jane Doe wrote a.go (4 lines), vendor/v.go (2 lines)
+// and docs/guide.md (3 lines) in 2020, then Bob b.go (2 lines) in 2021, and makes it the current directory.
+// It returns the path of the repository and the hash of the first commit.
+func blameFixture(t *testing.T) (string, string) {
+	repo := fixture.NewRepo(t)
+	first := repo.Commit("first", map[string]string{
+		"a.go": summarizetest.Lines(4), "vendor/v.go": summarizetest.Lines(2), "docs/guide.md": summarizetest.Lines(3),
+	})
+
+	repo.Author.Name, repo.Author.Email = "Bob", "bob@example.com"
+	repo.Author.When = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo.Commit("second", map[string]string{"b.go": summarizetest.Lines(2)})
+
+	summarizetest.Chdir(t, repo.Dir)
+	return repo.Dir, first
+}
+
+type blameJSON struct {
+	TotalLines   int    `json:"totalLines"`
+	MatchedFiles int    `json:"matchedFiles"`
+	NewLines     *int64 `json:"newLines"`
+	LegacyLines  *int64 `json:"legacyLines"`
+	Repositories []struct {
+		Repository string `json:"repository"`
+		TotalLines int    `json:"totalLines"`
+	} `json:"repositories"`
+}
+
+func TestBlameExclude(t *testing.T) {
+	blameFixture(t)
+
+	var cases = []struct {
+		name         string
+		opts         Options
+		lines, files int
+	}{
+		{"everything", Options{}, 11, 4},
+		{"path pattern", Options{PathPattern: "%.go"}, 8, 3},
+		{"directory glob", Options{Exclude: []string{"docs/*"}}, 8, 3},
+		{"several globs", Options{Exclude: []string{"*.md", "vendor/*"}}, 6, 2},
+		{"glob on a nested path", Options{Exclude: []string{"*/v.go"}}, 9, 3},
+		{"glob matching nothing", Options{Exclude: []string{"*.py"}}, 11, 4},
+		{"vendored", Options{ExcludeVendored: true}, 9, 3},
+		{"until", Options{Until: "2020-12-31"}, 9, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			summary := summarizetest.JSON[blameJSON](t)(NewTermUI(c.opts))
+			if summary.TotalLines != c.lines || summary.MatchedFiles != c.files {
+				t.Fatalf("expected %d lines in %d files, got %d in %d", c.lines, c.files, summary.TotalLines, summary.MatchedFiles)
+			}
+		})
+	}
+}
+
+func TestBlameIsNew(t *testing.T) {
+	blameFixture(t)
+
+	var cases = []struct {
+		name             string
+		opts             Options
+		newLines, legacy int64
+	}{
+		{"since a date", Options{Since: "2021-01-01"}, 2, 9},
+		{"since the day of a commit", Options{Since: "2021-06-01"}, 2, 9},
+		{"since after every commit", Options{Since: "2022-01-01"}, 0, 11},
+		{"since before every commit", Options{Since: "2019-01-01"}, 11, 0},
+		{"since relative to now", Options{Since: "-1 year"}, 0, 11},
+		{"since and until", Options{Since: "2020-01-01", Until: "2020-12-31"}, 9, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			summary := summarizetest.JSON[blameJSON](t)(NewTermUI(c.opts))
+			if summary.NewLines == nil || summary.LegacyLines == nil {
+				t.Fatalf("expected new and legacy lines with --since, got: %+v", summary)
+			}
+			if *summary.NewLines != c.newLines || *summary.LegacyLines != c.legacy {
+				t.Fatalf("expected %d new and %d legacy lines, got %d and %d", c.newLines, c.legacy, *summary.NewLines, *summary.LegacyLines)
+			}
+		})
+	}
+
+	// without --since, lines are not classified
+	if summary := summarizetest.JSON[blameJSON](t)(NewTermUI(Options{})); summary.NewLines != nil || summary.LegacyLines != nil {
+		t.Fatalf("expected no new and legacy lines without --since, got: %+v", summary)
+	}
+}
+
+func TestBlameReposAndRev(t *testing.T) {
+	dir, first := blameFixture(t)
+
+	other := fixture.NewRepo(t)
+	other.Commit("first", map[string]string{"c.go": summarizetest.Lines(5)})
+
+	// repositories come by number of lines, most first
+	summary := summarizetest.JSON[blameJSON](t)(NewTermUI(Options{Repos: []string{dir, other.Dir}}))
+	if summary.TotalLines != 16 || summary.MatchedFiles != 5 || len(summary.Repositories) != 2 {
+		t.Fatalf("expected 16 lines in 5 files of 2 repositories, got: %+v", summary)
+	}
+	if repo := summary.Repositories[0]; repo.Repository != dir || repo.TotalLines != 11 {
+		t.Errorf("expected 11 lines in %s, got: %+v", dir, repo)
+	}
+	if repo := summary.Repositories[1]; repo.Repository != other.Dir || repo.TotalLines != 5 {
+		t.Errorf("expected 5 lines in %s, got: %+v", other.Dir, repo)
+	}
+
+	// at the first commit, b.go does not exist yet
+	for _, rev := range []string{first, "HEAD~1"} {
+		if summary := summarizetest.JSON[blameJSON](t)(NewTermUI(Options{Rev: rev})); summary.TotalLines != 9 || summary.MatchedFiles != 3 {
+			t.Errorf("expected 9 lines in 3 files at %s, got: %+v", rev, summary)
+		}
+	}
+}
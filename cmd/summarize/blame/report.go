@@ -0,0 +1,180 @@
+package blame
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mergestat/mergestat-lite/cmd/summarize/report"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// reportTopAuthors is the number of authors charted in reports
+const reportTopAuthors = 10
+
+func (t *TermUI) loadBlameAges() tea.Msg {
+	for !t.blamePreloaded {
+		time.Sleep(300 * time.Millisecond)
+	}
+	var ages []*BlameAge
+	if err := t.db.Select(&ages, blameAgeSQL); err != nil {
+		return err
+	}
+
+	// buckets without any lines are left at 0
+	t.ages = make([]int, len(blameAgeBuckets))
+	for _, age := range ages {
+		t.ages[age.Bucket] = age.Lines
+	}
+	return nil
+}
+
+// load preloads and loads everything a report is built from, outside of the bubbletea program
+func (t *TermUI) load() {
+	for _, fn := range []func() tea.Msg{t.preloadBlame, t.loadBlameSummary, t.loadBlameAuthorSummary, t.loadBlameRepositorySummary, t.loadBlameAges} {
+		if err, ok := fn().(error); ok {
+			t.err = err
+			return
+		}
+	}
+}
+
+// avgAge converts an average age in days, as queried, to a duration
+func avgAge(days float64) time.Duration {
+	return time.Duration(days * 24 * float64(time.Hour.Nanoseconds()))
+}
+
+// Report builds a report of the summary, as rendered by PrintMarkdown and PrintHTML
+func (t *TermUI) Report() (*report.Report, error) {
+	if t.load(); t.err != nil {
+		return nil, t.err
+	}
+
+	p := message.NewPrinter(language.English)
+
+	var subtitle []string
+	if repos := strings.Join(t.repos, ", "); repos != "" {
+		subtitle = append(subtitle, repos)
+	}
+	if t.rev != "" {
+		subtitle = append(subtitle, "at "+t.rev)
+	}
+	if t.pathPattern != "%" {
+		subtitle = append(subtitle, "files matching "+t.pathPattern)
+	}
+	if len(t.exclude) > 0 {
+		subtitle = append(subtitle, "excluding "+strings.Join(t.exclude, ", "))
+	}
+
+	var r = &report.Report{Title: "Blame Summary", Subtitle: strings.Join(subtitle, ", ")}
+
+	var facts = []report.Fact{
+		{Name: "Matched Files", Value: p.Sprintf("%d", t.blameSummary.Files)},
+		{Name: "Total Lines", Value: p.Sprintf("%d", t.blameSummary.Lines)},
+	}
+	if t.since.date != "" {
+		newLines, legacyLines := t.renderNewAndLegacyLines(t.blameSummary.Lines, t.blameSummary.NewLines.Int64)
+		facts = append(facts, report.Fact{Name: "New Lines", Value: newLines}, report.Fact{Name: "Legacy Lines", Value: legacyLines})
+	}
+	facts = append(facts,
+		report.Fact{Name: "Distinct Authors", Value: p.Sprintf("%d", t.blameSummary.Authors)},
+		report.Fact{Name: "Commits", Value: p.Sprintf("%d", t.blameSummary.Commits)},
+		report.Fact{Name: "Avg. Age of Lines", Value: t.renderDurationString(avgAge(t.blameSummary.AvgAge.Float64))},
+		report.Fact{Name: "Oldest Line", Value: report.FormatDate(t.blameSummary.Oldest.String)},
+		report.Fact{Name: "Newest Line", Value: report.FormatDate(t.blameSummary.Latest.String)},
+	)
+	r.Sections = append(r.Sections, &report.Facts{Facts: facts})
+
+	var ages = &report.BarChart{Title: "Age of Lines", Unit: "lines"}
+	for i, label := range blameAgeBuckets {
+		ages.Bars = append(ages.Bars, report.Bar{Label: label, Value: float64(t.ages[i])})
+	}
+	r.Sections = append(r.Sections, ages)
+
+	var topAuthors = &report.BarChart{Title: "Top Authors", Unit: "lines"}
+	for i, author := range *t.blameAuthorSummaries {
+		if i == reportTopAuthors {
+			break
+		}
+		topAuthors.Bars = append(topAuthors.Bars, report.Bar{Label: author.AuthorName, Value: float64(author.Lines)})
+	}
+	r.Sections = append(r.Sections, topAuthors)
+
+	// the breakdown is only useful when summarizing more than one repository
+	if len(t.repos) > 1 {
+		var repos = &report.Table{
+			Title:   "Repositories",
+			Columns: []string{"Repository", "Blameable Lines", "Line %", "Files", "Authors", "Commits", "Avg. Age"},
+		}
+		for _, repo := range *t.repoSummaries {
+			repos.Rows = append(repos.Rows, []string{
+				repo.Repository,
+				p.Sprintf("%d", repo.Lines),
+				p.Sprintf("%.2f%%", (float32(repo.Lines)/float32(t.blameSummary.Lines))*100.0),
+				p.Sprintf("%d", repo.Files),
+				p.Sprintf("%d", repo.Authors),
+				p.Sprintf("%d", repo.Commits),
+				t.renderDurationString(avgAge(repo.AvgAge.Float64)),
+			})
+		}
+		r.Sections = append(r.Sections, repos)
+	}
+
+	var authors = &report.Table{
+		Title:   "Authors",
+		Columns: []string{"Author", "Blameable Lines", "Line %", "Commits", "Avg. Age", "First Commit", "Latest Commit"},
+	}
+	if t.since.date != "" {
+		authors.Columns = append(authors.Columns, "New Lines")
+	}
+	for _, author := range *t.blameAuthorSummaries {
+		name := author.AuthorName
+		if author.AuthorOrg.Valid {
+			name = fmt.Sprintf("%s (%s)", name, author.AuthorOrg.String)
+		}
+
+		row := []string{
+			name,
+			p.Sprintf("%d", author.Lines),
+			p.Sprintf("%.2f%%", (float32(author.Lines)/float32(t.blameSummary.Lines))*100.0),
+			p.Sprintf("%d", author.Commits),
+			t.renderDurationString(avgAge(author.AvgAge.Float64)),
+			report.FormatDate(author.Oldest.String),
+			report.FormatDate(author.Latest.String),
+		}
+		if t.since.date != "" {
+			newLines, _ := t.renderNewAndLegacyLines(author.Lines, author.NewLines.Int64)
+			row = append(row, newLines)
+		}
+		authors.Rows = append(authors.Rows, row)
+	}
+	r.Sections = append(r.Sections, authors)
+
+	return r, nil
+}
+
+// PrintMarkdown outputs summary results as a markdown report
+func (t *TermUI) PrintMarkdown() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+	return report.Markdown(r)
+}
+
+// PrintHTML outputs summary results as a self-contained HTML report
+func (t *TermUI) PrintHTML() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+
+	page, err := report.HTML(r)
+	if err != nil {
+		t.err = err
+		return err.Error()
+	}
+	return page
+}
@@ -38,15 +38,19 @@ type CommitSummary struct {
 // When a path filter is supplied by the user, we do apply it. Note that even supplying just a '%'
 // will exclude empty commits from the resultset. This makes sense, because empty commits won't have
 // changed any files in the specified pattern (they won't have changed any files at all).
+//
+// Both queries summarize every repository in summarized_repositories (see preloadCommits),
+// and commits are keyed by repository and hash, as the same commit may be in more than one repository.
+// Authors are keyed by their identity with $by_identity (see pkg/identities), by their name and email otherwise.
 const preloadCommitsWithFilePathPatternSQL = `
-CREATE TABLE preloaded_commit_stats AS SELECT * FROM commits LEFT JOIN stats('', commits.hash) WHERE file_path LIKE $file_path AND author_when > date($start, $start_mod) AND author_when < date($end, $end_mod);
-CREATE TABLE preloaded_commits AS SELECT hash, author_name, author_email, author_when, parents FROM preloaded_commit_stats GROUP BY hash;
+CREATE TABLE preloaded_commit_stats AS SELECT *, author_identity(author_name, author_email) AS author_identity, CASE WHEN $by_identity THEN author_identity(author_name, author_email) ELSE author_name || ' <' || author_email || '>' END AS author_key, author_org(author_name, author_email) AS author_org FROM summarized_repositories AS repositories, commits(repositories.repository, $rev) AS commits LEFT JOIN stats(repositories.repository, commits.hash) WHERE file_path LIKE $file_path AND author_when > date($start, $start_mod) AND author_when < date($end, $end_mod) AND ($include_bots OR NOT is_bot(author_name, author_email));
+CREATE TABLE preloaded_commits AS SELECT repository, hash, author_name, author_email, author_identity, author_key, author_when, parents FROM preloaded_commit_stats GROUP BY repository, hash;
 `
 
 // See comment above
 const preloadCommitsWithoutFilePathPatternSQL = `
-CREATE TABLE preloaded_commit_stats AS SELECT * FROM commits LEFT JOIN stats('', commits.hash) WHERE author_when > date($start, $start_mod) AND author_when < date($end, $end_mod);
-CREATE TABLE preloaded_commits AS SELECT hash, author_name, author_email, author_when, parents FROM preloaded_commit_stats GROUP BY hash;
+CREATE TABLE preloaded_commit_stats AS SELECT *, author_identity(author_name, author_email) AS author_identity, CASE WHEN $by_identity THEN author_identity(author_name, author_email) ELSE author_name || ' <' || author_email || '>' END AS author_key, author_org(author_name, author_email) AS author_org FROM summarized_repositories AS repositories, commits(repositories.repository, $rev) AS commits LEFT JOIN stats(repositories.repository, commits.hash) WHERE author_when > date($start, $start_mod) AND author_when < date($end, $end_mod) AND ($include_bots OR NOT is_bot(author_name, author_email));
+CREATE TABLE preloaded_commits AS SELECT repository, hash, author_name, author_email, author_identity, author_key, author_when, parents FROM preloaded_commit_stats GROUP BY repository, hash;
 `
 
 const commitSummarySQL = `
@@ -55,13 +59,14 @@ SELECT
 	(SELECT count(*) FROM preloaded_commits WHERE parents < 2) AS total_non_merges,
 	(SELECT author_when FROM preloaded_commits ORDER BY author_when ASC LIMIT 1) AS first_commit,
 	(SELECT author_when FROM preloaded_commits ORDER BY author_when DESC LIMIT 1) AS last_commit,
-	(SELECT count(distinct(author_email || author_name)) FROM preloaded_commits) AS distinct_authors,
-	(SELECT count(distinct(file_path)) FROM preloaded_commit_stats WHERE file_path LIKE $file_path) AS distinct_files
+	(SELECT count(distinct(author_key)) FROM preloaded_commits) AS distinct_authors,
+	(SELECT count(distinct(repository || ':' || file_path)) FROM preloaded_commit_stats WHERE file_path LIKE $file_path) AS distinct_files
 `
 
 type CommitAuthorSummary struct {
 	AuthorName    string         `db:"author_name"`
 	AuthorEmail   sql.NullString `db:"author_email"`
+	AuthorOrg     sql.NullString `db:"author_org"`
 	Commits       int            `db:"commit_count"`
 	Additions     sql.NullInt64  `db:"additions"`
 	Deletions     sql.NullInt64  `db:"deletions"`
@@ -72,18 +77,79 @@ type CommitAuthorSummary struct {
 
 const commitAuthorSummarySQL = `
 SELECT
-	author_name, author_email,
+	max(author_identity) AS author_name, max(author_email) AS author_email, max(author_org) AS author_org,
+	count(distinct repository || ':' || hash) AS commit_count,
+	sum(additions) AS additions,
+	sum(deletions) AS deletions,
+	count(distinct repository || ':' || file_path) AS distinct_files,
+	min(author_when) AS first_commit,
+	max(author_when) AS last_commit
+FROM preloaded_commit_stats
+GROUP BY author_key
+ORDER BY commit_count DESC
+`
+
+type CommitRepositorySummary struct {
+	Repository      string         `db:"repository"`
+	Commits         int            `db:"commit_count"`
+	DistinctAuthors int            `db:"distinct_authors"`
+	Additions       sql.NullInt64  `db:"additions"`
+	Deletions       sql.NullInt64  `db:"deletions"`
+	DistinctFiles   int            `db:"distinct_files"`
+	FirstCommit     sql.NullString `db:"first_commit"`
+	LastCommit      sql.NullString `db:"last_commit"`
+}
+
+const commitRepositorySummarySQL = `
+SELECT
+	repository,
 	count(distinct hash) AS commit_count,
+	count(distinct author_key) AS distinct_authors,
 	sum(additions) AS additions,
 	sum(deletions) AS deletions,
 	count(distinct file_path) AS distinct_files,
 	min(author_when) AS first_commit,
 	max(author_when) AS last_commit
 FROM preloaded_commit_stats
-GROUP BY author_name, author_email
+GROUP BY repository
 ORDER BY commit_count DESC
 `
 
+// commitsPerWeekSQL counts commits by the Monday of the week they were authored in
+const commitsPerWeekSQL = `
+SELECT date(author_when, '-6 days', 'weekday 1') AS week, count(*) AS commit_count
+FROM preloaded_commits
+GROUP BY week
+ORDER BY week
+`
+
+type CommitWeek struct {
+	Week    string `db:"week"`
+	Commits int    `db:"commit_count"`
+}
+
+// Options configures what a TermUI summarizes
+type Options struct {
+	// PathPattern only includes commits that modified a file matching it (with LIKE)
+	PathPattern string
+
+	// Start and End filter by author date, either a YYYY-MM-DD date or a SQLite date modifier relative to 'now'
+	Start, End string
+
+	// ExcludeBots excludes commits authored by bots (see pkg/identities)
+	ExcludeBots bool
+
+	// ByIdentity groups authors by identity (set when identities are configured), rather than by name and email
+	ByIdentity bool
+
+	// Repos are the repositories to summarize (paths or URLs), the default repository if empty.
+	// The text and report outputs only break the summary down by repository if there is more than one.
+	Repos []string
+
+	// Rev is the revision (branch, tag or commit) to summarize the history of, HEAD if empty
+	Rev string
+}
+
 type dateFilter struct {
 	date string
 	mod  string
@@ -94,14 +160,20 @@ type TermUI struct {
 	pathPattern           string
 	dateFilterStart       dateFilter
 	dateFilterEnd         dateFilter
+	excludeBots           bool
+	byIdentity            bool
+	repos                 []string
+	rev                   string
 	err                   error
 	spinner               spinner.Model
 	commitsPreloaded      bool
 	commitSummary         *CommitSummary
 	commitAuthorSummaries *[]*CommitAuthorSummary
+	repoSummaries         *[]*CommitRepositorySummary
+	commitsPerWeek        []*CommitWeek
 }
 
-func NewTermUI(pathPattern, dateFilterStart, dateFilterEnd string) (*TermUI, error) {
+func NewTermUI(opts Options) (*TermUI, error) {
 	var db *sqlx.DB
 	var err error
 	if db, err = sqlx.Open("sqlite3", "file::memory:?cache=shared"); err != nil {
@@ -121,29 +193,39 @@ func NewTermUI(pathPattern, dateFilterStart, dateFilterEnd string) (*TermUI, err
 	endMod := "0 days"
 
 	// if the start date cannot be parsed, assume it is a date modifier relative to 'now'
-	if _, err := time.Parse("2006-01-02", dateFilterStart); err != nil {
-		if dateFilterStart != "" {
-			startMod = dateFilterStart
+	if _, err := time.Parse("2006-01-02", opts.Start); err != nil {
+		if opts.Start != "" {
+			startMod = opts.Start
 		}
 	} else {
-		start = dateFilterStart
+		start = opts.Start
 		startMod = "0 days"
 	}
 
-	if _, err := time.Parse("2006-01-02", dateFilterEnd); err != nil {
-		if dateFilterEnd != "" {
-			endMod = dateFilterEnd
+	if _, err := time.Parse("2006-01-02", opts.End); err != nil {
+		if opts.End != "" {
+			endMod = opts.End
 		}
 	} else {
-		end = dateFilterEnd
+		end = opts.End
+	}
+
+	// an empty repository is the default one
+	repos := opts.Repos
+	if len(repos) == 0 {
+		repos = []string{""}
 	}
 
 	return &TermUI{
 		db:              db,
-		pathPattern:     pathPattern,
+		pathPattern:     opts.PathPattern,
 		spinner:         s,
 		dateFilterStart: dateFilter{date: start, mod: startMod},
 		dateFilterEnd:   dateFilter{date: end, mod: endMod},
+		excludeBots:     opts.ExcludeBots,
+		byIdentity:      opts.ByIdentity,
+		repos:           repos,
+		rev:             opts.Rev,
 	}, nil
 }
 
@@ -153,16 +235,29 @@ func (t *TermUI) Init() tea.Cmd {
 		t.preloadCommits,
 		t.loadCommitSummary,
 		t.loadAuthorCommitSummary,
+		t.loadRepositoryCommitSummary,
 	)
 }
 
 func (t *TermUI) preloadCommits() tea.Msg {
+	if _, err := t.db.Exec("CREATE TABLE summarized_repositories (repository TEXT)"); err != nil {
+		return err
+	}
+	for _, repo := range t.repos {
+		if _, err := t.db.Exec("INSERT INTO summarized_repositories VALUES (?)", repo); err != nil {
+			return err
+		}
+	}
+
 	preloadCommitsSQL := preloadCommitsWithoutFilePathPatternSQL
 	args := []interface{}{
+		sql.Named("rev", t.rev),
 		sql.Named("start", t.dateFilterStart.date),
 		sql.Named("start_mod", t.dateFilterStart.mod),
 		sql.Named("end", t.dateFilterEnd.date),
 		sql.Named("end_mod", t.dateFilterEnd.mod),
+		sql.Named("include_bots", !t.excludeBots),
+		sql.Named("by_identity", t.byIdentity),
 	}
 
 	if t.pathPattern != "" {
@@ -210,6 +305,19 @@ func (t *TermUI) loadAuthorCommitSummary() tea.Msg {
 	return nil
 }
 
+func (t *TermUI) loadRepositoryCommitSummary() tea.Msg {
+	for !t.commitsPreloaded {
+		time.Sleep(300 * time.Millisecond)
+	}
+	var repoSummaries []*CommitRepositorySummary
+	if err := t.db.Select(&repoSummaries, commitRepositorySummarySQL); err != nil {
+		return err
+	}
+
+	t.repoSummaries = &repoSummaries
+	return nil
+}
+
 func (t *TermUI) renderCommitSummaryTable(boldHeader bool) string {
 	var b bytes.Buffer
 	p := message.NewPrinter(language.English)
@@ -305,8 +413,13 @@ func (t *TermUI) renderCommitAuthorSummary(limit int) string {
 				return err.Error()
 			}
 
+			author := authorRow.AuthorName
+			if authorRow.AuthorOrg.Valid {
+				author = fmt.Sprintf("%s (%s)", author, authorRow.AuthorOrg.String)
+			}
+
 			r := strings.Join([]string{
-				authorRow.AuthorName,
+				author,
 				p.Sprintf("%d", authorRow.Commits),
 				p.Sprintf("%.2f%%", commitPercent),
 				p.Sprintf("%d", authorRow.DistinctFiles),
@@ -338,6 +451,64 @@ func (t *TermUI) renderCommitAuthorSummary(limit int) string {
 	return b.String()
 }
 
+func (t *TermUI) renderCommitRepositorySummary() string {
+	var b bytes.Buffer
+	p := message.NewPrinter(language.English)
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	// the breakdown is only useful when summarizing more than one repository
+	if len(t.repos) < 2 {
+		return ""
+	}
+
+	if t.repoSummaries != nil && t.commitSummary != nil {
+		r := strings.Join([]string{
+			"Repository",
+			"Commits",
+			"Commit %",
+			"Authors",
+			"Files Δ",
+			"Additions",
+			"Deletions",
+			"Latest Commit",
+		}, "\t")
+
+		p.Fprintln(w, r)
+
+		for _, repoRow := range *t.repoSummaries {
+			commitPercent := (float32(repoRow.Commits) / float32(t.commitSummary.Total)) * 100.0
+
+			lastCommit := "<none>"
+			if repoRow.LastCommit.Valid {
+				when, _ := time.Parse(time.RFC3339, repoRow.LastCommit.String)
+				lastCommit = fmt.Sprintf("%s (%s)", timediff.TimeDiff(when), when.Format("2006-01-02"))
+			}
+
+			r := strings.Join([]string{
+				repoRow.Repository,
+				p.Sprintf("%d", repoRow.Commits),
+				p.Sprintf("%.2f%%", commitPercent),
+				p.Sprintf("%d", repoRow.DistinctAuthors),
+				p.Sprintf("%d", repoRow.DistinctFiles),
+				p.Sprintf("%d", repoRow.Additions.Int64),
+				p.Sprintf("%d", repoRow.Deletions.Int64),
+				lastCommit,
+			}, "\t")
+
+			p.Fprintln(w, r)
+		}
+
+		if err := w.Flush(); err != nil {
+			return err.Error()
+		}
+	} else {
+		p.Fprintln(&b, "Loading repositories", t.spinner.View())
+	}
+
+	p.Fprintln(&b)
+	return b.String()
+}
+
 func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case error:
@@ -352,7 +523,7 @@ func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	default:
-		if t.commitSummary != nil && t.commitAuthorSummaries != nil && t.commitsPreloaded {
+		if t.commitSummary != nil && t.commitAuthorSummaries != nil && t.repoSummaries != nil && t.commitsPreloaded {
 			return t, tea.Quit
 		}
 		var cmd tea.Cmd
@@ -370,6 +541,7 @@ func (t *TermUI) View() string {
 
 	var b bytes.Buffer
 	fmt.Fprint(&b, t.renderCommitSummaryTable(true))
+	fmt.Fprint(&b, t.renderCommitRepositorySummary())
 	fmt.Fprint(&b, t.renderCommitAuthorSummary(25))
 
 	return b.String()
@@ -380,6 +552,7 @@ func (t *TermUI) PrintNoTTY() string {
 	t.preloadCommits()
 	t.loadCommitSummary()
 	t.loadAuthorCommitSummary()
+	t.loadRepositoryCommitSummary()
 
 	if t.err != nil {
 		return t.err.Error()
@@ -387,6 +560,7 @@ func (t *TermUI) PrintNoTTY() string {
 
 	var b bytes.Buffer
 	fmt.Fprint(&b, t.renderCommitSummaryTable(false))
+	fmt.Fprint(&b, t.renderCommitRepositorySummary())
 	fmt.Fprint(&b, t.renderCommitAuthorSummary(0))
 
 	return b.String()
@@ -397,6 +571,7 @@ func (t *TermUI) PrintJSON() string {
 	t.preloadCommits()
 	t.loadCommitSummary()
 	t.loadAuthorCommitSummary()
+	t.loadRepositoryCommitSummary()
 
 	if t.err != nil {
 		return t.err.Error()
@@ -418,16 +593,37 @@ func (t *TermUI) PrintJSON() string {
 		authorSummaries[i] = map[string]interface{}{
 			"name":          authorSummary.AuthorName,
 			"email":         authorSummary.AuthorEmail,
+			"org":           nil,
 			"commits":       authorSummary.Commits,
 			"commitPercent": commitPercent,
 			"filesModified": authorSummary.DistinctFiles,
 			"additions":     authorSummary.Additions.Int64,
 			"deletions":     authorSummary.Deletions.Int64,
 		}
+		if authorSummary.AuthorOrg.Valid {
+			authorSummaries[i]["org"] = authorSummary.AuthorOrg.String
+		}
 	}
 
 	output["authors"] = authorSummaries
 
+	repoSummaries := make([]map[string]interface{}, len(*t.repoSummaries))
+
+	for i, repoSummary := range *t.repoSummaries {
+		repoSummaries[i] = map[string]interface{}{
+			"repository":    repoSummary.Repository,
+			"commits":       repoSummary.Commits,
+			"uniqueAuthors": repoSummary.DistinctAuthors,
+			"filesChanged":  repoSummary.DistinctFiles,
+			"additions":     repoSummary.Additions.Int64,
+			"deletions":     repoSummary.Deletions.Int64,
+			"firstCommit":   repoSummary.FirstCommit.String,
+			"lastCommit":    repoSummary.LastCommit.String,
+		}
+	}
+
+	output["repositories"] = repoSummaries
+
 	if o, err := json.MarshalIndent(output, "", "  "); err != nil {
 		return err.Error()
 	} else {
@@ -0,0 +1,108 @@
+package commits
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/cmd/summarize/internal/summarizetest"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// commitsFixtures creates two repositories: the first has two commits of Jane Doe on master,
+// and an old branch at the first one, the second has a commit of Bob. It returns their paths.
+func commitsFixtures(t *testing.T) (first, second string) {
+	summarizetest.Register()
+
+	repo := fixture.NewRepo(t)
+	hash := repo.Commit("first", map[string]string{"a.go": "a\n"})
+	repo.Commit("second", map[string]string{"a.go": "a\nb\n", "b.go": "b\n"})
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName("old"), plumbing.NewHash(hash))
+	if err := repo.Repo.Storer.SetReference(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	other := fixture.NewRepo(t)
+	other.Author.Name, other.Author.Email = "Bob", "bob@example.com"
+	other.Commit("first", map[string]string{"c.go": "c\n"})
+
+	return repo.Dir, other.Dir
+}
+
+type commitsJSON struct {
+	Commits       int `json:"commits"`
+	UniqueAuthors int `json:"uniqueAuthors"`
+	FilesChanged  int `json:"filesChanged"`
+	Authors       []struct {
+		Name    string `json:"name"`
+		Commits int    `json:"commits"`
+	} `json:"authors"`
+	Repositories []struct {
+		Repository string `json:"repository"`
+		Commits    int    `json:"commits"`
+		Authors    int    `json:"uniqueAuthors"`
+		Files      int    `json:"filesChanged"`
+	} `json:"repositories"`
+}
+
+func TestCommitsRepos(t *testing.T) {
+	first, second := commitsFixtures(t)
+
+	summary := summarizetest.JSON[commitsJSON](t)(NewTermUI(Options{Repos: []string{first, second}}))
+	if summary.Commits != 3 || summary.UniqueAuthors != 2 || summary.FilesChanged != 3 {
+		t.Fatalf("expected 3 commits of 2 authors changing 3 files, got: %+v", summary)
+	}
+
+	// repositories come by number of commits, most first
+	if len(summary.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got: %+v", summary.Repositories)
+	}
+	if repo := summary.Repositories[0]; repo.Repository != first || repo.Commits != 2 || repo.Authors != 1 || repo.Files != 2 {
+		t.Errorf("unexpected summary of %s: %+v", first, repo)
+	}
+	if repo := summary.Repositories[1]; repo.Repository != second || repo.Commits != 1 || repo.Authors != 1 || repo.Files != 1 {
+		t.Errorf("unexpected summary of %s: %+v", second, repo)
+	}
+}
+
+func TestCommitsRepositoryBreakdown(t *testing.T) {
+	first, second := commitsFixtures(t)
+
+	for _, repos := range [][]string{{first}, {first, second}} {
+		ui, err := NewTermUI(Options{Repos: repos})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := ui.PrintNoTTY()
+		if err = ui.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the breakdown by repository only shows when there is more than one
+		if shown := strings.Contains(out, first); shown != (len(repos) > 1) {
+			t.Errorf("expected the breakdown to show (%v) with %d repositories, got:\n%s", len(repos) > 1, len(repos), out)
+		}
+	}
+}
+
+func TestCommitsRev(t *testing.T) {
+	first, _ := commitsFixtures(t)
+
+	var cases = []struct {
+		rev     string
+		commits int
+	}{
+		{"", 2},
+		{"HEAD", 2},
+		{"master", 2},
+		{"old", 1},
+		{"HEAD~1", 1},
+	}
+	for _, c := range cases {
+		summary := summarizetest.JSON[commitsJSON](t)(NewTermUI(Options{Repos: []string{first}, Rev: c.rev}))
+		if summary.Commits != c.commits || len(summary.Authors) != 1 || summary.Authors[0].Commits != c.commits {
+			t.Errorf("expected %d commits at %q, got: %+v", c.commits, c.rev, summary)
+		}
+	}
+}
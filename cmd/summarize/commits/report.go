@@ -0,0 +1,182 @@
+package commits
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mergestat/mergestat-lite/cmd/summarize/report"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// reportTopAuthors is the number of authors charted in reports
+const reportTopAuthors = 10
+
+func (t *TermUI) loadCommitsPerWeek() tea.Msg {
+	for !t.commitsPreloaded {
+		time.Sleep(300 * time.Millisecond)
+	}
+	var weeks []*CommitWeek
+	if err := t.db.Select(&weeks, commitsPerWeekSQL); err != nil {
+		return err
+	}
+
+	t.commitsPerWeek = fillWeeks(weeks)
+	return nil
+}
+
+// fillWeeks adds the weeks without commits between the first and last of weeks (sorted by week)
+func fillWeeks(weeks []*CommitWeek) []*CommitWeek {
+	if len(weeks) == 0 {
+		return weeks
+	}
+
+	var byWeek = make(map[string]int, len(weeks))
+	for _, week := range weeks {
+		byWeek[week.Week] = week.Commits
+	}
+
+	first, err := time.Parse("2006-01-02", weeks[0].Week)
+	if err != nil {
+		return weeks
+	}
+	last, err := time.Parse("2006-01-02", weeks[len(weeks)-1].Week)
+	if err != nil {
+		return weeks
+	}
+
+	var filled []*CommitWeek
+	for week := first; !week.After(last); week = week.AddDate(0, 0, 7) {
+		w := week.Format("2006-01-02")
+		filled = append(filled, &CommitWeek{Week: w, Commits: byWeek[w]})
+	}
+	return filled
+}
+
+// load preloads and loads everything a report is built from, outside of the bubbletea program
+func (t *TermUI) load() {
+	for _, fn := range []func() tea.Msg{t.preloadCommits, t.loadCommitSummary, t.loadAuthorCommitSummary, t.loadRepositoryCommitSummary, t.loadCommitsPerWeek} {
+		if err, ok := fn().(error); ok {
+			t.err = err
+			return
+		}
+	}
+}
+
+// Report builds a report of the summary, as rendered by PrintMarkdown and PrintHTML
+func (t *TermUI) Report() (*report.Report, error) {
+	if t.load(); t.err != nil {
+		return nil, t.err
+	}
+
+	p := message.NewPrinter(language.English)
+
+	var subtitle []string
+	if repos := strings.Join(t.repos, ", "); repos != "" {
+		subtitle = append(subtitle, repos)
+	}
+	if t.rev != "" {
+		subtitle = append(subtitle, "at "+t.rev)
+	}
+	if t.pathPattern != "" {
+		subtitle = append(subtitle, "files matching "+t.pathPattern)
+	}
+
+	var r = &report.Report{Title: "Commit Summary", Subtitle: strings.Join(subtitle, ", ")}
+
+	r.Sections = append(r.Sections, &report.Facts{Facts: []report.Fact{
+		{Name: "Commits", Value: p.Sprintf("%d", t.commitSummary.Total)},
+		{Name: "Non-Merge Commits", Value: p.Sprintf("%d", t.commitSummary.TotalNonMerges)},
+		{Name: "Files Δ", Value: p.Sprintf("%d", t.commitSummary.DistinctFiles)},
+		{Name: "Unique Authors", Value: p.Sprintf("%d", t.commitSummary.DistinctAuthors)},
+		{Name: "First Commit", Value: report.FormatDate(t.commitSummary.FirstCommit.String)},
+		{Name: "Latest Commit", Value: report.FormatDate(t.commitSummary.LastCommit.String)},
+	}})
+
+	var perWeek = &report.BarChart{Title: "Commits per Week", Unit: "commits", Series: true}
+	for _, week := range t.commitsPerWeek {
+		perWeek.Bars = append(perWeek.Bars, report.Bar{Label: week.Week, Value: float64(week.Commits)})
+	}
+	r.Sections = append(r.Sections, perWeek)
+
+	var topAuthors = &report.BarChart{Title: "Top Authors", Unit: "commits"}
+	for i, author := range *t.commitAuthorSummaries {
+		if i == reportTopAuthors {
+			break
+		}
+		topAuthors.Bars = append(topAuthors.Bars, report.Bar{Label: author.AuthorName, Value: float64(author.Commits)})
+	}
+	r.Sections = append(r.Sections, topAuthors)
+
+	// the breakdown is only useful when summarizing more than one repository
+	if len(t.repos) > 1 {
+		var repos = &report.Table{
+			Title:   "Repositories",
+			Columns: []string{"Repository", "Commits", "Commit %", "Authors", "Files Δ", "Additions", "Deletions", "Latest Commit"},
+		}
+		for _, repo := range *t.repoSummaries {
+			repos.Rows = append(repos.Rows, []string{
+				repo.Repository,
+				p.Sprintf("%d", repo.Commits),
+				p.Sprintf("%.2f%%", (float32(repo.Commits)/float32(t.commitSummary.Total))*100.0),
+				p.Sprintf("%d", repo.DistinctAuthors),
+				p.Sprintf("%d", repo.DistinctFiles),
+				p.Sprintf("%d", repo.Additions.Int64),
+				p.Sprintf("%d", repo.Deletions.Int64),
+				report.FormatDate(repo.LastCommit.String),
+			})
+		}
+		r.Sections = append(r.Sections, repos)
+	}
+
+	var authors = &report.Table{
+		Title:   "Authors",
+		Columns: []string{"Author", "Commits", "Commit %", "Files Δ", "Additions", "Deletions", "First Commit", "Latest Commit"},
+	}
+	for _, author := range *t.commitAuthorSummaries {
+		name := author.AuthorName
+		if author.AuthorOrg.Valid {
+			name = fmt.Sprintf("%s (%s)", name, author.AuthorOrg.String)
+		}
+
+		authors.Rows = append(authors.Rows, []string{
+			name,
+			p.Sprintf("%d", author.Commits),
+			p.Sprintf("%.2f%%", (float32(author.Commits)/float32(t.commitSummary.Total))*100.0),
+			p.Sprintf("%d", author.DistinctFiles),
+			p.Sprintf("%d", author.Additions.Int64),
+			p.Sprintf("%d", author.Deletions.Int64),
+			report.FormatDate(author.FirstCommit),
+			report.FormatDate(author.LastCommit),
+		})
+	}
+	r.Sections = append(r.Sections, authors)
+
+	return r, nil
+}
+
+// PrintMarkdown outputs summary results as a markdown report
+func (t *TermUI) PrintMarkdown() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+	return report.Markdown(r)
+}
+
+// PrintHTML outputs summary results as a self-contained HTML report
+func (t *TermUI) PrintHTML() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+
+	page, err := report.HTML(r)
+	if err != nil {
+		t.err = err
+		return err.Error()
+	}
+	return page
+}
@@ -0,0 +1,238 @@
+package hotspots
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// hotspotsSQL ranks the files of the default repository by their hotspot score.
+// Without a since date, date() returns NULL and the table is passed an empty since, which covers the whole history.
+const hotspotsSQL = `
+SELECT path, commits, authors, additions, deletions, lines, complexity, score
+FROM hotspots('', coalesce(date($since, $since_mod), ''))
+WHERE path LIKE $file_path
+ORDER BY score DESC, commits DESC, path
+`
+
+type Hotspot struct {
+	Path       string `db:"path"`
+	Commits    int    `db:"commits"`
+	Authors    int    `db:"authors"`
+	Additions  int    `db:"additions"`
+	Deletions  int    `db:"deletions"`
+	Lines      int    `db:"lines"`
+	Complexity int    `db:"complexity"`
+	Score      int    `db:"score"`
+}
+
+type TermUI struct {
+	db          *sqlx.DB
+	pathPattern string
+	since       string
+	sinceMod    string
+	err         error
+	spinner     spinner.Model
+	hotspots    *[]*Hotspot
+}
+
+func NewTermUI(pathPattern, since string) (*TermUI, error) {
+	var db *sqlx.DB
+	var err error
+	if db, err = sqlx.Open("sqlite3", "file::memory:?cache=shared"); err != nil {
+		return nil, fmt.Errorf("failed to initialize database connection: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	s := spinner.New()
+	s.Spinner = spinner.Spinner{
+		Frames: []string{".", "..", "..."},
+		FPS:    300 * time.Millisecond,
+	}
+
+	if pathPattern == "" {
+		pathPattern = "%"
+	}
+
+	// if the since date cannot be parsed, assume it is a date modifier relative to 'now'
+	sinceMod := "0 days"
+	if _, err := time.Parse("2006-01-02", since); err != nil && since != "" {
+		since, sinceMod = "now", since
+	}
+
+	return &TermUI{
+		db:          db,
+		pathPattern: pathPattern,
+		since:       since,
+		sinceMod:    sinceMod,
+		spinner:     s,
+	}, nil
+}
+
+func (t *TermUI) Init() tea.Cmd {
+	return tea.Batch(
+		t.spinner.Tick,
+		t.loadHotspots,
+	)
+}
+
+func (t *TermUI) loadHotspots() tea.Msg {
+	var hotspots []*Hotspot
+	if err := t.db.Select(&hotspots, hotspotsSQL, sql.Named("since", t.since), sql.Named("since_mod", t.sinceMod), sql.Named("file_path", t.pathPattern)); err != nil {
+		return err
+	}
+
+	t.hotspots = &hotspots
+	return nil
+}
+
+func (t *TermUI) renderHotspots(boldHeader bool, limit int) string {
+	var b bytes.Buffer
+	p := message.NewPrinter(language.English)
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	if t.hotspots == nil {
+		p.Fprintln(&b, "Loading hotspots", t.spinner.View())
+		return b.String()
+	}
+
+	if len(*t.hotspots) == 0 {
+		return "<no hotspots>\n"
+	}
+
+	var headingStyle = lipgloss.NewStyle().Bold(boldHeader)
+	var headings = []string{"File", "Score", "Commits", "Authors", "Churn", "Lines", "Complexity"}
+	for i, heading := range headings {
+		headings[i] = headingStyle.Render(heading)
+	}
+	p.Fprintln(w, strings.Join(headings, "\t"))
+
+	for i, spot := range *t.hotspots {
+		if i > limit-1 && limit != 0 {
+			break
+		}
+
+		r := strings.Join([]string{
+			spot.Path,
+			p.Sprintf("%d", spot.Score),
+			p.Sprintf("%d", spot.Commits),
+			p.Sprintf("%d", spot.Authors),
+			p.Sprintf("+%d / -%d", spot.Additions, spot.Deletions),
+			p.Sprintf("%d", spot.Lines),
+			p.Sprintf("%d", spot.Complexity),
+		}, "\t")
+
+		p.Fprintln(w, r)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err.Error()
+	}
+
+	if limit != 0 {
+		d := len(*t.hotspots) - limit
+		if d == 1 {
+			p.Fprintf(&b, "...1 more file\n")
+		} else if d > 1 {
+			p.Fprintf(&b, "...%d more files\n", d)
+		}
+	}
+
+	return b.String()
+}
+
+func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case error:
+		t.err = msg
+		return t, tea.Quit
+
+	case tea.KeyMsg:
+		switch msg.String() {
+
+		case "ctrl+c", "q":
+			return t, tea.Quit
+		}
+
+	default:
+		if t.hotspots != nil {
+			return t, tea.Quit
+		}
+		var cmd tea.Cmd
+		t.spinner, cmd = t.spinner.Update(msg)
+		return t, cmd
+	}
+
+	return t, nil
+}
+
+func (t *TermUI) View() string {
+	if t.err != nil {
+		return t.err.Error()
+	}
+
+	return t.renderHotspots(true, 25)
+}
+
+// PrintNoTTY prints a version of output with no terminal styles
+func (t *TermUI) PrintNoTTY() string {
+	if err, ok := t.loadHotspots().(error); ok {
+		t.err = err
+	}
+
+	if t.err != nil {
+		return t.err.Error()
+	}
+
+	return t.renderHotspots(false, 0)
+}
+
+// PrintJSON outputs summary results as a JSON object
+func (t *TermUI) PrintJSON() string {
+	if err, ok := t.loadHotspots().(error); ok {
+		t.err = err
+	}
+
+	if t.err != nil {
+		return t.err.Error()
+	}
+
+	hotspots := make([]map[string]interface{}, len(*t.hotspots))
+	for i, spot := range *t.hotspots {
+		hotspots[i] = map[string]interface{}{
+			"path":       spot.Path,
+			"score":      spot.Score,
+			"commits":    spot.Commits,
+			"authors":    spot.Authors,
+			"additions":  spot.Additions,
+			"deletions":  spot.Deletions,
+			"lines":      spot.Lines,
+			"complexity": spot.Complexity,
+		}
+	}
+
+	if o, err := json.MarshalIndent(map[string]interface{}{"hotspots": hotspots}, "", "  "); err != nil {
+		return err.Error()
+	} else {
+		return string(o)
+	}
+}
+
+func (t *TermUI) Close() error {
+	defer t.db.Close()
+	if t.err != nil {
+		return t.err
+	}
+	return nil
+}
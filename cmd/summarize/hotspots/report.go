@@ -0,0 +1,88 @@
+package hotspots
+
+import (
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/cmd/summarize/report"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// reportTopHotspots is the number of files charted in reports
+const reportTopHotspots = 10
+
+// Report builds a report of the hotspots, as rendered by PrintMarkdown and PrintHTML
+func (t *TermUI) Report() (*report.Report, error) {
+	if err, ok := t.loadHotspots().(error); ok {
+		t.err = err
+		return nil, err
+	}
+
+	p := message.NewPrinter(language.English)
+
+	var subtitle []string
+	if t.pathPattern != "%" {
+		subtitle = append(subtitle, "files matching "+t.pathPattern)
+	}
+	if t.since != "" {
+		since := t.since
+		if since == "now" {
+			since = t.sinceMod
+		}
+		subtitle = append(subtitle, "since "+since)
+	}
+
+	var r = &report.Report{Title: "Hotspots", Subtitle: strings.Join(subtitle, ", ")}
+
+	var top = &report.BarChart{Title: "Top Hotspots", Unit: "score"}
+	for i, spot := range *t.hotspots {
+		if i == reportTopHotspots {
+			break
+		}
+		top.Bars = append(top.Bars, report.Bar{Label: spot.Path, Value: float64(spot.Score)})
+	}
+	r.Sections = append(r.Sections, top)
+
+	var files = &report.Table{
+		Title:   "Files",
+		Columns: []string{"File", "Score", "Commits", "Authors", "Churn", "Lines", "Complexity"},
+	}
+	for _, spot := range *t.hotspots {
+		files.Rows = append(files.Rows, []string{
+			spot.Path,
+			p.Sprintf("%d", spot.Score),
+			p.Sprintf("%d", spot.Commits),
+			p.Sprintf("%d", spot.Authors),
+			p.Sprintf("+%d / -%d", spot.Additions, spot.Deletions),
+			p.Sprintf("%d", spot.Lines),
+			p.Sprintf("%d", spot.Complexity),
+		})
+	}
+	r.Sections = append(r.Sections, files)
+
+	return r, nil
+}
+
+// PrintMarkdown outputs the hotspots as a markdown report
+func (t *TermUI) PrintMarkdown() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+	return report.Markdown(r)
+}
+
+// PrintHTML outputs the hotspots as a self-contained HTML report
+func (t *TermUI) PrintHTML() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+
+	page, err := report.HTML(r)
+	if err != nil {
+		t.err = err
+		return err.Error()
+	}
+	return page
+}
@@ -0,0 +1,78 @@
+// Package summarizetest sets up the tests of the summarize commands, which query the repository of the current
+// directory with the mergestat extension
+package summarizetest
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+var register sync.Once
+
+// Register registers the extension with the connections opened from then on, without any identities
+func Register() {
+	register.Do(func() {
+		sqlite.Register(extensions.RegisterFn(
+			options.WithExtraFunctions(),
+			options.WithRepoLocator(locator.MultiLocator(nil)),
+		))
+	})
+}
+
+// Chdir registers the extension, and makes dir the current directory (so the default repository) until the end of t
+func Chdir(t testing.TB, dir string) {
+	t.Helper()
+	Register()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+}
+
+// Lines returns n lines of text
+func Lines(n int) string {
+	return strings.Repeat("line\n", n)
+}
+
+// Summary is the TermUI of a summarize command
+type Summary interface {
+	PrintJSON() string
+	Close() error
+}
+
+// JSON returns a function taking a new summary, and the error creating it (as returned by the NewTermUI function
+// of its command), which prints the summary as JSON, closes it, and returns the JSON decoded into a T
+func JSON[T any](t testing.TB) func(Summary, error) *T {
+	return func(s Summary, err error) *T {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		out := s.PrintJSON()
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var summary T
+		if err = json.Unmarshal([]byte(out), &summary); err != nil {
+			t.Fatalf("invalid JSON output: %v: %s", err, out)
+		}
+		return &summary
+	}
+}
@@ -0,0 +1,507 @@
+package ownership
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jmoiron/sqlx"
+	"github.com/mergestat/timediff"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// preloadOwnershipSQL blames every matching file, keeping the directory each line is in.
+// Trimming all the characters of a path but '/' from its right strips everything after the last '/',
+// so the directory of a file at the root of the repository is empty.
+// Authors are keyed by their identity with $by_identity (see pkg/identities), by their name and email otherwise.
+const preloadOwnershipSQL = `
+CREATE TABLE preloaded_ownership AS
+SELECT
+	files.path,
+	rtrim(files.path, replace(files.path, '/', '')) AS directory,
+	author_identity(blame.author_name, blame.author_email) AS author_identity,
+	CASE WHEN $by_identity THEN author_identity(blame.author_name, blame.author_email) ELSE blame.author_name || ' <' || blame.author_email || '>' END AS author_key,
+	author_org(blame.author_name, blame.author_email) AS author_org
+FROM files, blame('', '', files.path)
+WHERE path LIKE $file_path AND ($include_bots OR NOT is_bot(blame.author_name, blame.author_email))
+`
+
+// ownershipSQL returns the lines of each author in each directory, including those of the directories within it
+// (so the root directory has all of them), along with the date of their latest commit (to the whole repository),
+// and whether that is within the activity window. Commit dates are compared as UTC datetimes, as they are written
+// in the time zone of each commit, and returned in RFC3339.
+const ownershipSQL = `
+WITH RECURSIVE directories(directory, author_key, author_identity, author_org) AS (
+	SELECT directory, author_key, author_identity, author_org FROM preloaded_ownership
+	UNION ALL
+	SELECT rtrim(rtrim(directory, '/'), replace(rtrim(directory, '/'), '/', '')), author_key, author_identity, author_org
+	FROM directories WHERE directory != ''
+)
+SELECT
+	directory,
+	author_key, max(author_identity) AS author_name, max(author_org) AS author_org,
+	count(*) AS loc,
+	strftime('%Y-%m-%dT%H:%M:%SZ', max(activity.last_commit)) AS last_commit,
+	coalesce(max(activity.last_commit) >= datetime($active_since, $active_since_mod), 0) AS active
+FROM directories
+LEFT JOIN (
+	SELECT
+		CASE WHEN $by_identity THEN author_identity(author_name, author_email) ELSE author_name || ' <' || author_email || '>' END AS author,
+		max(datetime(author_when)) AS last_commit
+	FROM commits GROUP BY author
+) AS activity ON activity.author = author_key
+GROUP BY directory, author_key
+ORDER BY directory, loc DESC, author_name
+`
+
+type authorOwnershipRow struct {
+	Directory  string         `db:"directory"`
+	AuthorKey  string         `db:"author_key"`
+	AuthorName string         `db:"author_name"`
+	AuthorOrg  sql.NullString `db:"author_org"`
+	Lines      int            `db:"loc"`
+	LastCommit sql.NullString `db:"last_commit"`
+	Active     bool           `db:"active"`
+}
+
+// AuthorShare is the share of the lines of a directory blamed on an author
+type AuthorShare struct {
+	Name       string
+	Org        string
+	Lines      int
+	Share      float64 // percentage of the lines of the directory
+	LastCommit sql.NullString
+	Active     bool
+}
+
+// DirectoryOwnership summarizes how the lines of a directory are distributed among authors
+type DirectoryOwnership struct {
+	Directory string
+	Lines     int
+
+	// Authors are sorted by the number of lines they own, most first
+	Authors []*AuthorShare
+
+	// BusFactor is the minimum number of authors that own at least half of the lines
+	BusFactor int
+
+	// Abandoned is set if none of the authors making up the bus factor has been active
+	Abandoned bool
+}
+
+type TermUI struct {
+	db              *sqlx.DB
+	pathPattern     string
+	excludeBots     bool
+	byIdentity      bool
+	activeSince     string
+	activeSinceMod  string
+	err             error
+	spinner         spinner.Model
+	preloaded       bool
+	directories     *[]*DirectoryOwnership
+	distinctAuthors int
+	totalLines      int
+	abandonedLines  int // of the abandoned directories, counted once for those within another one
+}
+
+// NewTermUI returns a TermUI summarizing the ownership of the files matching pathPattern.
+// Authors with no commits since activeSince (a YYYY-MM-DD date, or a SQLite date modifier relative to 'now') are inactive.
+// They are grouped by identity with byIdentity (set when identities are configured), by name and email otherwise.
+func NewTermUI(pathPattern, activeSince string, excludeBots, byIdentity bool) (*TermUI, error) {
+	var db *sqlx.DB
+	var err error
+	if db, err = sqlx.Open("sqlite3", "file::memory:?cache=shared"); err != nil {
+		return nil, fmt.Errorf("failed to initialize database connection: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	s := spinner.New()
+	s.Spinner = spinner.Spinner{
+		Frames: []string{".", "..", "..."},
+		FPS:    300 * time.Millisecond,
+	}
+
+	if pathPattern == "" {
+		pathPattern = "%"
+	}
+
+	activeSinceMod := "0 days"
+	// if the date cannot be parsed, assume it is a date modifier relative to 'now'
+	if _, err := time.Parse("2006-01-02", activeSince); err != nil {
+		if activeSince == "" {
+			activeSince = "-1 year"
+		}
+		activeSince, activeSinceMod = "now", activeSince
+	}
+
+	return &TermUI{
+		db:             db,
+		pathPattern:    pathPattern,
+		excludeBots:    excludeBots,
+		byIdentity:     byIdentity,
+		activeSince:    activeSince,
+		activeSinceMod: activeSinceMod,
+		spinner:        s,
+	}, nil
+}
+
+func (t *TermUI) Init() tea.Cmd {
+	return tea.Batch(
+		t.spinner.Tick,
+		t.preloadOwnership,
+		t.loadOwnership,
+	)
+}
+
+func (t *TermUI) preloadOwnership() tea.Msg {
+	args := []interface{}{
+		sql.Named("file_path", t.pathPattern),
+		sql.Named("include_bots", !t.excludeBots),
+		sql.Named("by_identity", t.byIdentity),
+	}
+	if _, err := t.db.Exec(preloadOwnershipSQL, args...); err != nil {
+		return err
+	}
+
+	t.preloaded = true
+	return nil
+}
+
+func (t *TermUI) loadOwnership() tea.Msg {
+	for !t.preloaded {
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	var rows []*authorOwnershipRow
+	args := []interface{}{
+		sql.Named("active_since", t.activeSince),
+		sql.Named("active_since_mod", t.activeSinceMod),
+		sql.Named("by_identity", t.byIdentity),
+	}
+	if err := t.db.Select(&rows, ownershipSQL, args...); err != nil {
+		return err
+	}
+
+	var directories []*DirectoryOwnership
+	var authors = make(map[string]struct{})
+	for _, row := range rows {
+		if len(directories) == 0 || directories[len(directories)-1].Directory != row.Directory {
+			directories = append(directories, &DirectoryOwnership{Directory: row.Directory})
+		}
+		dir := directories[len(directories)-1]
+		dir.Lines += row.Lines
+		dir.Authors = append(dir.Authors, &AuthorShare{
+			Name: row.AuthorName, Org: row.AuthorOrg.String, Lines: row.Lines, LastCommit: row.LastCommit, Active: row.Active,
+		})
+		authors[row.AuthorKey] = struct{}{}
+	}
+
+	for _, dir := range directories {
+		var covered int
+		dir.Abandoned = true
+		for _, author := range dir.Authors {
+			author.Share = float64(author.Lines) / float64(dir.Lines) * 100.0
+			if covered*2 < dir.Lines {
+				covered += author.Lines
+				dir.BusFactor++
+				dir.Abandoned = dir.Abandoned && !author.Active
+			}
+		}
+	}
+
+	// the lines of a directory are also those of the directories it is in, up to the root which has all of them
+	var abandoned = make(map[string]bool)
+	for _, dir := range directories {
+		abandoned[dir.Directory] = dir.Abandoned
+	}
+	t.totalLines, t.abandonedLines = 0, 0
+	for _, dir := range directories {
+		if dir.Directory == "" {
+			t.totalLines = dir.Lines
+		}
+		if dir.Abandoned && !withinAbandoned(dir.Directory, abandoned) {
+			t.abandonedLines += dir.Lines
+		}
+	}
+
+	// the largest directories first, as the ones that matter most
+	sort.SliceStable(directories, func(i, j int) bool { return directories[i].Lines > directories[j].Lines })
+
+	t.distinctAuthors = len(authors)
+	t.directories = &directories
+	return nil
+}
+
+// withinAbandoned returns whether any of the directories dir is in is abandoned
+func withinAbandoned(dir string, abandoned map[string]bool) bool {
+	for dir != "" {
+		if dir = parentDirectory(dir); abandoned[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// parentDirectory returns the directory dir is in, "" (the root of the repository) for a top-level one
+func parentDirectory(dir string) string {
+	dir = strings.TrimSuffix(dir, "/")
+	return dir[:strings.LastIndex(dir, "/")+1]
+}
+
+func (t *TermUI) renderOwnershipSummaryTable(boldHeader bool) string {
+	var b bytes.Buffer
+	p := message.NewPrinter(language.English)
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	var directories, lines, authors, busFactorOne, abandoned string
+	if t.directories != nil {
+		var busFactorOneCount, abandonedCount int
+		for _, dir := range *t.directories {
+			if dir.BusFactor == 1 {
+				busFactorOneCount++
+			}
+			if dir.Abandoned {
+				abandonedCount++
+			}
+		}
+
+		directories = p.Sprintf("%d", len(*t.directories))
+		lines = p.Sprintf("%d", t.totalLines)
+		authors = p.Sprintf("%d", t.distinctAuthors)
+		busFactorOne = p.Sprintf("%d", busFactorOneCount)
+		abandoned = p.Sprintf("%d (%d lines)", abandonedCount, t.abandonedLines)
+	} else {
+		directories = t.spinner.View()
+		lines = t.spinner.View()
+		authors = t.spinner.View()
+		busFactorOne = t.spinner.View()
+		abandoned = t.spinner.View()
+	}
+
+	var headingStyle = lipgloss.NewStyle().Bold(boldHeader)
+
+	rows := []string{
+		strings.Join([]string{headingStyle.Render("Directories"), directories}, "\t"),
+		strings.Join([]string{headingStyle.Render("Total Lines"), lines}, "\t"),
+		strings.Join([]string{headingStyle.Render("Distinct Authors"), authors}, "\t"),
+		strings.Join([]string{headingStyle.Render("Bus Factor of 1"), busFactorOne}, "\t"),
+		strings.Join([]string{headingStyle.Render("Abandoned"), abandoned}, "\t"),
+	}
+
+	p.Fprintln(w, strings.Join(rows, "\n"))
+	if err := w.Flush(); err != nil {
+		return err.Error()
+	}
+
+	p.Fprintln(&b)
+	p.Fprintln(&b)
+
+	return b.String()
+}
+
+func (t *TermUI) renderDirectoryOwnership(limit int) string {
+	var b bytes.Buffer
+	p := message.NewPrinter(language.English)
+	w := tabwriter.NewWriter(&b, 0, 0, 3, ' ', tabwriter.TabIndent)
+
+	if t.directories == nil {
+		p.Fprintln(&b, "Loading directories", t.spinner.View())
+		return b.String()
+	}
+
+	if len(*t.directories) == 0 {
+		return "<no directories>"
+	}
+
+	p.Fprintln(w, strings.Join([]string{
+		"Directory",
+		"Lines",
+		"Bus Factor",
+		"Top Authors",
+		"Abandoned",
+	}, "\t"))
+
+	for i, dir := range *t.directories {
+		if i > limit-1 && limit != 0 {
+			break
+		}
+
+		var topAuthors []string
+		for _, author := range dir.Authors[:dir.BusFactor] {
+			name := author.Name
+			if author.Org != "" {
+				name = fmt.Sprintf("%s (%s)", name, author.Org)
+			}
+			if !author.Active {
+				lastCommit := "never"
+				if when, err := time.Parse(time.RFC3339, author.LastCommit.String); err == nil {
+					lastCommit = timediff.TimeDiff(when)
+				}
+				name = fmt.Sprintf("%s [last commit %s]", name, lastCommit)
+			}
+			topAuthors = append(topAuthors, p.Sprintf("%s %.0f%%", name, author.Share))
+		}
+
+		var abandoned string
+		if dir.Abandoned {
+			abandoned = "yes"
+		}
+
+		p.Fprintln(w, strings.Join([]string{
+			directoryName(dir.Directory),
+			p.Sprintf("%d", dir.Lines),
+			p.Sprintf("%d", dir.BusFactor),
+			strings.Join(topAuthors, ", "),
+			abandoned,
+		}, "\t"))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err.Error()
+	}
+
+	if limit != 0 {
+		d := len(*t.directories) - limit
+		if d == 1 {
+			p.Fprintf(&b, "...1 more directory\n")
+		} else if d > 1 {
+			p.Fprintf(&b, "...%d more directories\n", d)
+		}
+	}
+
+	return b.String()
+}
+
+// directoryName returns how a directory is displayed, with the root of the repository as "."
+func directoryName(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return strings.TrimSuffix(dir, "/")
+}
+
+func (t *TermUI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case error:
+		t.err = msg
+		return t, tea.Quit
+
+	case tea.KeyMsg:
+		switch msg.String() {
+
+		case "ctrl+c", "q":
+			return t, tea.Quit
+		}
+
+	default:
+		if t.directories != nil && t.preloaded {
+			return t, tea.Quit
+		}
+		var cmd tea.Cmd
+		t.spinner, cmd = t.spinner.Update(msg)
+		return t, cmd
+	}
+
+	return t, nil
+}
+
+func (t *TermUI) View() string {
+	if t.err != nil {
+		return t.err.Error()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprint(&b, t.renderOwnershipSummaryTable(true))
+	fmt.Fprint(&b, t.renderDirectoryOwnership(25))
+
+	return b.String()
+}
+
+// load preloads and loads the ownership, outside of the bubbletea program
+func (t *TermUI) load() {
+	for _, fn := range []func() tea.Msg{t.preloadOwnership, t.loadOwnership} {
+		if err, ok := fn().(error); ok {
+			t.err = err
+			return
+		}
+	}
+}
+
+// PrintNoTTY prints a version of output with no terminal styles
+func (t *TermUI) PrintNoTTY() string {
+	if t.load(); t.err != nil {
+		return t.err.Error()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprint(&b, t.renderOwnershipSummaryTable(false))
+	fmt.Fprint(&b, t.renderDirectoryOwnership(0))
+
+	return b.String()
+}
+
+// PrintJSON outputs summary results as a JSON object
+func (t *TermUI) PrintJSON() string {
+	if t.load(); t.err != nil {
+		return t.err.Error()
+	}
+
+	directories := make([]map[string]interface{}, len(*t.directories))
+	for i, dir := range *t.directories {
+		authors := make([]map[string]interface{}, len(dir.Authors))
+		for j, author := range dir.Authors {
+			authors[j] = map[string]interface{}{
+				"name":        author.Name,
+				"org":         nil,
+				"lines":       author.Lines,
+				"linePercent": author.Share,
+				"lastCommit":  nil,
+				"active":      author.Active,
+			}
+			if author.Org != "" {
+				authors[j]["org"] = author.Org
+			}
+			if author.LastCommit.Valid {
+				authors[j]["lastCommit"] = author.LastCommit.String
+			}
+		}
+
+		directories[i] = map[string]interface{}{
+			"directory": directoryName(dir.Directory),
+			"lines":     dir.Lines,
+			"busFactor": dir.BusFactor,
+			"abandoned": dir.Abandoned,
+			"authors":   authors,
+		}
+	}
+
+	output := map[string]interface{}{
+		"directories":     directories,
+		"totalLines":      t.totalLines,
+		"distinctAuthors": t.distinctAuthors,
+	}
+
+	if o, err := json.MarshalIndent(output, "", "  "); err != nil {
+		return err.Error()
+	} else {
+		return string(o)
+	}
+}
+
+func (t *TermUI) Close() error {
+	defer t.db.Close()
+	if t.err != nil {
+		return t.err
+	}
+	return nil
+}
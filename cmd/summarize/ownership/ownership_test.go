@@ -0,0 +1,214 @@
+package ownership
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/cmd/summarize/internal/summarizetest"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// ownershipFixture creates a repository where Jane Doe wrote the README (2 lines) and pkg/a.go (6 lines) in 2020,
+// then Bob pkg/b.go (4 lines) and Alice pkg/c.go (3 lines) in 2021, and makes it the current directory
+func ownershipFixture(t *testing.T) {
+	repo := fixture.NewRepo(t)
+	repo.Commit("first", map[string]string{"README.md": summarizetest.Lines(2), "pkg/a.go": summarizetest.Lines(6)})
+
+	repo.Author.Name, repo.Author.Email = "Bob", "bob@example.com"
+	repo.Author.When = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo.Commit("second", map[string]string{"pkg/b.go": summarizetest.Lines(4)})
+
+	repo.Author.Name, repo.Author.Email = "Alice", "alice@example.com"
+	repo.Commit("third", map[string]string{"pkg/c.go": summarizetest.Lines(3)})
+
+	summarizetest.Chdir(t, repo.Dir)
+}
+
+type ownershipJSON struct {
+	Directories []struct {
+		Directory string `json:"directory"`
+		Lines     int    `json:"lines"`
+		BusFactor int    `json:"busFactor"`
+		Abandoned bool   `json:"abandoned"`
+		Authors   []struct {
+			Name   string  `json:"name"`
+			Org    *string `json:"org"`
+			Lines  int     `json:"lines"`
+			Share  float64 `json:"linePercent"`
+			Active bool    `json:"active"`
+		} `json:"authors"`
+	} `json:"directories"`
+	TotalLines      int `json:"totalLines"`
+	DistinctAuthors int `json:"distinctAuthors"`
+}
+
+func TestOwnership(t *testing.T) {
+	ownershipFixture(t)
+
+	summary := summarizetest.JSON[ownershipJSON](t)(NewTermUI("", "2021-01-01", false, false))
+	if summary.TotalLines != 15 || summary.DistinctAuthors != 3 || len(summary.Directories) != 2 {
+		t.Fatalf("expected 15 lines of 3 authors in 2 directories, got: %+v", summary)
+	}
+
+	// the root directory has all the lines, and Jane, who hasn't committed since 2020, owns more than half of them
+	root := summary.Directories[0]
+	if root.Directory != "." || root.Lines != 15 || root.BusFactor != 1 || !root.Abandoned {
+		t.Errorf("unexpected ownership of the root directory: %+v", root)
+	}
+
+	// the lines of pkg are shared by Jane (6), Bob (4) and Alice (3):
+	// it takes Jane and Bob to own at least half of them, and Bob is still active
+	pkg := summary.Directories[1]
+	if pkg.Directory != "pkg" || pkg.Lines != 13 || pkg.BusFactor != 2 || pkg.Abandoned {
+		t.Errorf("unexpected ownership of pkg: %+v", pkg)
+	}
+	var expected = []struct {
+		name   string
+		lines  int
+		active bool
+	}{{"Jane Doe", 6, false}, {"Bob", 4, true}, {"Alice", 3, true}}
+	if len(pkg.Authors) != len(expected) {
+		t.Fatalf("expected %d authors of pkg, got: %+v", len(expected), pkg.Authors)
+	}
+	for i, author := range pkg.Authors {
+		if author.Name != expected[i].name || author.Lines != expected[i].lines || author.Active != expected[i].active {
+			t.Errorf("expected author %d of pkg to be %+v, got: %+v", i, expected[i], author)
+		}
+	}
+	if share := pkg.Authors[0].Share; share < 46.1 || share > 46.2 {
+		t.Errorf("expected Jane Doe to own 46.15%% of pkg, got %.2f%%", share)
+	}
+}
+
+func TestOwnershipOfParentDirectories(t *testing.T) {
+	repo := fixture.NewRepo(t)
+	repo.Commit("first", map[string]string{"a/b/c.go": summarizetest.Lines(4), "e/f.go": summarizetest.Lines(3)})
+	repo.Author.Name, repo.Author.Email = "Bob", "bob@example.com"
+	repo.Author.When = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo.Commit("second", map[string]string{"a/d.go": summarizetest.Lines(2), "g.go": summarizetest.Lines(10)})
+	summarizetest.Chdir(t, repo.Dir)
+
+	ui, err := NewTermUI("", "2021-01-01", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ui.Close()
+	if ui.load(); ui.err != nil {
+		t.Fatal(ui.err)
+	}
+
+	// each directory has the lines of the ones within it, and only Jane owns a, a/b and e
+	var expected = map[string]struct {
+		lines     int
+		abandoned bool
+	}{"": {19, false}, "a/": {6, true}, "a/b/": {4, true}, "e/": {3, true}}
+	if len(*ui.directories) != len(expected) {
+		t.Fatalf("expected %d directories, got %d", len(expected), len(*ui.directories))
+	}
+	for _, dir := range *ui.directories {
+		if e := expected[dir.Directory]; dir.Lines != e.lines || dir.Abandoned != e.abandoned {
+			t.Errorf("expected %q to have %d lines (abandoned: %v), got %d (%v)", dir.Directory, e.lines, e.abandoned, dir.Lines, dir.Abandoned)
+		}
+	}
+
+	// the lines of a/b are abandoned along with a, and only counted once
+	if ui.totalLines != 19 || ui.abandonedLines != 9 {
+		t.Errorf("expected 19 lines with 9 abandoned, got %d with %d", ui.totalLines, ui.abandonedLines)
+	}
+}
+
+func TestOwnershipLastCommitTimeZones(t *testing.T) {
+	repo := fixture.NewRepo(t)
+
+	// 2021-01-01T01:00:00+05:00 is 2020-12-31T20:00:00Z, before the commit made at 2020-12-31T22:00:00Z
+	repo.Author.When = time.Date(2021, 1, 1, 0, 0, 0, 0, time.FixedZone("", 5*60*60))
+	repo.Commit("first", map[string]string{"a.go": summarizetest.Lines(2)})
+	repo.Author.When = time.Date(2020, 12, 31, 21, 0, 0, 0, time.UTC)
+	repo.Commit("second", map[string]string{"b.go": summarizetest.Lines(1)})
+	summarizetest.Chdir(t, repo.Dir)
+
+	ui, err := NewTermUI("", "2021-01-01", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ui.Close()
+	if ui.load(); ui.err != nil {
+		t.Fatal(ui.err)
+	}
+
+	author := (*ui.directories)[0].Authors[0]
+	if author.LastCommit.String != "2020-12-31T22:00:00Z" || author.Active {
+		t.Fatalf("expected an inactive author whose last commit is 2020-12-31T22:00:00Z, got: %+v", author)
+	}
+}
+
+func TestOwnershipActiveSince(t *testing.T) {
+	ownershipFixture(t)
+
+	// with activity relative to now, none of the authors of the fixture are active anymore
+	for _, dir := range summarizetest.JSON[ownershipJSON](t)(NewTermUI("", "-1 year", false, false)).Directories {
+		if !dir.Abandoned {
+			t.Errorf("expected %s to be abandoned", dir.Directory)
+		}
+	}
+
+	for _, dir := range summarizetest.JSON[ownershipJSON](t)(NewTermUI("", "2019-12-31", false, false)).Directories {
+		if dir.Abandoned {
+			t.Errorf("expected %s not to be abandoned", dir.Directory)
+		}
+	}
+}
+
+func TestOwnershipPathPattern(t *testing.T) {
+	ownershipFixture(t)
+
+	// the root directory only has the lines of pkg
+	summary := summarizetest.JSON[ownershipJSON](t)(NewTermUI("pkg/%", "2021-01-01", false, false))
+	if len(summary.Directories) != 2 || summary.Directories[1].Directory != "pkg" || summary.TotalLines != 13 {
+		t.Fatalf("expected only pkg to be summarized, got: %+v", summary)
+	}
+	if root := summary.Directories[0]; root.Directory != "." || root.Lines != 13 {
+		t.Fatalf("expected the root directory to have the 13 lines of pkg, got: %+v", root)
+	}
+}
+
+func TestOwnershipByIdentity(t *testing.T) {
+	repo := fixture.NewRepo(t)
+	repo.Commit("first", map[string]string{"a.go": summarizetest.Lines(2)})
+	repo.Author.Email = "jane@work.example.com"
+	repo.Commit("second", map[string]string{"b.go": summarizetest.Lines(1)})
+	summarizetest.Chdir(t, repo.Dir)
+
+	// without identities, Jane Doe's two emails are two authors
+	summary := summarizetest.JSON[ownershipJSON](t)(NewTermUI("", "2019-12-31", false, false))
+	if summary.DistinctAuthors != 2 || len(summary.Directories[0].Authors) != 2 {
+		t.Fatalf("expected 2 authors, got: %+v", summary)
+	}
+	for _, author := range summary.Directories[0].Authors {
+		if author.Name != "Jane Doe" || author.Org != nil {
+			t.Errorf("expected Jane Doe with a null org, got: %+v", author)
+		}
+	}
+
+	// grouped by identity, they are one
+	summary = summarizetest.JSON[ownershipJSON](t)(NewTermUI("", "2019-12-31", false, true))
+	if dir := summary.Directories[0]; summary.DistinctAuthors != 1 || len(dir.Authors) != 1 || dir.Authors[0].Lines != 3 || !dir.Authors[0].Active {
+		t.Fatalf("expected one active author of 3 lines, got: %+v", summary)
+	}
+}
+
+func TestParentDirectory(t *testing.T) {
+	for dir, expected := range map[string]string{"pkg/": "", "pkg/display/": "pkg/", "a/b/c/": "a/b/"} {
+		if parent := parentDirectory(dir); parent != expected {
+			t.Errorf("parentDirectory(%q) = %q, want %q", dir, parent, expected)
+		}
+	}
+}
+
+func TestDirectoryName(t *testing.T) {
+	for dir, expected := range map[string]string{"": ".", "pkg/": "pkg", "pkg/display/": "pkg/display"} {
+		if name := directoryName(dir); name != expected {
+			t.Errorf("directoryName(%q) = %q, want %q", dir, name, expected)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ownership
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/cmd/summarize/report"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Report builds a report of the ownership, as rendered by PrintMarkdown and PrintHTML
+func (t *TermUI) Report() (*report.Report, error) {
+	if t.load(); t.err != nil {
+		return nil, t.err
+	}
+
+	p := message.NewPrinter(language.English)
+
+	var subtitle string
+	if t.pathPattern != "%" {
+		subtitle = "files matching " + t.pathPattern
+	}
+
+	var r = &report.Report{Title: "Ownership", Subtitle: subtitle}
+
+	var busFactorOne, abandoned int
+	var busFactors = &report.BarChart{Title: "Directories by Bus Factor", Unit: "directories"}
+	for _, dir := range *t.directories {
+		if dir.BusFactor == 1 {
+			busFactorOne++
+		}
+		if dir.Abandoned {
+			abandoned++
+		}
+
+		// a bar per bus factor, from 1 to the highest one
+		for len(busFactors.Bars) < dir.BusFactor {
+			busFactors.Bars = append(busFactors.Bars, report.Bar{Label: fmt.Sprint(len(busFactors.Bars) + 1)})
+		}
+		if dir.BusFactor > 0 {
+			busFactors.Bars[dir.BusFactor-1].Value++
+		}
+	}
+
+	r.Sections = append(r.Sections, &report.Facts{Facts: []report.Fact{
+		{Name: "Directories", Value: p.Sprintf("%d", len(*t.directories))},
+		{Name: "Total Lines", Value: p.Sprintf("%d", t.totalLines)},
+		{Name: "Distinct Authors", Value: p.Sprintf("%d", t.distinctAuthors)},
+		{Name: "Bus Factor of 1", Value: p.Sprintf("%d", busFactorOne)},
+		{Name: "Abandoned", Value: p.Sprintf("%d (%d lines)", abandoned, t.abandonedLines)},
+	}}, busFactors)
+
+	var directories = &report.Table{
+		Title:   "Directories",
+		Columns: []string{"Directory", "Lines", "Bus Factor", "Top Authors", "Abandoned"},
+	}
+	for _, dir := range *t.directories {
+		var topAuthors []string
+		for _, author := range dir.Authors[:dir.BusFactor] {
+			name := author.Name
+			if author.Org != "" {
+				name = fmt.Sprintf("%s (%s)", name, author.Org)
+			}
+			if !author.Active {
+				name = fmt.Sprintf("%s [last commit %s]", name, report.FormatDate(author.LastCommit.String))
+			}
+			topAuthors = append(topAuthors, p.Sprintf("%s %.0f%%", name, author.Share))
+		}
+
+		var abandoned string
+		if dir.Abandoned {
+			abandoned = "yes"
+		}
+
+		directories.Rows = append(directories.Rows, []string{
+			directoryName(dir.Directory),
+			p.Sprintf("%d", dir.Lines),
+			p.Sprintf("%d", dir.BusFactor),
+			strings.Join(topAuthors, ", "),
+			abandoned,
+		})
+	}
+	r.Sections = append(r.Sections, directories)
+
+	return r, nil
+}
+
+// PrintMarkdown outputs the ownership as a markdown report
+func (t *TermUI) PrintMarkdown() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+	return report.Markdown(r)
+}
+
+// PrintHTML outputs the ownership as a self-contained HTML report
+func (t *TermUI) PrintHTML() string {
+	r, err := t.Report()
+	if err != nil {
+		return err.Error()
+	}
+
+	page, err := report.HTML(r)
+	if err != nil {
+		t.err = err
+		return err.Error()
+	}
+	return page
+}
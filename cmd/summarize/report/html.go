@@ -0,0 +1,235 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"math"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+)
+
+// dimensions (in pixels) of the charts drawn in HTML reports
+const (
+	chartWidth      = 720
+	chartLabelWidth = 220 // left of horizontal bars, for their labels
+	chartValueWidth = 80  // right of horizontal bars, for their values
+	chartRowHeight  = 22  // of a horizontal bar, including the space around it
+	chartHeight     = 180 // of the plot of a series, excluding its axis
+	chartAxisHeight = 20  // below the plot of a series, for its labels
+	chartMaxLabels  = 8   // labels along the axis of a series
+)
+
+// HTML renders the report as a self-contained HTML page: styles are inlined and charts are inline SVG,
+// so that it can be viewed offline, or attached and shared as a single file.
+func HTML(r *Report) (string, error) {
+	var page = htmlPage{Title: r.Title, Subtitle: r.Subtitle}
+	for _, section := range r.Sections {
+		s := htmlSection{Title: section.heading()}
+		switch section := section.(type) {
+		case *Facts:
+			s.Facts = section
+		case *Table:
+			s.Table = section
+		case *BarChart:
+			s.Chart = layoutChart(section)
+		}
+		page.Sections = append(page.Sections, s)
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, page); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+type htmlPage struct {
+	Title, Subtitle string
+	Sections        []htmlSection
+}
+
+// htmlSection has exactly one of its Facts, Table or Chart set
+type htmlSection struct {
+	Title string
+	Facts *Facts
+	Table *Table
+	Chart *svgChart
+}
+
+// svgChart is a BarChart laid out for drawing
+type svgChart struct {
+	Width, Height int
+	Unit          string
+	Series        bool
+	Bars          []svgBar
+	Labels        []svgLabel // along the axis, for series
+	AxisY         int
+}
+
+type svgBar struct {
+	X, Y, Width, Height float64
+	Label, Value        string
+	ShortLabel          string  // truncated to fit left of horizontal bars
+	LabelX, ValueX      float64 // for horizontal bars
+	TextY               float64
+}
+
+type svgLabel struct {
+	X    float64
+	Text string
+}
+
+func layoutChart(c *BarChart) *svgChart {
+	var max = c.max()
+	var chart = &svgChart{Width: chartWidth, Unit: c.Unit, Series: c.Series}
+
+	scale := func(v, length float64) float64 {
+		if max <= 0 {
+			return 0
+		}
+		return v / max * length
+	}
+
+	if !c.Series {
+		chart.Height = len(c.Bars) * chartRowHeight
+		barLength := float64(chartWidth - chartLabelWidth - chartValueWidth)
+		for i, bar := range c.Bars {
+			y := float64(i * chartRowHeight)
+			w := scale(bar.Value, barLength)
+			chart.Bars = append(chart.Bars, svgBar{
+				X: chartLabelWidth, Y: y + 3, Width: w, Height: chartRowHeight - 6,
+				Label: bar.Label, Value: formatValue(bar.Value), ShortLabel: truncate(bar.Label, 32),
+				LabelX: chartLabelWidth - 8, ValueX: chartLabelWidth + w + 6, TextY: y + chartRowHeight/2 + 4,
+			})
+		}
+		return chart
+	}
+
+	chart.Height = chartHeight + chartAxisHeight
+	chart.AxisY = chartHeight
+	if len(c.Bars) == 0 {
+		return chart
+	}
+
+	step := float64(chartWidth) / float64(len(c.Bars))
+	gap := math.Min(2, step/4)
+	every := int(math.Ceil(float64(len(c.Bars)) / chartMaxLabels))
+	for i, bar := range c.Bars {
+		h := scale(bar.Value, chartHeight-4)
+		x := float64(i) * step
+		chart.Bars = append(chart.Bars, svgBar{
+			X: x + gap/2, Y: chartHeight - h, Width: step - gap, Height: h,
+			Label: bar.Label, Value: formatValue(bar.Value),
+		})
+		if i%every == 0 {
+			chart.Labels = append(chart.Labels, svgLabel{X: x, Text: bar.Label})
+		}
+	}
+	return chart
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if it had to be shortened
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// htmlTable renders a table as the html output format writes query results (see display.HTMLTable)
+func htmlTable(columns []string, rows [][]string) (template.HTML, error) {
+	var b strings.Builder
+	t, err := display.NewHTMLTable(&b, columns)
+	if err != nil {
+		return "", err
+	}
+	for _, row := range rows {
+		if err = t.Row(row); err != nil {
+			return "", err
+		}
+	}
+	if err = t.Close(); err != nil {
+		return "", err
+	}
+	// the table is escaped by display.HTMLTable, and ends with a newline the template already has
+	return template.HTML(strings.TrimSuffix(b.String(), "\n")), nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{"table": htmlTable}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292f; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { margin-bottom: 0.2em; }
+.subtitle { color: #57606a; margin-top: 0; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: 0.3em; margin-top: 2em; }
+table { border-collapse: collapse; font-size: 14px; }
+th, td { border: 1px solid #d0d7de; padding: 4px 10px; text-align: left; }
+th { background: #f6f8fa; }
+table.facts th { width: 12em; }
+.note { color: #57606a; font-style: italic; }
+svg { font-size: 12px; }
+svg rect { fill: #2f81f7; }
+svg text { fill: #24292f; }
+svg .axis { stroke: #d0d7de; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Subtitle}}
+<p class="subtitle">{{.Subtitle}}</p>
+{{- end}}
+{{- range .Sections}}
+{{- if .Title}}
+<h2>{{.Title}}</h2>
+{{- end}}
+{{- with .Facts}}
+<table class="facts">
+{{- range .Facts}}
+<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- with .Table}}
+{{- if .Rows}}
+{{table .Columns .Rows}}
+{{- else}}
+<p class="note">none</p>
+{{- end}}
+{{- if .Note}}
+<p class="note">{{.Note}}</p>
+{{- end}}
+{{- end}}
+{{- with .Chart}}
+{{- if .Bars}}
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img">
+{{- if .Series}}
+{{- $unit := .Unit}}
+{{- range .Bars}}
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{.Label}}: {{.Value}} {{$unit}}</title></rect>
+{{- end}}
+<line class="axis" x1="0" y1="{{.AxisY}}" x2="{{.Width}}" y2="{{.AxisY}}"/>
+{{- $axis := .AxisY}}
+{{- range .Labels}}
+<text x="{{.X}}" y="{{$axis}}" dy="14">{{.Text}}</text>
+{{- end}}
+{{- else}}
+{{- $unit := .Unit}}
+{{- range .Bars}}
+<text x="{{.LabelX}}" y="{{.TextY}}" text-anchor="end">{{.ShortLabel}}</text>
+<rect x="{{.X}}" y="{{.Y}}" width="{{.Width}}" height="{{.Height}}"><title>{{.Label}}: {{.Value}} {{$unit}}</title></rect>
+<text x="{{.ValueX}}" y="{{.TextY}}">{{.Value}}</text>
+{{- end}}
+{{- end}}
+</svg>
+{{- else}}
+<p class="note">none</p>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+)
+
+// markdownBarWidth is the width, in characters, of the longest bar of a chart rendered in markdown
+const markdownBarWidth = 30
+
+// Markdown renders the report as GitHub flavored markdown. Tables are written as the markdown output format writes
+// query results (see display.MarkdownTable), and charts are rendered as tables, with bars drawn in text.
+func Markdown(r *Report) string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# %s\n\n", display.EscapeMarkdown(r.Title))
+	if r.Subtitle != "" {
+		fmt.Fprintf(&b, "%s\n\n", display.EscapeMarkdown(r.Subtitle))
+	}
+
+	for _, section := range r.Sections {
+		if title := section.heading(); title != "" {
+			fmt.Fprintf(&b, "## %s\n\n", display.EscapeMarkdown(title))
+		}
+
+		switch s := section.(type) {
+		case *Facts:
+			rows := make([][]string, len(s.Facts))
+			for i, fact := range s.Facts {
+				rows[i] = []string{fact.Name, fact.Value}
+			}
+			writeMarkdownTable(&b, []string{"", ""}, rows)
+		case *Table:
+			writeMarkdownTable(&b, s.Columns, s.Rows)
+			if s.Note != "" {
+				fmt.Fprintf(&b, "_%s_\n\n", display.EscapeMarkdown(s.Note))
+			}
+		case *BarChart:
+			max := s.max()
+			rows := make([][]string, len(s.Bars))
+			for i, bar := range s.Bars {
+				var drawn string
+				if max > 0 && bar.Value > 0 {
+					drawn = strings.Repeat("█", int(math.Max(1, math.Round(bar.Value/max*markdownBarWidth))))
+				}
+				rows[i] = []string{bar.Label, formatValue(bar.Value), drawn}
+			}
+			writeMarkdownTable(&b, []string{"", s.Unit, ""}, rows)
+		}
+	}
+
+	return b.String()
+}
+
+func writeMarkdownTable(b *bytes.Buffer, columns []string, rows [][]string) {
+	if len(rows) == 0 {
+		fmt.Fprint(b, "_none_\n\n")
+		return
+	}
+
+	// writes to a bytes.Buffer don't fail
+	t, _ := display.NewMarkdownTable(b, columns)
+	for _, row := range rows {
+		_ = t.Row(row)
+	}
+	fmt.Fprintln(b)
+}
@@ -0,0 +1,95 @@
+// Package report renders the results of the summarize commands as documents meant to be shared,
+// in markdown (for PR descriptions and wikis) or as a self-contained HTML page, with charts drawn in inline SVG.
+// Nothing is loaded from the network when rendering or viewing a report.
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report is a titled list of sections, rendered in order
+type Report struct {
+	Title    string
+	Subtitle string
+	Sections []Section
+}
+
+// Section is one of *Facts, *Table or *BarChart
+type Section interface {
+	heading() string
+}
+
+// Facts is a list of named values, such as the totals of a summary
+type Facts struct {
+	Title string
+	Facts []Fact
+}
+
+// Fact is a single named value
+type Fact struct {
+	Name, Value string
+}
+
+// Table is a table of preformatted values, with a row per entry
+type Table struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+
+	// Note is displayed under the table, for instance to mention rows that were left out
+	Note string
+}
+
+// BarChart is a chart of values, one bar per label
+type BarChart struct {
+	Title string
+	Bars  []Bar
+
+	// Unit describes the values, as in "commits" or "lines"
+	Unit string
+
+	// Series draws bars as vertical columns, in order, as is better suited for a time series.
+	// Otherwise, bars are horizontal, each one labelled.
+	Series bool
+}
+
+// Bar is a single value of a BarChart
+type Bar struct {
+	Label string
+	Value float64
+}
+
+func (f *Facts) heading() string    { return f.Title }
+func (t *Table) heading() string    { return t.Title }
+func (c *BarChart) heading() string { return c.Title }
+
+// max returns the largest value in the chart, or 0 if it is empty
+func (c *BarChart) max() float64 {
+	var max float64
+	for _, bar := range c.Bars {
+		if bar.Value > max {
+			max = bar.Value
+		}
+	}
+	return max
+}
+
+// formatValue formats a bar value, without decimals if it is a whole number
+func formatValue(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+// FormatDate formats an RFC3339 timestamp as a date, or as <none> if it is empty or invalid.
+// Reports are meant to be read later on, so dates are absolute rather than relative to now.
+func FormatDate(s string) string {
+	when, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "<none>"
+	}
+	return when.Format("2006-01-02")
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the reports")
+
+// sample is a report with every kind of section, and text to escape in each format
+var sample = &Report{
+	Title:    "Commit Summary",
+	Subtitle: "mergestat/mergestat-lite, since 2024-01-01",
+	Sections: []Section{
+		&Facts{Facts: []Fact{
+			{Name: "Commits", Value: "42"},
+			{Name: "Latest Commit", Value: "2024-03-04"},
+		}},
+		&BarChart{Title: "Commits per Week", Unit: "commits", Series: true, Bars: []Bar{
+			{Label: "2024-01-01", Value: 4},
+			{Label: "2024-01-08", Value: 0},
+			{Label: "2024-01-15", Value: 10},
+		}},
+		&BarChart{Title: "Top Authors", Unit: "commits", Bars: []Bar{
+			{Label: "Jane <jane@example.com>", Value: 30},
+			{Label: "bob_the_builder", Value: 12.5},
+		}},
+		&Table{
+			Title:   "Files",
+			Columns: []string{"path", "commits"},
+			Rows: [][]string{
+				{"pkg/__init__.py", "3"},
+				{"a|b & <c>.go", "1"},
+			},
+			Note: "and 2 more files",
+		},
+		&Table{Title: "Bots", Columns: []string{"name"}},
+	},
+}
+
+// golden compares rendered to the golden file of the given name, or updates it with -update
+func golden(t *testing.T, name, rendered string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(rendered), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered != string(expected) {
+		t.Fatalf("unexpected rendering, run the tests with -update to see the differences with git diff.\nexpected:\n%s\ngot:\n%s", expected, rendered)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	golden(t, "report.md", Markdown(sample))
+}
+
+func TestHTML(t *testing.T) {
+	page, err := HTML(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "report.html", page)
+}
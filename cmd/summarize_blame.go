@@ -1,20 +1,32 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mergestat/mergestat-lite/cmd/summarize/blame"
 	"github.com/spf13/cobra"
 )
 
 var (
-	blameOutputJSON bool
+	blameOutputJSON       bool
+	blameExcludeBots      bool
+	blameRepos            []string
+	blameRev              string
+	blameSince            string
+	blameUntil            string
+	blameExclude          []string
+	blameExcludeVendored  bool
+	blameExcludeGenerated bool
 )
 
 func init() {
-	summarizeBlameCmd.Flags().BoolVar(&blameOutputJSON, "json", false, "output as JSON")
+	summarizeBlameCmd.Flags().BoolVar(&blameOutputJSON, "json", false, "output as JSON (same as --format json)")
+	summarizeBlameCmd.Flags().BoolVar(&blameExcludeBots, "exclude-bots", false, "exclude lines authored by bots (see --identities)")
+	summarizeBlameCmd.Flags().StringSliceVar(&blameRepos, "repos", nil, "summarize these repositories (paths or URLs, comma separated or repeated) instead of the default repo")
+	summarizeBlameCmd.Flags().StringVar(&blameRev, "rev", "", "blame this branch, tag or commit instead of HEAD")
+	summarizeBlameCmd.Flags().StringVar(&blameSince, "since", "", "classify lines authored since a date as new, and older lines as legacy. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
+	summarizeBlameCmd.Flags().StringVar(&blameUntil, "until", "", "leave out lines authored after a date. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
+	summarizeBlameCmd.Flags().StringSliceVar(&blameExclude, "exclude", nil, "skip files matching these globs (comma separated or repeated), such as 'vendor/*' or '*.pb.go'")
+	summarizeBlameCmd.Flags().BoolVar(&blameExcludeVendored, "exclude-vendored", false, "skip vendored files, such as node_modules/ (as detected by enry_is_vendor)")
+	summarizeBlameCmd.Flags().BoolVar(&blameExcludeGenerated, "exclude-generated", false, "skip generated files, such as minified javascript (as detected by enry_is_generated)")
 }
 
 var summarizeBlameCmd = &cobra.Command{
@@ -23,6 +35,9 @@ var summarizeBlameCmd = &cobra.Command{
 	Long: `Prints a summary of the blameable lines for all files matching the supplied path pattern in the default repo (--repo or current directory).
 Specify a file path pattern as the first argument to see aggregate blame data for all files that match the pattern.
 Use '%' to match all file paths or as a wildcard (e.g. '%.go' for all .go files). You may specify a full file path (no wildcard) as well.
+Use --repos to summarize several repositories at once. The breakdown per repository is only shown with more than one,
+except in the JSON output, which always lists the repositories.
+Use --since to split lines into new and legacy ones, and --exclude to skip files by glob (GLOB is case sensitive and '*' matches '/' as well).
 `,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -30,7 +45,24 @@ Use '%' to match all file paths or as a wildcard (e.g. '%.go' for all .go files)
 
 		var ui *blame.TermUI
 		var err error
-		if ui, err = blame.NewTermUI(pathPattern); err != nil {
+		var repos = blameRepos
+		if len(repos) == 0 {
+			repos = []string{repo}
+		}
+
+		var opts = blame.Options{
+			PathPattern:      pathPattern,
+			ExcludeBots:      blameExcludeBots,
+			ByIdentity:       identitiesFile != "",
+			Repos:            repos,
+			Rev:              blameRev,
+			Since:            blameSince,
+			Until:            blameUntil,
+			Exclude:          blameExclude,
+			ExcludeVendored:  blameExcludeVendored,
+			ExcludeGenerated: blameExcludeGenerated,
+		}
+		if ui, err = blame.NewTermUI(opts); err != nil {
 			handleExitError(err)
 		}
 		defer func() {
@@ -39,18 +71,6 @@ Use '%' to match all file paths or as a wildcard (e.g. '%.go' for all .go files)
 			}
 		}()
 
-		if blameOutputJSON {
-			fmt.Println(ui.PrintJSON())
-			return
-		}
-
-		// check if output is a terminal (https://rosettacode.org/wiki/Check_output_device_is_a_terminal#Go)
-		if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
-			if _, err := tea.NewProgram(ui).Run(); err != nil {
-				handleExitError(err)
-			}
-		} else {
-			fmt.Print(ui.PrintNoTTY())
-		}
+		printSummary(ui, blameOutputJSON)
 	},
 }
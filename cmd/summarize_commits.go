@@ -1,10 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/mergestat/mergestat-lite/cmd/summarize/commits"
 	"github.com/spf13/cobra"
 )
@@ -13,18 +9,26 @@ var (
 	summarizeDateFilterStart string
 	summarizeDateFilterEnd   string
 	summarizeOutputJSON      bool
+	summarizeExcludeBots     bool
+	summarizeRepos           []string
+	summarizeRev             string
 )
 
 func init() {
 	summarizeCommitsCmd.Flags().StringVarP(&summarizeDateFilterStart, "start", "s", "", "specify a start date to filter by. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
 	summarizeCommitsCmd.Flags().StringVarP(&summarizeDateFilterEnd, "end", "e", "", "specify an end date to filter by. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
-	summarizeCommitsCmd.Flags().BoolVar(&summarizeOutputJSON, "json", false, "output as JSON")
+	summarizeCommitsCmd.Flags().BoolVar(&summarizeOutputJSON, "json", false, "output as JSON (same as --format json)")
+	summarizeCommitsCmd.Flags().BoolVar(&summarizeExcludeBots, "exclude-bots", false, "exclude commits authored by bots (see --identities)")
+	summarizeCommitsCmd.Flags().StringSliceVar(&summarizeRepos, "repos", nil, "summarize these repositories (paths or URLs, comma separated or repeated) instead of the default repo")
+	summarizeCommitsCmd.Flags().StringVar(&summarizeRev, "rev", "", "summarize the history of this branch, tag or commit instead of HEAD")
 }
 
 var summarizeCommitsCmd = &cobra.Command{
 	Use:   "commits [file pattern]",
 	Short: "Print a summary of commit activity",
 	Long: `Prints a summary of commit activity in the default repository (either the current directory or supplied by --repo).
+Use --repos to summarize several repositories at once. The breakdown per repository is only shown with more than one,
+except in the JSON output, which always lists the repositories.
 Specify a file pattern as an argument to filter for commits that only modified a certain file or directory.
 The path is used in a SQL LIKE clause, so use '%' as a wildcard.
 Read more here: https://sqlite.org/lang_expr.html#the_like_glob_regexp_and_match_operators
@@ -38,7 +42,21 @@ Read more here: https://sqlite.org/lang_expr.html#the_like_glob_regexp_and_match
 
 		var ui *commits.TermUI
 		var err error
-		if ui, err = commits.NewTermUI(pathPattern, summarizeDateFilterStart, summarizeDateFilterEnd); err != nil {
+		var repos = summarizeRepos
+		if len(repos) == 0 {
+			repos = []string{repo}
+		}
+
+		var opts = commits.Options{
+			PathPattern: pathPattern,
+			Start:       summarizeDateFilterStart,
+			End:         summarizeDateFilterEnd,
+			ExcludeBots: summarizeExcludeBots,
+			ByIdentity:  identitiesFile != "",
+			Repos:       repos,
+			Rev:         summarizeRev,
+		}
+		if ui, err = commits.NewTermUI(opts); err != nil {
 			handleExitError(err)
 		}
 		defer func() {
@@ -47,18 +65,6 @@ Read more here: https://sqlite.org/lang_expr.html#the_like_glob_regexp_and_match
 			}
 		}()
 
-		if summarizeOutputJSON {
-			fmt.Println(ui.PrintJSON())
-			return
-		}
-
-		// check if output is a terminal (https://rosettacode.org/wiki/Check_output_device_is_a_terminal#Go)
-		if fileInfo, _ := os.Stdout.Stat(); (fileInfo.Mode() & os.ModeCharDevice) != 0 {
-			if _, err := tea.NewProgram(ui).Run(); err != nil {
-				handleExitError(err)
-			}
-		} else {
-			fmt.Print(ui.PrintNoTTY())
-		}
+		printSummary(ui, summarizeOutputJSON)
 	},
 }
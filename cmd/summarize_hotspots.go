@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"github.com/mergestat/mergestat-lite/cmd/summarize/hotspots"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hotspotsSince      string
+	hotspotsOutputJSON bool
+)
+
+func init() {
+	summarizeHotspotsCmd.Flags().StringVarP(&hotspotsSince, "since", "s", "", "only count changes since a date. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
+	summarizeHotspotsCmd.Flags().BoolVar(&hotspotsOutputJSON, "json", false, "output as JSON (same as --format json)")
+}
+
+var summarizeHotspotsCmd = &cobra.Command{
+	Use:   "hotspots [file pattern]",
+	Short: "Print the files that change most often and are most complex",
+	Long: `Prints the hotspots of the default repository (either the current directory or supplied by --repo): the files at HEAD
+ranked by how often they changed (commits) multiplied by their complexity (the total indentation of their lines).
+Vendored, generated, test and binary files are left out.
+Specify a file pattern as an argument to only rank files matching it, using '%' as a wildcard (e.g. 'cmd/%').
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var pathPattern string
+		if len(args) > 0 {
+			pathPattern = args[0]
+		}
+
+		var ui *hotspots.TermUI
+		var err error
+		if ui, err = hotspots.NewTermUI(pathPattern, hotspotsSince); err != nil {
+			handleExitError(err)
+		}
+		defer func() {
+			if err := ui.Close(); err != nil {
+				handleExitError(err)
+			}
+		}()
+
+		printSummary(ui, hotspotsOutputJSON)
+	},
+}
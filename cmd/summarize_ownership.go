@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/mergestat/mergestat-lite/cmd/summarize/ownership"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ownershipOutputJSON  bool
+	ownershipExcludeBots bool
+	ownershipActiveSince string
+)
+
+func init() {
+	summarizeOwnershipCmd.Flags().BoolVar(&ownershipOutputJSON, "json", false, "output as JSON (same as --format json)")
+	summarizeOwnershipCmd.Flags().BoolVar(&ownershipExcludeBots, "exclude-bots", false, "exclude lines authored by bots (see --identities)")
+	summarizeOwnershipCmd.Flags().StringVar(&ownershipActiveSince, "active-since", "-1 year", "authors without commits since this date are considered inactive. Can be of format YYYY-MM-DD, or a SQLite \"date modifier,\" relative to 'now'")
+}
+
+var summarizeOwnershipCmd = &cobra.Command{
+	Use:   "ownership [file pattern]",
+	Short: "Print a summary of how code ownership is distributed across directories",
+	Long: `Prints, for each directory of the default repo (--repo or current directory), how its blameable lines are shared among authors.
+The bus factor of a directory is the minimum number of authors that own at least half of its lines.
+A directory is abandoned if none of those authors have committed since --active-since.
+Specify a file path pattern as the first argument to only include matching files, using '%' as a wildcard (e.g. 'pkg/%').
+`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var pathPattern string
+		if len(args) > 0 {
+			pathPattern = args[0]
+		}
+
+		var ui *ownership.TermUI
+		var err error
+		if ui, err = ownership.NewTermUI(pathPattern, ownershipActiveSince, ownershipExcludeBots, identitiesFile != ""); err != nil {
+			handleExitError(err)
+		}
+		defer func() {
+			if err := ui.Close(); err != nil {
+				handleExitError(err)
+			}
+		}()
+
+		printSummary(ui, ownershipOutputJSON)
+	},
+}
@@ -0,0 +1,134 @@
+package extensions
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"go.riyazali.net/sqlite"
+)
+
+// connContext holds the context of the statements run on a connection (see options.Options.StatementContext),
+// which is the base context (see options.WithBaseContext), or while one is bound with BindContext, a context
+// derived from the bound one that is also cancelled with the base context.
+type connContext struct {
+	base context.Context
+
+	mu     sync.Mutex
+	token  string          // of the bound context, "" if none is bound
+	bound  context.Context // derived from the bound context
+	cancel context.CancelFunc
+}
+
+// statement returns the context of the statement being run on the connection
+func (c *connContext) statement() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.bound != nil {
+		return c.bound
+	}
+	return c.base
+}
+
+// bind makes a context derived from ctx the context of the statements of the connection,
+// until it is unbound with the same token
+func (c *connContext) bind(token string, ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unbindLocked()
+
+	// the derived context is cancelled with the base one too, such as when the process is interrupted
+	var base = c.base
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-base.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	c.token, c.bound, c.cancel = token, ctx, cancel
+}
+
+// unbind gets the connection back to the base context, if the context bound with token still is
+func (c *connContext) unbind(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.unbindLocked()
+	}
+}
+
+func (c *connContext) unbindLocked() {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.token, c.bound, c.cancel = "", nil, nil
+}
+
+// bindings are the contexts being bound by BindContext, by the token it passes to the connection
+var bindings = struct {
+	sync.Mutex
+	ctxs map[string]context.Context
+}{ctxs: make(map[string]context.Context)}
+
+// bindContextFn is the function BindContext binds (and unbinds) contexts with, given the token of the binding.
+// It is internal to BindContext: the tokens are random, so that no other statement can bind or unbind a context.
+type bindContextFn struct{ conn *connContext }
+
+func (*bindContextFn) Deterministic() bool { return false }
+func (*bindContextFn) Args() int           { return 2 }
+func (fn *bindContextFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
+	var token = values[0].Text()
+	if values[1].Int() == 0 {
+		fn.conn.unbind(token)
+		c.ResultNull()
+		return
+	}
+
+	bindings.Lock()
+	ctx, ok := bindings.ctxs[token]
+	bindings.Unlock()
+	if !ok {
+		c.ResultError(fmt.Errorf("unknown context"))
+		return
+	}
+	fn.conn.bind(token, ctx)
+	c.ResultNull()
+}
+
+// BindContext makes ctx the context of what the extension does for the statements run on conn, such as cloning
+// repositories, walking history and calling APIs, so that cancelling ctx (or its deadline) aborts them,
+// rather than only when the base context (see options.WithBaseContext) is cancelled. Each statement takes
+// the context when it starts, so ctx is meant to be bound for a single statement (or a few run one after
+// the other), and unbind called once it is done, which must be before conn is closed.
+func BindContext(ctx context.Context, conn *sql.Conn) (unbind func(), err error) {
+	var b = make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to bind the context of the connection: %v", err)
+	}
+	var token = hex.EncodeToString(b)
+
+	bindings.Lock()
+	bindings.ctxs[token] = ctx
+	bindings.Unlock()
+
+	// once bound, the connection holds on to the context itself
+	defer func() {
+		bindings.Lock()
+		delete(bindings.ctxs, token)
+		bindings.Unlock()
+	}()
+
+	if _, err = conn.ExecContext(ctx, "SELECT mergestat_bind_context(?, 1)", token); err != nil {
+		return nil, fmt.Errorf("failed to bind the context of the connection: %v", err)
+	}
+
+	return func() {
+		// the bound context may be done, and would prevent the statement from running
+		_, _ = conn.ExecContext(context.Background(), "SELECT mergestat_bind_context(?, 0)", token)
+	}, nil
+}
@@ -4,12 +4,16 @@
 package extensions
 
 import (
+	"context"
+
 	"github.com/mergestat/mergestat-lite/extensions/internal/enry"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git"
 	"github.com/mergestat/mergestat-lite/extensions/internal/github"
 	"github.com/mergestat/mergestat-lite/extensions/internal/golang"
 	"github.com/mergestat/mergestat-lite/extensions/internal/helpers"
+	"github.com/mergestat/mergestat-lite/extensions/internal/identities"
 	"github.com/mergestat/mergestat-lite/extensions/internal/npm"
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/internal/sourcegraph"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"go.riyazali.net/sqlite"
@@ -21,8 +25,24 @@ func RegisterFn(fns ...options.OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlit
 		fn(opt)
 	}
 
+	// without a base context, nothing the extensions do can be cancelled
+	if opt.BaseContext == nil {
+		opt.BaseContext = context.Background()
+	}
+
 	// return an extension function that register modules with sqlite when this package is loaded
 	return func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
+		// the function is called for each connection, whose statements get a context of their own (see BindContext)
+		var connOpt = *opt
+		var conn = &connContext{base: opt.BaseContext}
+		connOpt.StatementContext = conn.statement
+		opt := &connOpt
+
+		// internal to BindContext, so it is not described in mergestat_functions (see schema.CreateFunction)
+		if err := ext.CreateFunction("mergestat_bind_context", &bindContextFn{conn: conn}); err != nil {
+			return sqlite.SQLITE_ERROR, err
+		}
+
 		if !opt.ExcludeGit {
 			// register the git tables
 			if sqliteErr, err := git.Register(ext, opt); err != nil {
@@ -43,6 +63,10 @@ func RegisterFn(fns ...options.OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlit
 			if sqliteErr, err := golang.Register(ext, opt); err != nil {
 				return sqliteErr, err
 			}
+
+			if sqliteErr, err := identities.Register(ext, opt); err != nil {
+				return sqliteErr, err
+			}
 		}
 
 		// conditionally register the GitHub functionality
@@ -64,6 +88,11 @@ func RegisterFn(fns ...options.OptionFn) func(ext *sqlite.ExtensionApi) (_ sqlit
 			}
 		}
 
+		// register the tables describing what was registered above
+		if sqliteErr, err := schema.Register(ext, opt); err != nil {
+			return sqliteErr, err
+		}
+
 		return sqlite.SQLITE_OK, nil
 	}
 }
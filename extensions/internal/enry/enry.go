@@ -1,6 +1,7 @@
 package enry
 
 import (
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"go.riyazali.net/sqlite"
@@ -21,7 +22,7 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 	}
 
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "enry", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
 		}
 	}
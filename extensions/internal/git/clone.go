@@ -1,7 +1,6 @@
 package git
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/go-git/go-git/v5"
@@ -37,7 +36,7 @@ func (fn *CloneFn) Apply(c *sqlite.Context, values ...sqlite.Value) {
 	}
 
 	var repo *git.Repository
-	if repo, err = fn.Options.Locator.Open(context.Background(), path); err != nil {
+	if repo, err = fn.Options.Locator.Open(fn.Options.StatementContext(), path); err != nil {
 		c.ResultError(errors.Wrapf(err, "failed to open %q", path))
 		return
 	}
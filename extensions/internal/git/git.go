@@ -3,6 +3,7 @@ package git
 import (
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/native"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -12,9 +13,11 @@ import (
 // Register registers git related functionality as a SQLite extension
 func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
 	moduleOpts := &utils.ModuleOptions{
-		Locator: opt.Locator,
-		Context: opt.Context,
-		Logger:  opt.Logger,
+		Locator:          opt.Locator,
+		RefLister:        opt.RefLister,
+		Context:          opt.Context,
+		Logger:           opt.Logger,
+		StatementContext: opt.StatementContext,
 	}
 
 	// by default use a NOOP logger so we don't need nil checks within the modules
@@ -25,15 +28,20 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register virtual table modules
 	var modules = map[string]sqlite.Module{
-		"commits": NewLogModule(moduleOpts),
-		"refs":    NewRefModule(moduleOpts),
-		"stats":   native.NewStatsModule(moduleOpts),
-		"files":   native.NewFilesModule(moduleOpts),
-		"blame":   native.NewBlameModule(moduleOpts),
+		"commits":       NewLogModule(moduleOpts),
+		"refs":          NewRefModule(moduleOpts),
+		"stats":         native.NewStatsModule(moduleOpts),
+		"files":         native.NewFilesModule(moduleOpts),
+		"blame":         native.NewBlameModule(moduleOpts),
+		"file_history":  native.NewFileHistoryModule(moduleOpts),
+		"hotspots":      native.NewHotspotsModule(moduleOpts),
+		"line_survival": native.NewLineSurvivalModule(moduleOpts),
+		"remote_refs":   NewRemoteRefsModule(moduleOpts),
+		"tags":          NewTagsModule(moduleOpts),
 	}
 
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = schema.CreateModule(ext, opt, "git", name, mod); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
 		}
 	}
@@ -44,7 +52,7 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 	}
 
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "git", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
 		}
 	}
@@ -17,6 +17,7 @@ func init() {
 	// register sqlite extension when this package is loaded
 	sqlite.Register(extensions.RegisterFn(
 		options.WithExtraFunctions(), options.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(nil))),
+		options.WithRefLister(locator.RemoteRefLister(nil)),
 	))
 }
 
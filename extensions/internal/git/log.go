@@ -35,6 +35,8 @@ func (mod *logModule) Connect(_ *sqlite.Conn, _ []string, declare func(string) e
 			committer_email TEXT,
 			committer_when 	DATETIME,
 			parents 		INT,
+			author_tz_offset 	TEXT,
+			committer_tz_offset TEXT,
 
 			repository 	HIDDEN,
 			ref 		HIDDEN,
@@ -80,7 +82,7 @@ func (tab *gitLogTable) Open() (sqlite.VirtualCursor, error) {
 //	and op code is an integer constant for the operation.
 //
 //	A potential issue with such framing is the small count of columns we can map,
-//	which comes to about 2^4 = 16 .. we have already got 11 columns in current implementation.
+//	which comes to about 2^4 = 16 .. we have already got 13 columns in current implementation.
 //	And so, this contract must be revisited if we exceed the count of columns.
 func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexInfoOutput, error) {
 	var argv = 0
@@ -99,7 +101,7 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 		}
 
 		// if repository is provided, it must be usable
-		if idx == 9 && !constraint.Usable {
+		if idx == 11 && !constraint.Usable {
 			return nil, sqlite.SQLITE_CONSTRAINT
 		}
 
@@ -120,7 +122,7 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 			}
 
 		// user has specified which repository and / or reference to use
-		case (idx == 9 || idx == 10) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
+		case (idx == 11 || idx == 12) && constraint.Op == sqlite.INDEX_CONSTRAINT_EQ:
 			{
 				set(1, idx)
 				out.ConstraintUsage[i] = &sqlite.ConstraintUsage{ArgvIndex: argv, Omit: true}
@@ -157,6 +159,7 @@ func (tab *gitLogTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexIn
 
 type gitLogCursor struct {
 	*utils.ModuleOptions
+	ctx context.Context // of the statement being run, set by Filter
 
 	repo *git.Repository
 	rev  *plumbing.Revision
@@ -172,6 +175,7 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 	defer func() {
 		logger.Debug().Msg("running git log filter")
 	}()
+	cur.ctx = cur.StatementContext()
 
 	// values extracted from constraints
 	var hash, path, refName string
@@ -182,9 +186,9 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 		switch b := bitmap[i]; b {
 		case 0b00010000:
 			hash = val.Text()
-		case 0b00011001:
+		case 0b00011011:
 			path = val.Text()
-		case 0b00011010:
+		case 0b00011100:
 			refName = val.Text()
 		case 0b0100111:
 			end = val.Text()
@@ -202,7 +206,7 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 			}
 		}
 
-		if repo, err = cur.Locator.Open(context.Background(), path); err != nil {
+		if repo, err = cur.Locator.Open(cur.ctx, path); err != nil {
 			return errors.Wrapf(err, "failed to open %q", path)
 		}
 		cur.repo = repo
@@ -229,7 +233,7 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 
 	logger = logger.With().Str("revision", opts.From.String()).Logger()
 
-	if skipMailmap, _ := cur.Context.GetBool("skipMailmap"); !skipMailmap {
+	{ // load the mailmap from the tree of the starting commit
 		var c *object.Commit
 		if c, err = repo.CommitObject(opts.From); err != nil {
 			return errors.Wrapf(err, "could not lookup commit")
@@ -239,32 +243,11 @@ func (cur *gitLogCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 			return errors.Wrapf(err, "could not lookup tree")
 		}
 
-		var f *object.File
-		if f, err = t.File(".mailmap"); err != nil {
-			if err != object.ErrFileNotFound {
-				return errors.Wrapf(err, "could not lookup mailmap file")
-			} else {
-				goto skip_mailmap
-			}
-		}
-
-		var m string
-		if m, err = f.Contents(); err != nil {
-			if err != nil {
-				return errors.Wrapf(err, "could not retrieve contents of mailmap file")
-			}
-		}
-
-		var mm mailmap.MailMap
-		if mm, err = mailmap.Parse(m); err != nil {
-			return errors.Wrapf(err, "could not parse mailmap file")
+		if cur.mm, err = utils.LoadMailmap(cur.Context, repo, t); err != nil {
+			return err
 		}
-		logger.Info().Msg("found and parsed .mailmap file")
-		cur.mm = mm
 	}
 
-skip_mailmap:
-
 	if hash != "" {
 		// we only need to get a single commit
 		cur.commits = object.NewCommitIter(repo.Storer, storer.NewEncodedObjectLookupIter(
@@ -321,12 +304,21 @@ func (cur *gitLogCursor) Column(c *sqlite.VirtualTableContext, col int) error {
 		c.ResultText(commit.Committer.When.Format(time.RFC3339))
 	case 8:
 		c.ResultInt(commit.NumParents())
+	case 9:
+		c.ResultText(commit.Author.When.Format("-07:00"))
+	case 10:
+		c.ResultText(commit.Committer.When.Format("-07:00"))
 	}
 
 	return nil
 }
 
 func (cur *gitLogCursor) Next() (err error) {
+	// stop walking the history if the context of the statement has been cancelled
+	if err = cur.ctx.Err(); err != nil {
+		return err
+	}
+
 	if cur.commit, err = cur.commits.Next(); err != nil {
 		// check for ErrObjectNotFound to ensure we don't crash
 		// if the user provided hash did not point to a commit
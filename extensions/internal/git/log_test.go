@@ -1,10 +1,17 @@
 package git_test
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
 )
 
 func TestSelectAllCommits(t *testing.T) {
@@ -22,7 +29,8 @@ func TestSelectAllCommits(t *testing.T) {
 		var authorName, authorEmail, authorWhen string
 		var committerName, committerEmail, committerWhen string
 		var parents int
-		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents)
+		var authorTzOffset, committerTzOffset string
+		err = rows.Scan(&hash, &message, &authorName, &authorEmail, &authorWhen, &committerName, &committerEmail, &committerWhen, &parents, &authorTzOffset, &committerTzOffset)
 		if err != nil {
 			t.Fatalf("failed to scan resultset: %v", err)
 		}
@@ -34,6 +42,28 @@ func TestSelectAllCommits(t *testing.T) {
 	}
 }
 
+func TestCommitTimezoneOffsets(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	// converting to the commit's own offset must yield the wall clock time recorded in the commit
+	var total, mismatched int
+	err := db.QueryRow(`SELECT count(*),
+		count(CASE WHEN to_timezone(author_when, author_tz_offset) != replace(substr(author_when, 1, 19), 'T', ' ') THEN 1 END)
+		FROM commits(?) WHERE author_tz_offset NOT IN ('+00:00', '-00:00')`, repo).Scan(&total, &mismatched)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if total == 0 {
+		t.Fatalf("expected commits with a non-UTC offset")
+	}
+
+	if mismatched != 0 {
+		t.Fatalf("expected local times to match for all %d commits, %d did not", total, mismatched)
+	}
+}
+
 func TestSelectCommitByHash(t *testing.T) {
 	db := Connect(t, Memory)
 	repo, ref := "https://github.com/mergestat/mergestat-lite", "HEAD"
@@ -110,3 +140,57 @@ func TestDefaultCases(t *testing.T) {
 		}
 	})
 }
+
+func TestCommitsCancelled(t *testing.T) {
+	repo := fixture.NewRepo(t)
+	for i := 0; i < 10; i++ {
+		repo.Commit(fmt.Sprintf("commit %d", i), map[string]string{"file.txt": strconv.Itoa(i)})
+	}
+
+	db := Connect(t, Memory)
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	unbind, err := extensions.BindContext(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without its token, no other statement can bind (or unbind) a context
+	if _, err = conn.ExecContext(context.Background(), "SELECT mergestat_bind_context('token', 1)"); err == nil {
+		t.Fatal("expected binding an unknown context to fail")
+	}
+
+	// the statement itself is not cancelled, only what the extension does for it
+	rows, err := conn.QueryContext(context.Background(), "SELECT hash FROM commits(?)", repo.Dir)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	var n int
+	for rows.Next() {
+		if n++; n == 2 {
+			cancel()
+		}
+	}
+	if err = rows.Err(); err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Fatalf("expected the scan to be cancelled, got: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected the scan to stop after 2 commits, got %d", n)
+	}
+	rows.Close()
+	unbind()
+
+	// once unbound, the connection is back to the base context
+	if err = conn.QueryRowContext(context.Background(), "SELECT count(*) FROM commits(?)", repo.Dir).Scan(&n); err != nil {
+		t.Fatalf("failed to execute query: %v", err)
+	}
+	if n != 10 {
+		t.Fatalf("expected 10 commits, got %d", n)
+	}
+}
@@ -1,15 +1,16 @@
 package native
 
 import (
-	"context"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/plumbing"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
 	"github.com/pkg/errors"
 	"go.riyazali.net/sqlite"
 )
@@ -17,6 +18,9 @@ import (
 var blameCols = []vtab.Column{
 	{Name: "line_no", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 	{Name: "commit_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_email", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "author_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
 
 	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
 	{Name: "rev", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
@@ -30,11 +34,11 @@ func NewBlameModule(options *utils.ModuleOptions) sqlite.Module {
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
 				switch constraint.ColIndex {
-				case 2:
+				case 5:
 					repoPath = constraint.Value.Text()
-				case 3:
+				case 6:
 					rev = constraint.Value.Text()
-				case 4:
+				case 7:
 					filePath = constraint.Value.Text()
 				}
 			}
@@ -82,21 +86,17 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 		}
 	}
 
-	r, err := options.Locator.Open(context.Background(), repoPath)
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
 	if err != nil {
 		return nil, err
 	}
 
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("blame table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, free, err := openRepository("blame", r)
 	if err != nil {
 		return nil, err
 	}
-	defer repo.Free()
+	defer free()
 
 	var commitID *libgit2.Oid
 	// if no rev is supplied, use HEAD
@@ -121,6 +121,23 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 	}
 	logger = logger.With().Str("revision", commitID.String()).Logger()
 
+	var mm mailmap.MailMap
+	{ // load the mailmap from the tree of the blamed commit
+		c, err := r.CommitObject(plumbing.NewHash(commitID.String()))
+		if err != nil {
+			return nil, err
+		}
+
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, err
+		}
+
+		if mm, err = utils.LoadMailmap(options.Context, r, tree); err != nil {
+			return nil, err
+		}
+	}
+
 	opts, err := libgit2.DefaultBlameOptions()
 	if err != nil {
 		return nil, err
@@ -142,6 +159,9 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 	iter.lines = make([]*blamedLine, 0)
 	fileLine := 1
 	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		hunk, err := blame.HunkByLine(fileLine)
 		if err != nil {
 			if errors.Is(err, libgit2.ErrInvalid) {
@@ -152,6 +172,7 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 		iter.lines = append(iter.lines, &blamedLine{
 			hunk:   &hunk,
 			lineNo: fileLine,
+			author: mm.Lookup(mailmap.NameAndEmail{Name: hunk.OrigSignature.Name, Email: hunk.OrigSignature.Email}),
 		})
 		fileLine++
 	}
@@ -162,6 +183,7 @@ func newBlameIter(options *utils.ModuleOptions, repoPath, rev, filePath string)
 type blamedLine struct {
 	lineNo int
 	hunk   *libgit2.BlameHunk
+	author mailmap.NameAndEmail // with the mailmap applied
 }
 
 type blameIter struct {
@@ -179,6 +201,12 @@ func (i *blameIter) Column(ctx vtab.Context, c int) error {
 		ctx.ResultInt(currentLine.lineNo)
 	case 1:
 		ctx.ResultText(currentLine.hunk.OrigCommitId.String())
+	case 2:
+		ctx.ResultText(currentLine.author.Name)
+	case 3:
+		ctx.ResultText(currentLine.author.Email)
+	case 4:
+		ctx.ResultText(currentLine.hunk.OrigSignature.When.Format(time.RFC3339))
 	}
 	return nil
 }
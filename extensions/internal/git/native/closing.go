@@ -0,0 +1,79 @@
+package native
+
+import (
+	"io"
+
+	"github.com/augmentable-dev/vtab"
+	"go.riyazali.net/sqlite"
+)
+
+// newClosingTableFunc returns a table-valued function like vtab.NewTableFunc, whose iterators (if they implement io.Closer)
+// are closed along with their cursor, or when it is filtered again. vtab only lets go of an iterator once it is done,
+// so the resources it holds would otherwise be left behind by statements that stop early (such as with a LIMIT).
+func newClosingTableFunc(name string, columns []vtab.Column, newIterator func([]*vtab.Constraint, []*sqlite.OrderBy) (vtab.Iterator, error)) sqlite.Module {
+	var m = &closingModule{}
+	m.Module = vtab.NewTableFunc(name, columns, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		iter, err := newIterator(constraints, order)
+		if closer, ok := iter.(io.Closer); ok && err == nil {
+			m.created = closer
+		}
+		return iter, err
+	})
+	return m
+}
+
+// closingModule hands the iterators its table function creates over to the cursors filtering them.
+// Modules are registered on each connection, whose cursors SQLite filters one at a time, so the iterator
+// created last is the one of the cursor being filtered.
+type closingModule struct {
+	sqlite.Module
+	created io.Closer // by the table function, for the cursor filtering to take
+}
+
+func (m *closingModule) Connect(conn *sqlite.Conn, args []string, declare func(string) error) (sqlite.VirtualTable, error) {
+	table, err := m.Module.Connect(conn, args, declare)
+	if err != nil {
+		return nil, err
+	}
+	return &closingTable{VirtualTable: table, module: m}, nil
+}
+
+type closingTable struct {
+	sqlite.VirtualTable
+	module *closingModule
+}
+
+func (t *closingTable) Open() (sqlite.VirtualCursor, error) {
+	cursor, err := t.VirtualTable.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &closingCursor{VirtualCursor: cursor, module: t.module}, nil
+}
+
+type closingCursor struct {
+	sqlite.VirtualCursor
+	module *closingModule
+	iter   io.Closer // of the last filter, if it is one
+}
+
+func (c *closingCursor) Filter(indexNumber int, indexString string, values ...sqlite.Value) error {
+	c.closeIter()
+
+	c.module.created = nil
+	err := c.VirtualCursor.Filter(indexNumber, indexString, values...)
+	c.iter, c.module.created = c.module.created, nil
+	return err
+}
+
+func (c *closingCursor) Close() error {
+	c.closeIter()
+	return c.VirtualCursor.Close()
+}
+
+func (c *closingCursor) closeIter() {
+	if c.iter != nil {
+		_ = c.iter.Close()
+		c.iter = nil
+	}
+}
@@ -0,0 +1,112 @@
+package native
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+// closedIter is an iterator of a single row, which records whether it was closed
+type closedIter struct {
+	rows   int
+	closed bool
+}
+
+func (i *closedIter) Next() (vtab.Row, error) {
+	if i.rows++; i.rows > 1 {
+		return nil, io.EOF
+	}
+	return i, nil
+}
+
+func (i *closedIter) Column(ctx vtab.Context, c int) error { ctx.ResultInt(i.rows); return nil }
+func (i *closedIter) Close() error                         { i.closed = true; return nil }
+
+func TestClosingTableFunc(t *testing.T) {
+	var iters []*closedIter
+	mod := newClosingTableFunc("closing", []vtab.Column{{Name: "n", Type: "INT"}},
+		func([]*vtab.Constraint, []*sqlite.OrderBy) (vtab.Iterator, error) {
+			iters = append(iters, &closedIter{})
+			return iters[len(iters)-1], nil
+		})
+
+	table, err := mod.Connect(nil, []string{"closing", "main", "closing"}, func(string) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := table.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first row is read, and the iterator left before its end
+	const index = `{"Constraints":[],"Orders":[]}`
+	if err = cursor.Filter(0, index); err != nil || cursor.Eof() {
+		t.Fatalf("expected a row, got: %v", err)
+	}
+	if iters[0].closed {
+		t.Fatal("expected the iterator to be open while the cursor uses it")
+	}
+
+	// filtering again closes the previous iterator, and closing the cursor the last one
+	if err = cursor.Filter(0, index); err != nil {
+		t.Fatal(err)
+	}
+	if !iters[0].closed || iters[1].closed {
+		t.Fatalf("expected only the first iterator to be closed, got %v and %v", iters[0].closed, iters[1].closed)
+	}
+	if err = cursor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !iters[1].closed {
+		t.Fatal("expected the iterator to be closed with the cursor")
+	}
+}
+
+func TestFilesIterClose(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, r, "README.md", "hello\n")
+
+	var logger = zerolog.Nop()
+	var opts = &utils.ModuleOptions{
+		Locator:          options.RepoLocatorFn(func(context.Context, string) (*git.Repository, error) { return r, nil }),
+		Logger:           &logger,
+		StatementContext: context.Background,
+	}
+
+	iter, err := newFilesIter(opts, "in-memory", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = iter.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the in-memory repository is copied to disk, and the copy removed once the iterator is closed
+	dir := iter.repo.Path()
+	if _, err = os.Stat(dir); err != nil {
+		t.Fatalf("expected the copy of the repository at %s: %v", dir, err)
+	}
+	if err = iter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, got: %v", dir, err)
+	}
+	if err = iter.Close(); err != nil {
+		t.Fatalf("expected closing again to do nothing, got: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package native
+
+import (
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/rs/zerolog"
+)
+
+// diffLines diffs oldTree to newTree (a nil or empty tree diffs against nothing), finds the renames and copies
+// enabled in findOpts, and calls fn with each changed file. The line callback fn returns (if not nil) is then called
+// with each line of the hunks of that file, as the diff's line callback would be. Errors freeing the diff are logged.
+func diffLines(logger *zerolog.Logger, repo *libgit2.Repository, oldTree, newTree *libgit2.Tree, diffOpts *libgit2.DiffOptions, findOpts *libgit2.DiffFindOptions, fn func(delta libgit2.DiffDelta) (libgit2.DiffForEachLineCallback, error)) error {
+	diff, err := repo.DiffTreeToTree(oldTree, newTree, diffOpts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := diff.Free(); err != nil {
+			logger.Warn().Err(err).Msg("failed to free diff")
+		}
+	}()
+
+	if err := diff.FindSimilar(findOpts); err != nil {
+		return err
+	}
+
+	return diff.ForEach(func(delta libgit2.DiffDelta, progress float64) (libgit2.DiffForEachHunkCallback, error) {
+		onLine, err := fn(delta)
+		if err != nil || onLine == nil {
+			return nil, err
+		}
+		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
+			return onLine, nil
+		}, nil
+	}, libgit2.DiffDetailLines)
+}
+
+// diffCommit diffs commit against its first parent (or nothing, for a root commit), as diffLines does
+func diffCommit(logger *zerolog.Logger, repo *libgit2.Repository, commit *libgit2.Commit, diffOpts *libgit2.DiffOptions, findOpts *libgit2.DiffFindOptions, fn func(delta libgit2.DiffDelta) (libgit2.DiffForEachLineCallback, error)) error {
+	tree, err := commit.Tree()
+	if err != nil {
+		return err
+	}
+	defer tree.Free()
+
+	var parentTree *libgit2.Tree
+	if parent := commit.Parent(0); parent != nil {
+		defer parent.Free()
+		if parentTree, err = parent.Tree(); err != nil {
+			return err
+		}
+		defer parentTree.Free()
+	}
+
+	return diffLines(logger, repo, parentTree, tree, diffOpts, findOpts, fn)
+}
+
+// countLines returns a line callback adding up the lines added and deleted
+func countLines(additions, deletions *int) libgit2.DiffForEachLineCallback {
+	return func(line libgit2.DiffLine) error {
+		switch line.Origin {
+		case libgit2.DiffLineAddition:
+			*additions++
+		case libgit2.DiffLineDeletion:
+			*deletions++
+		}
+		return nil
+	}
+}
@@ -0,0 +1,321 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var fileHistoryCols = []vtab.Column{
+	{Name: "hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "committer_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "files", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "lines", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "bytes", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "path", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewFileHistoryModule returns the implementation of a table-valued-function that walks the history of a file or directory,
+// and returns a snapshot of its size (number of files, lines and bytes) at every commit that touched it, newest first.
+// As with git log, a merge is only included if it differs from each of its parents at path.
+// A commit that deletes path is included, with all of its metrics at zero.
+func NewFileHistoryModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("file_history", fileHistoryCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref, filePath string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					ref = constraint.Value.Text()
+				case 7:
+					filePath = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newFileHistoryIter(options, repoPath, ref, filePath)
+	})
+}
+
+func newFileHistoryIter(options *utils.ModuleOptions, repoPath, ref, filePath string) (*fileHistoryIter, error) {
+	filePath = strings.Trim(filePath, "/")
+
+	logger := options.Logger.With().
+		Str("module", "git-file-history").
+		Str("repo-path", repoPath).
+		Str("file-path", filePath).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating file history iterator")
+	}()
+
+	if repoPath == "" {
+		if wd, err := os.Getwd(); err != nil {
+			return nil, err
+		} else {
+			repoPath = wd
+		}
+	}
+
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, free, err := openRepository("file_history", r)
+	if err != nil {
+		return nil, err
+	}
+	defer free()
+
+	var commitID *libgit2.Oid
+	// if no ref is supplied, use HEAD
+	if ref == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		commitID = head.Target()
+	} else {
+		obj, err := repo.RevparseSingle(ref)
+		if err != nil {
+			return nil, err
+		}
+		defer obj.Free()
+
+		commit, err := obj.Peel(libgit2.ObjectCommit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ref, could not resolve to a commit")
+		}
+		defer commit.Free()
+
+		commitID = commit.Id()
+	}
+	logger = logger.With().Str("revision", commitID.String()).Logger()
+
+	walk, err := repo.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+
+	walk.Sorting(libgit2.SortTopological | libgit2.SortTime)
+	if err := walk.Push(commitID); err != nil {
+		return nil, err
+	}
+
+	var measure = &snapshotter{repo: repo, blobs: make(map[libgit2.Oid]blobMetrics)}
+	var iter = &fileHistoryIter{index: -1}
+	var walkErr error
+	err = walk.Iterate(func(commit *libgit2.Commit) bool {
+		defer commit.Free()
+		if walkErr = ctx.Err(); walkErr != nil {
+			return false
+		}
+
+		var entry *libgit2.Oid
+		if entry, walkErr = entryAtPath(commit, filePath); walkErr != nil {
+			return false
+		}
+
+		var touched bool
+		if touched, walkErr = touchesPath(commit, filePath, entry); walkErr != nil || !touched {
+			return walkErr == nil
+		}
+
+		var snapshot = &fileSnapshot{hash: commit.Id().String(), when: commit.Committer().When}
+		if entry != nil {
+			if walkErr = measure.snapshot(ctx, entry, snapshot); walkErr != nil {
+				return false
+			}
+		}
+		iter.snapshots = append(iter.snapshots, snapshot)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if walkErr != nil {
+		return nil, walkErr
+	}
+
+	return iter, nil
+}
+
+// entryAtPath returns the id of the tree or blob at path in the tree of commit, or nil if there is nothing at path.
+// An empty path is the root tree.
+func entryAtPath(commit *libgit2.Commit, path string) (*libgit2.Oid, error) {
+	if path == "" {
+		return commit.TreeId(), nil
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		if libgit2.IsErrorCode(err, libgit2.ErrorCodeNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return entry.Id, nil
+}
+
+// touchesPath reports whether commit changed what is at path, entry being the id of what is at path in commit.
+// A commit with no parents touches path if it adds it, and any other commit if path differs from each of its parents.
+func touchesPath(commit *libgit2.Commit, path string, entry *libgit2.Oid) (bool, error) {
+	if commit.ParentCount() == 0 {
+		return entry != nil, nil
+	}
+
+	for n := uint(0); n < commit.ParentCount(); n++ {
+		parent := commit.Parent(n)
+		if parent == nil {
+			return false, fmt.Errorf("could not lookup parent %d of %s", n, commit.Id())
+		}
+		parentEntry, err := entryAtPath(parent, path)
+		parent.Free()
+		if err != nil {
+			return false, err
+		}
+
+		if (entry == nil && parentEntry == nil) || (entry != nil && parentEntry != nil && entry.Equal(parentEntry)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type blobMetrics struct {
+	lines int
+	bytes int64
+}
+
+// snapshotter measures trees and blobs, remembering the metrics of every blob so that
+// only blobs that changed between two snapshots are ever loaded
+type snapshotter struct {
+	repo  *libgit2.Repository
+	blobs map[libgit2.Oid]blobMetrics
+}
+
+// snapshot fills in the metrics of the tree or blob with the given id
+func (s *snapshotter) snapshot(ctx context.Context, id *libgit2.Oid, snapshot *fileSnapshot) error {
+	obj, err := s.repo.Lookup(id)
+	if err != nil {
+		return err
+	}
+	defer obj.Free()
+
+	switch obj.Type() {
+	case libgit2.ObjectBlob:
+		metrics, err := s.blob(id)
+		if err != nil {
+			return err
+		}
+		snapshot.files, snapshot.lines, snapshot.bytes = 1, metrics.lines, metrics.bytes
+	case libgit2.ObjectTree:
+		tree, err := obj.AsTree()
+		if err != nil {
+			return err
+		}
+		return walkBlobs(ctx, tree, func(p string, treeEntry *libgit2.TreeEntry) error {
+			metrics, err := s.blob(treeEntry.Id)
+			if err != nil {
+				return err
+			}
+			snapshot.files++
+			snapshot.lines += metrics.lines
+			snapshot.bytes += metrics.bytes
+			return nil
+		})
+	}
+	return nil
+}
+
+func (s *snapshotter) blob(id *libgit2.Oid) (blobMetrics, error) {
+	if metrics, ok := s.blobs[*id]; ok {
+		return metrics, nil
+	}
+
+	blob, err := s.repo.LookupBlob(id)
+	if err != nil {
+		return blobMetrics{}, err
+	}
+	defer blob.Free()
+
+	var metrics = blobMetrics{bytes: blob.Size()}
+	if contents := blob.Contents(); !blob.IsBinary() && len(contents) > 0 {
+		metrics.lines = bytes.Count(contents, []byte{'\n'})
+		if contents[len(contents)-1] != '\n' {
+			metrics.lines++ // the last line has no trailing newline
+		}
+	}
+
+	s.blobs[*id] = metrics
+	return metrics, nil
+}
+
+type fileSnapshot struct {
+	hash  string
+	when  time.Time
+	files int
+	lines int
+	bytes int64
+}
+
+type fileHistoryIter struct {
+	snapshots []*fileSnapshot
+	index     int
+}
+
+func (i *fileHistoryIter) Column(ctx vtab.Context, c int) error {
+	snapshot := i.snapshots[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(snapshot.hash)
+	case 1:
+		ctx.ResultText(snapshot.when.Format(time.RFC3339))
+	case 2:
+		ctx.ResultInt(snapshot.files)
+	case 3:
+		ctx.ResultInt(snapshot.lines)
+	case 4:
+		ctx.ResultInt64(snapshot.bytes)
+	}
+	return nil
+}
+
+func (i *fileHistoryIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.snapshots) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,163 @@
+package native_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// fileHistoryFixture creates a repository where a.txt, b.txt and c.txt are added, then a.txt is removed.
+// A side branch then changes b.txt and c.txt, master changes c.txt, and the merge of both takes b.txt
+// from the side branch as is, and both changes of c.txt. It returns its path and the hashes of the commits by name.
+func fileHistoryFixture(t *testing.T) (string, map[string]string) {
+	repo := fixture.NewRepo(t)
+	var commits = make(map[string]string)
+	commits["first"] = repo.Commit("first", map[string]string{"a.txt": "one\ntwo\n", "b.txt": "b\n", "c.txt": "c\n"})
+	commits["removal"] = repo.Commit("removal", nil, "a.txt")
+
+	wt, err := repo.Repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = wt.Checkout(&git.CheckoutOptions{Hash: plumbing.NewHash(commits["removal"])}); err != nil {
+		t.Fatal(err)
+	}
+	commits["side"] = repo.Commit("side", map[string]string{"b.txt": "b\nside\n", "c.txt": "c\nside\n"})
+
+	if err = wt.Checkout(&git.CheckoutOptions{Branch: plumbing.Master}); err != nil {
+		t.Fatal(err)
+	}
+	commits["master"] = repo.Commit("master", map[string]string{"c.txt": "c\nmaster\n"})
+	commits["merge"] = repo.Merge("merge", commits["side"], map[string]string{"b.txt": "b\nside\n", "c.txt": "c\nmaster\nside\n"})
+
+	return repo.Dir, commits
+}
+
+type fileSnapshot struct {
+	hash                string
+	files, lines, bytes int
+}
+
+func queryFileHistory(t *testing.T, repo, path string) []fileSnapshot {
+	t.Helper()
+
+	db := Connect(t, Memory)
+	rows, err := db.Query("SELECT hash, files, lines, bytes FROM file_history(?, '', ?)", repo, path)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var snapshots []fileSnapshot
+	for rows.Next() {
+		var snapshot fileSnapshot
+		if err = rows.Scan(&snapshot.hash, &snapshot.files, &snapshot.lines, &snapshot.bytes); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+	return snapshots
+}
+
+func TestFileHistoryOfRemovedFile(t *testing.T) {
+	repo, commits := fileHistoryFixture(t)
+
+	// the removal is a snapshot of nothing
+	var expected = []fileSnapshot{{commits["removal"], 0, 0, 0}, {commits["first"], 1, 2, 8}}
+	if snapshots := queryFileHistory(t, repo, "a.txt"); !reflect.DeepEqual(snapshots, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, snapshots)
+	}
+}
+
+func TestFileHistoryOfMerges(t *testing.T) {
+	repo, commits := fileHistoryFixture(t)
+
+	// b.txt is merged as it is on the side branch, so the merge doesn't change it
+	var expected = []fileSnapshot{{commits["side"], 1, 2, 7}, {commits["first"], 1, 1, 2}}
+	if snapshots := queryFileHistory(t, repo, "b.txt"); !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected %+v, got %+v", expected, snapshots)
+	}
+
+	// c.txt is merged unlike either branch, so the merge is a snapshot of it
+	expected = []fileSnapshot{
+		{commits["merge"], 1, 3, 14}, {commits["master"], 1, 2, 9}, {commits["side"], 1, 2, 7}, {commits["first"], 1, 1, 2},
+	}
+	if snapshots := queryFileHistory(t, repo, "c.txt"); !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("expected %+v, got %+v", expected, snapshots)
+	}
+}
+
+func TestFileHistoryMatchesContents(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	for _, path := range []string{"Makefile", "go.mod"} {
+		// the most recent snapshot is that of the file at hash
+		var files, lines, bytes int
+		err := db.QueryRow("SELECT files, lines, bytes FROM file_history(?, ?, ?) LIMIT 1", repo, hash, path).
+			Scan(&files, &lines, &bytes)
+		if err != nil {
+			t.Fatalf("failed to execute query: %v", err.Error())
+		}
+		t.Logf("file history: path=%s files=%d lines=%d bytes=%d", path, files, lines, bytes)
+
+		contents, err := os.ReadFile(fmt.Sprintf("./testdata/%s/%s.testdata", hash, path))
+		if err != nil {
+			t.Fatalf("failed to load fixture file: %v", err)
+		}
+
+		if files != 1 {
+			t.Fatalf("expected a single file, got %d", files)
+		}
+
+		if expected := strings.Count(string(contents), "\n"); lines != expected {
+			t.Fatalf("expected %d lines in %s, got %d", expected, path, lines)
+		}
+
+		if bytes != len(contents) {
+			t.Fatalf("expected %d bytes in %s, got %d", len(contents), path, bytes)
+		}
+	}
+}
+
+func TestFileHistoryOfDirectory(t *testing.T) {
+	db := Connect(t, Memory)
+	repo, hash := "https://github.com/mergestat/mergestat-lite", "2359c9a9ba0ba8aa694601ff12538c4e74b82cd5"
+
+	// an empty path is the root of the repository, so every commit changing anything is a snapshot
+	var snapshots, commits int
+	err := db.QueryRow("SELECT count(*), count(DISTINCT hash) FROM file_history(?, ?, '')", repo, hash).Scan(&snapshots, &commits)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if snapshots == 0 || snapshots != commits {
+		t.Fatalf("expected a single snapshot for each commit, got %d snapshots of %d commits", snapshots, commits)
+	}
+
+	var files, lines int
+	err = db.QueryRow("SELECT files, lines FROM file_history(?, ?, '/') LIMIT 1", repo, hash).Scan(&files, &lines)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	var expectedFiles int
+	err = db.QueryRow("SELECT count(*) FROM files(?, ?)", repo, hash).Scan(&expectedFiles)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if files != expectedFiles || lines == 0 {
+		t.Fatalf("expected %d files with some lines, got %d files with %d lines", expectedFiles, files, lines)
+	}
+}
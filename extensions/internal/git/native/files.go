@@ -8,7 +8,6 @@ import (
 	"path"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
 	"go.riyazali.net/sqlite"
@@ -25,7 +24,7 @@ var filesCols = []vtab.Column{
 
 // NewFilesModule returns the implementation of a table-valued-function for accessing the content of files in git
 func NewFilesModule(options *utils.ModuleOptions) sqlite.Module {
-	return vtab.NewTableFunc("files", filesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+	return newClosingTableFunc("files", filesCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
 		var repoPath, rev string
 		for _, constraint := range constraints {
 			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
@@ -50,7 +49,7 @@ func NewFilesModule(options *utils.ModuleOptions) sqlite.Module {
 	})
 }
 
-func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIter, error) {
+func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (_ *filesIter, err error) {
 	logger := options.Logger.With().
 		Str("module", "git-files").
 		Str("repo-path", repoPath).
@@ -73,21 +72,23 @@ func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIte
 		}
 	}
 
-	r, err := options.Locator.Open(context.Background(), repoPath)
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
 	if err != nil {
 		return nil, err
 	}
 
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("file table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, free, err := openRepository("files", r)
 	if err != nil {
 		return nil, err
 	}
-	iter.repo = repo
+	iter.repo, iter.free = repo, free
+	defer func() {
+		// otherwise, the repository is freed once the cursor is closed (see Close)
+		if err != nil {
+			free()
+		}
+	}()
 
 	var commitID *libgit2.Oid
 	var commit *libgit2.Commit
@@ -125,13 +126,10 @@ func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIte
 	}
 
 	iter.files = make([]*file, 0, tree.EntryCount())
-	err = tree.Walk(func(p string, treeEntry *libgit2.TreeEntry) error {
-		if treeEntry.Type != libgit2.ObjectBlob {
-			return nil
-		}
+	err = walkBlobs(ctx, tree, func(p string, treeEntry *libgit2.TreeEntry) error {
 		iter.files = append(iter.files, &file{
 			id:         treeEntry.Id,
-			path:       path.Join(p, treeEntry.Name),
+			path:       p,
 			executable: treeEntry.Filemode == libgit2.FilemodeBlobExecutable,
 		})
 		return nil
@@ -143,6 +141,20 @@ func newFilesIter(options *utils.ModuleOptions, repoPath, rev string) (*filesIte
 	return iter, nil
 }
 
+// walkBlobs calls fn with the full path and entry of every blob in tree, without loading any of them,
+// and stops the walk early if ctx is cancelled
+func walkBlobs(ctx context.Context, tree *libgit2.Tree, fn func(p string, treeEntry *libgit2.TreeEntry) error) error {
+	return tree.Walk(func(p string, treeEntry *libgit2.TreeEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if treeEntry.Type != libgit2.ObjectBlob {
+			return nil
+		}
+		return fn(path.Join(p, treeEntry.Name), treeEntry)
+	})
+}
+
 type file struct {
 	id         *libgit2.Oid
 	path       string
@@ -155,6 +167,7 @@ type filesIter struct {
 	files    []*file
 	index    int
 	repo     *libgit2.Repository
+	free     func() // releases repo
 }
 
 func (i *filesIter) Column(ctx vtab.Context, c int) error {
@@ -182,10 +195,16 @@ func (i *filesIter) Column(ctx vtab.Context, c int) error {
 func (i *filesIter) Next() (vtab.Row, error) {
 	i.index++
 	if i.index >= len(i.files) {
-		if i.repo != nil {
-			i.repo.Free()
-		}
 		return nil, io.EOF
 	}
 	return i, nil
 }
+
+// Close frees the repository, see newClosingTableFunc
+func (i *filesIter) Close() error {
+	if i.free != nil {
+		i.free()
+		i.free = nil
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+package native
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-enry/go-enry/v2"
+	"github.com/go-git/go-git/v5/plumbing"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+var hotspotsCols = []vtab.Column{
+	{Name: "path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "commits", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "authors", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "additions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "deletions", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "lines", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "complexity", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "score", Type: "INT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "since", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewHotspotsModule returns the implementation of a table-valued-function that ranks the files at HEAD
+// by how often they change and how complex they are. For every file changed since the given date
+// (in the whole history if none is given), it returns:
+//
+//   - the number of (non-merge) commits that changed it, the number of distinct (mailmapped) authors of those commits,
+//     and the lines they added and deleted, as in the stats table
+//   - the number of non-blank lines of the file, and its complexity: the total indentation of those lines,
+//     counting a tab or four spaces as one level, which is a language-agnostic proxy for nesting
+//   - a score, the number of commits multiplied by the complexity
+//
+// Changes made to a file before it was renamed count towards its path at HEAD.
+// Binary files, and files enry classifies as vendored, generated or tests are left out.
+func NewHotspotsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("hotspots", hotspotsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, since string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 8:
+					repoPath = constraint.Value.Text()
+				case 9:
+					since = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newHotspotsIter(options, repoPath, since)
+	})
+}
+
+func newHotspotsIter(options *utils.ModuleOptions, repoPath, since string) (*hotspotsIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-hotspots").
+		Str("repo-path", repoPath).
+		Str("since", since).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating hotspots iterator")
+	}()
+
+	var sinceTime time.Time
+	if since != "" {
+		var err error
+		if sinceTime, err = parseSince(since); err != nil {
+			return nil, err
+		}
+	}
+
+	if repoPath == "" {
+		if wd, err := os.Getwd(); err != nil {
+			return nil, err
+		} else {
+			repoPath = wd
+		}
+	}
+
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, free, err := openRepository("hotspots", r)
+	if err != nil {
+		return nil, err
+	}
+	defer free()
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	defer head.Free()
+
+	headCommit, err := repo.LookupCommit(head.Target())
+	if err != nil {
+		return nil, err
+	}
+	defer headCommit.Free()
+
+	var mm mailmap.MailMap
+	{ // load the mailmap from the tree at HEAD
+		c, err := r.CommitObject(plumbing.NewHash(headCommit.Id().String()))
+		if err != nil {
+			return nil, err
+		}
+
+		tree, err := c.Tree()
+		if err != nil {
+			return nil, err
+		}
+
+		if mm, err = utils.LoadMailmap(options.Context, r, tree); err != nil {
+			return nil, err
+		}
+	}
+
+	var churn = make(map[string]*hotspot)
+	if err := collectChurn(ctx, &logger, repo, head.Target(), sinceTime, mm, churn); err != nil {
+		return nil, err
+	}
+
+	tree, err := headCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+
+	var iter = &hotspotsIter{index: -1}
+	err = walkBlobs(ctx, tree, func(p string, treeEntry *libgit2.TreeEntry) error {
+		spot, ok := churn[p]
+		if !ok || enry.IsVendor(p) || enry.IsTest(p) {
+			return nil
+		}
+
+		blob, err := repo.LookupBlob(treeEntry.Id)
+		if err != nil {
+			return err
+		}
+		defer blob.Free()
+
+		contents := blob.Contents()
+		if blob.IsBinary() || enry.IsGenerated(p, contents) {
+			return nil
+		}
+
+		spot.path = p
+		spot.lines, spot.complexity = measureComplexity(contents)
+		iter.hotspots = append(iter.hotspots, spot)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return iter, nil
+}
+
+// collectChurn walks the history from the given commit, and adds up the changes of every non-merge commit
+// since the given time (a zero time includes every commit), by the path of the files they changed.
+// Renames are followed: as the history is walked from the newest commits, the changes made to a file
+// before it was renamed are added up under the path it was renamed to (and eventually its path at HEAD).
+func collectChurn(ctx context.Context, logger *zerolog.Logger, repo *libgit2.Repository, from *libgit2.Oid, since time.Time, mm mailmap.MailMap, churn map[string]*hotspot) error {
+	walk, err := repo.Walk()
+	if err != nil {
+		return err
+	}
+	defer walk.Free()
+
+	walk.Sorting(libgit2.SortTopological | libgit2.SortTime)
+	if err := walk.Push(from); err != nil {
+		return err
+	}
+
+	diffOpts, err := libgit2.DefaultDiffOptions()
+	if err != nil {
+		return err
+	}
+	diffOpts.ContextLines = 0
+
+	findOpts, err := libgit2.DefaultDiffFindOptions()
+	if err != nil {
+		return err
+	}
+	findOpts.Flags = libgit2.DiffFindRenames
+
+	var renamed = make(map[string]string) // the path a file was later renamed to, by its path before the rename
+	var walkErr error
+	err = walk.Iterate(func(commit *libgit2.Commit) bool {
+		defer commit.Free()
+		if walkErr = ctx.Err(); walkErr != nil {
+			return false
+		}
+
+		if commit.ParentCount() > 1 || commit.Committer().When.Before(since) {
+			return true
+		}
+
+		author := commit.Author()
+		email := strings.ToLower(mm.Lookup(mailmap.NameAndEmail{Name: author.Name, Email: author.Email}).Email)
+		walkErr = diffCommit(logger, repo, commit, &diffOpts, &findOpts, func(delta libgit2.DiffDelta) (libgit2.DiffForEachLineCallback, error) {
+			path := delta.NewFile.Path
+			if p, ok := renamed[path]; ok {
+				path = p
+			}
+			if delta.Status == libgit2.DeltaRenamed {
+				renamed[delta.OldFile.Path] = path
+			}
+
+			spot, ok := churn[path]
+			if !ok {
+				spot = &hotspot{authors: make(map[string]struct{})}
+				churn[path] = spot
+			}
+			spot.commits++
+			spot.authors[email] = struct{}{}
+			return countLines(&spot.additions, &spot.deletions), nil
+		})
+		return walkErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	return walkErr
+}
+
+// measureComplexity returns the number of non-blank lines in contents, and their total indentation
+func measureComplexity(contents []byte) (lines, complexity int) {
+	for _, line := range bytes.Split(contents, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		lines++
+
+		var tabs, spaces int
+	indent:
+		for _, c := range line {
+			switch c {
+			case '\t':
+				tabs++
+			case ' ':
+				spaces++
+			default:
+				break indent
+			}
+		}
+		complexity += tabs + spaces/4
+	}
+	return lines, complexity
+}
+
+// parseSince parses the since argument, either a date or a date and time, as formatted by SQLite's date functions or RFC 3339
+func parseSince(since string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, since); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q, expected a date such as 2006-01-02", since)
+}
+
+type hotspot struct {
+	path       string
+	commits    int
+	authors    map[string]struct{}
+	additions  int
+	deletions  int
+	lines      int
+	complexity int
+}
+
+type hotspotsIter struct {
+	hotspots []*hotspot
+	index    int
+}
+
+func (i *hotspotsIter) Column(ctx vtab.Context, c int) error {
+	spot := i.hotspots[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(spot.path)
+	case 1:
+		ctx.ResultInt(spot.commits)
+	case 2:
+		ctx.ResultInt(len(spot.authors))
+	case 3:
+		ctx.ResultInt(spot.additions)
+	case 4:
+		ctx.ResultInt(spot.deletions)
+	case 5:
+		ctx.ResultInt(spot.lines)
+	case 6:
+		ctx.ResultInt(spot.complexity)
+	case 7:
+		ctx.ResultInt(spot.commits * spot.complexity)
+	}
+	return nil
+}
+
+func (i *hotspotsIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.hotspots) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
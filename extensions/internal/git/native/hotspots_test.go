@@ -0,0 +1,93 @@
+package native_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// hotspotsFixture creates a repository where Jane Doe writes main.go, util.go (renamed from old.go), a test
+// and a vendored file, and Bob then nests the body of main, and returns its path
+func hotspotsFixture(t *testing.T) string {
+	repo := fixture.NewRepo(t)
+	repo.Commit("first", map[string]string{
+		"main.go":        "func main() {\n\tfmt.Println()\n}\n",
+		"old.go":         "func util() {\n\treturn\n}\n",
+		"main_test.go":   "func TestMain() {\n\tmain()\n}\n",
+		"vendor/vend.go": "func vend() {\n\treturn\n}\n",
+	})
+
+	repo.Author.Name, repo.Author.Email = "Bob", "bob@example.com"
+	repo.Commit("second", map[string]string{"main.go": "func main() {\n\tif true {\n\t\tfmt.Println()\n\t}\n}\n"})
+
+	repo.Author.Name, repo.Author.Email = "Jane Doe", "jane@example.com"
+	repo.Commit("third", map[string]string{"util.go": "func util() {\n\treturn\n}\n"}, "old.go")
+
+	return repo.Dir
+}
+
+type hotspot struct {
+	commits, authors, additions, deletions, lines, complexity, score int
+}
+
+func queryHotspots(t *testing.T, query string, args ...interface{}) map[string]hotspot {
+	t.Helper()
+
+	db := Connect(t, Memory)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var spots = make(map[string]hotspot)
+	for rows.Next() {
+		var path string
+		var spot hotspot
+		if err = rows.Scan(&path, &spot.commits, &spot.authors, &spot.additions, &spot.deletions, &spot.lines, &spot.complexity, &spot.score); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		spots[path] = spot
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+	return spots
+}
+
+func TestHotspots(t *testing.T) {
+	repo := hotspotsFixture(t)
+
+	spots := queryHotspots(t, "SELECT path, commits, authors, additions, deletions, lines, complexity, score FROM hotspots(?)", repo)
+	var expected = map[string]hotspot{
+		// Bob replaces the (one level deep) print with three lines, two and three levels deep
+		"main.go": {commits: 2, authors: 2, additions: 6, deletions: 1, lines: 5, complexity: 4, score: 8},
+		// the commit adding old.go counts towards util.go, and the rename itself changes no lines
+		"util.go": {commits: 2, authors: 1, additions: 3, deletions: 0, lines: 3, complexity: 1, score: 2},
+	}
+
+	if len(spots) != len(expected) {
+		t.Fatalf("expected %d hotspots, got: %v", len(expected), spots)
+	}
+	for path, want := range expected {
+		if spot, ok := spots[path]; !ok || spot != want {
+			t.Errorf("expected %s to be %+v, got %+v", path, want, spot)
+		}
+	}
+}
+
+func TestHotspotsSince(t *testing.T) {
+	repo := hotspotsFixture(t)
+
+	// the fixture commits are an hour apart from 2020-01-01 01:00:00, so only the rename is left
+	spots := queryHotspots(t, "SELECT path, commits, authors, additions, deletions, lines, complexity, score FROM hotspots(?, '2020-01-01 02:30:00')", repo)
+	if expected := (hotspot{commits: 1, authors: 1, lines: 3, complexity: 1, score: 1}); len(spots) != 1 || spots["util.go"] != expected {
+		t.Fatalf("expected only util.go to be %+v, got: %v", expected, spots)
+	}
+
+	// nothing changes in the future
+	if spots := queryHotspots(t, "SELECT path, commits, authors, additions, deletions, lines, complexity, score FROM hotspots(?, date('now', '+1 year'))", repo); len(spots) != 0 {
+		t.Fatalf("expected no hotspots, got: %v", spots)
+	}
+}
@@ -0,0 +1,327 @@
+package native
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/rs/zerolog"
+	"go.riyazali.net/sqlite"
+)
+
+var lineSurvivalCols = []vtab.Column{
+	{Name: "file_path", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "added_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "added_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "removed_hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "removed_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "ref", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+	{Name: "path_pattern", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewLineSurvivalModule returns the implementation of a table-valued-function that replays the history of a ref
+// and returns a row for every line ever added, along with the commit that added it and the commit that removed it
+// (NULL if the line survives at the tip of the ref).
+//
+// History is replayed along the first-parent chain, so lines that come in with a merge are attributed to the merge commit.
+// Lines are followed across renames, and file_path is the last path a line was seen at.
+// The optional path_pattern is a git pathspec (such as "src/" or "*.go") limiting the files that are replayed.
+//
+// Blame only accounts for the lines that survive at a revision, so it can't tell when the others were removed:
+// instead, every line ever added is kept in memory until the whole history is replayed, which makes the memory used
+// grow with the size of the history. Use path_pattern to replay a part of the tree in larger repositories.
+func NewLineSurvivalModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("line_survival", lineSurvivalCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath, ref, pathPattern string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					repoPath = constraint.Value.Text()
+				case 6:
+					ref = constraint.Value.Text()
+				case 7:
+					pathPattern = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newLineSurvivalIter(options, repoPath, ref, pathPattern)
+	})
+}
+
+func newLineSurvivalIter(options *utils.ModuleOptions, repoPath, ref, pathPattern string) (*lineSurvivalIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-line-survival").
+		Str("repo-path", repoPath).
+		Str("path-pattern", pathPattern).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating line survival iterator")
+	}()
+
+	if repoPath == "" {
+		if wd, err := os.Getwd(); err != nil {
+			return nil, err
+		} else {
+			repoPath = wd
+		}
+	}
+
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, free, err := openRepository("line_survival", r)
+	if err != nil {
+		return nil, err
+	}
+	defer free()
+
+	var commitID *libgit2.Oid
+	// if no ref is supplied, use HEAD
+	if ref == "" {
+		head, err := repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		commitID = head.Target()
+	} else {
+		obj, err := repo.RevparseSingle(ref)
+		if err != nil {
+			return nil, err
+		}
+		defer obj.Free()
+
+		commit, err := obj.Peel(libgit2.ObjectCommit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ref, could not resolve to a commit")
+		}
+		defer commit.Free()
+
+		commitID = commit.Id()
+	}
+	logger = logger.With().Str("revision", commitID.String()).Logger()
+
+	replay, err := newLineReplay(&logger, repo, pathPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	walk, err := repo.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer walk.Free()
+
+	walk.Sorting(libgit2.SortTopological | libgit2.SortReverse)
+	walk.SimplifyFirstParent()
+	if err := walk.Push(commitID); err != nil {
+		return nil, err
+	}
+
+	var replayErr error
+	err = walk.Iterate(func(commit *libgit2.Commit) bool {
+		defer commit.Free()
+		if replayErr = ctx.Err(); replayErr != nil {
+			return false
+		}
+		replayErr = replay.apply(commit)
+		return replayErr == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if replayErr != nil {
+		return nil, replayErr
+	}
+
+	return &lineSurvivalIter{lines: replay.lines, index: -1}, nil
+}
+
+// revision identifies the commit a line was added or removed in
+type revision struct {
+	hash string
+	when time.Time // the committer time
+}
+
+// lifetime tracks a single line, from the commit that added it to the one that removed it
+type lifetime struct {
+	path    string
+	added   *revision
+	removed *revision
+}
+
+// lineReplay applies the diff of each commit (against its first parent) to the lines of every tracked file
+type lineReplay struct {
+	logger   *zerolog.Logger
+	repo     *libgit2.Repository
+	diffOpts libgit2.DiffOptions
+	findOpts libgit2.DiffFindOptions
+
+	files map[string][]*lifetime // the lines of each file, in order, as of the last applied commit
+	lines []*lifetime            // every line ever added, in the order they were added
+}
+
+// fileChange collects the lines added and deleted in a single file by a commit
+type fileChange struct {
+	delta     libgit2.DiffDelta
+	additions map[int]*lifetime // keyed by the (1-based) line number in the new file
+	deletions map[int]bool      // keyed by the (1-based) line number in the old file
+}
+
+func newLineReplay(logger *zerolog.Logger, repo *libgit2.Repository, pathPattern string) (*lineReplay, error) {
+	diffOpts, err := libgit2.DefaultDiffOptions()
+	if err != nil {
+		return nil, err
+	}
+	diffOpts.ContextLines = 0
+	diffOpts.InterhunkLines = 0
+	if pathPattern != "" {
+		diffOpts.Pathspec = []string{pathPattern}
+	}
+
+	findOpts, err := libgit2.DefaultDiffFindOptions()
+	if err != nil {
+		return nil, err
+	}
+	findOpts.Flags = libgit2.DiffFindRenames
+
+	return &lineReplay{logger: logger, repo: repo, diffOpts: diffOpts, findOpts: findOpts, files: make(map[string][]*lifetime)}, nil
+}
+
+// apply replays the changes made by commit
+func (r *lineReplay) apply(commit *libgit2.Commit) error {
+	rev := &revision{hash: commit.Id().String(), when: commit.Committer().When}
+
+	var changes []*fileChange
+	err := diffCommit(r.logger, r.repo, commit, &r.diffOpts, &r.findOpts, func(delta libgit2.DiffDelta) (libgit2.DiffForEachLineCallback, error) {
+		change := &fileChange{delta: delta, additions: make(map[int]*lifetime), deletions: make(map[int]bool)}
+		changes = append(changes, change)
+		return func(line libgit2.DiffLine) error {
+			switch line.Origin {
+			case libgit2.DiffLineAddition:
+				change.additions[line.NewLineno] = &lifetime{path: delta.NewFile.Path, added: rev}
+			case libgit2.DiffLineDeletion:
+				change.deletions[line.OldLineno] = true
+			}
+			return nil
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// files are rewritten into a copy, so that every change reads the lines as they were before this commit
+	// (a file may be renamed to the path of another that is renamed or deleted in the same commit)
+	var next = make(map[string][]*lifetime, len(r.files))
+	for path, lines := range r.files {
+		next[path] = lines
+	}
+	for _, change := range changes {
+		if change.delta.Status != libgit2.DeltaAdded {
+			delete(next, change.delta.OldFile.Path)
+		}
+	}
+
+	for _, change := range changes {
+		var old []*lifetime
+		if change.delta.Status != libgit2.DeltaAdded {
+			old = r.files[change.delta.OldFile.Path]
+		}
+
+		// binary files have no lines to track, so whatever was there before is considered removed
+		if change.delta.Status == libgit2.DeltaDeleted || change.delta.Flags&libgit2.DiffFlagBinary != 0 {
+			for _, line := range old {
+				line.removed = rev
+			}
+			continue
+		}
+
+		var survivors = make([]*lifetime, 0, len(old))
+		for n, line := range old {
+			if change.deletions[n+1] {
+				line.removed = rev
+			} else {
+				survivors = append(survivors, line)
+			}
+		}
+
+		if change.delta.Status == libgit2.DeltaRenamed {
+			for _, line := range survivors {
+				line.path = change.delta.NewFile.Path
+			}
+		}
+
+		// lines of the new file are either added by this commit, or survivors in their original order
+		var lines = make([]*lifetime, 0, len(survivors)+len(change.additions))
+		for n := 1; len(change.additions) > 0 || len(survivors) > 0; n++ {
+			if line, ok := change.additions[n]; ok {
+				lines = append(lines, line)
+				r.lines = append(r.lines, line)
+				delete(change.additions, n)
+			} else if len(survivors) > 0 {
+				lines = append(lines, survivors[0])
+				survivors = survivors[1:]
+			}
+		}
+		next[change.delta.NewFile.Path] = lines
+	}
+
+	r.files = next
+	return nil
+}
+
+type lineSurvivalIter struct {
+	lines []*lifetime
+	index int
+}
+
+func (i *lineSurvivalIter) Column(ctx vtab.Context, c int) error {
+	line := i.lines[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(line.path)
+	case 1:
+		ctx.ResultText(line.added.hash)
+	case 2:
+		ctx.ResultText(line.added.when.Format(time.RFC3339))
+	case 3:
+		if line.removed == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(line.removed.hash)
+		}
+	case 4:
+		if line.removed == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(line.removed.when.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+func (i *lineSurvivalIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.lines) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
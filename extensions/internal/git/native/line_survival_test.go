@@ -0,0 +1,104 @@
+package native_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// survivalFixture creates a repository where a.txt is written, edited and renamed to b.txt,
+// and c.txt is added then removed, and returns its path along with the hashes of the four commits
+func survivalFixture(t *testing.T) (string, []string) {
+	repo := fixture.NewRepo(t)
+	var commits = []string{
+		repo.Commit("first", map[string]string{"a.txt": "one\ntwo\nthree\n"}),
+		repo.Commit("second", map[string]string{"a.txt": "one\nTWO\nthree\nfour\n"}),
+		repo.Commit("third", map[string]string{"b.txt": "one\nTWO\nthree\nfour\n", "c.txt": "x\n"}, "a.txt"),
+		repo.Commit("fourth", nil, "c.txt"),
+	}
+	return repo.Dir, commits
+}
+
+type lineLifetime struct {
+	path, added, removed string
+}
+
+func queryLineSurvival(t *testing.T, query string, args ...interface{}) []lineLifetime {
+	t.Helper()
+
+	db := Connect(t, Memory)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var lines []lineLifetime
+	for rows.Next() {
+		var line lineLifetime
+		var removed sql.NullString
+		if err = rows.Scan(&line.path, &line.added, &removed); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+		line.removed = removed.String
+		lines = append(lines, line)
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+	return lines
+}
+
+func TestLineSurvival(t *testing.T) {
+	repo, commits := survivalFixture(t)
+
+	lines := queryLineSurvival(t, "SELECT file_path, added_hash, removed_hash FROM line_survival(?)", repo)
+
+	// lines come in the order they were added, and the renamed lines are at their last path
+	var expected = []lineLifetime{
+		{"b.txt", commits[0], ""},         // one
+		{"a.txt", commits[0], commits[1]}, // two, replaced by TWO before the rename
+		{"b.txt", commits[0], ""},         // three
+		{"b.txt", commits[1], ""},         // TWO
+		{"b.txt", commits[1], ""},         // four
+		{"c.txt", commits[2], commits[3]}, // x
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got: %v", len(expected), lines)
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("expected line %d to be %+v, got %+v", i, expected[i], line)
+		}
+	}
+}
+
+func TestLineSurvivalRef(t *testing.T) {
+	repo, commits := survivalFixture(t)
+
+	// at the second commit, nothing is renamed yet and only two was removed
+	lines := queryLineSurvival(t, "SELECT file_path, added_hash, removed_hash FROM line_survival(?, ?) WHERE removed_hash IS NOT NULL", repo, commits[1])
+	if expected := (lineLifetime{"a.txt", commits[0], commits[1]}); len(lines) != 1 || lines[0] != expected {
+		t.Fatalf("expected only %+v to be removed, got: %v", expected, lines)
+	}
+
+	db := Connect(t, Memory)
+	var addedWhen, removedWhen string
+	if err := db.QueryRow("SELECT added_when, removed_when FROM line_survival(?, ?) WHERE removed_hash IS NOT NULL", repo, commits[1]).Scan(&addedWhen, &removedWhen); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	if addedWhen != "2020-01-01T01:00:00Z" || removedWhen != "2020-01-01T02:00:00Z" {
+		t.Fatalf("expected the line to live from 01:00 to 02:00 on 2020-01-01, got %s to %s", addedWhen, removedWhen)
+	}
+}
+
+func TestLineSurvivalPathPattern(t *testing.T) {
+	repo, commits := survivalFixture(t)
+
+	lines := queryLineSurvival(t, "SELECT file_path, added_hash, removed_hash FROM line_survival(?, 'HEAD', 'c.txt')", repo)
+	if expected := (lineLifetime{"c.txt", commits[2], commits[3]}); len(lines) != 1 || lines[0] != expected {
+		t.Fatalf("expected only %+v, got: %v", expected, lines)
+	}
+}
@@ -0,0 +1,65 @@
+package native_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// packedFixtures returns the paths of a bundle and of a bare mirror of a repository where README.md (2 lines)
+// is committed, then main.go (3 lines), along with the hash of the first commit. It skips the test without git.
+func packedFixtures(t *testing.T) (bundle, mirror, first string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	repo := fixture.NewRepo(t)
+	first = repo.Commit("first", map[string]string{"README.md": "hello\nworld\n"})
+	repo.Commit("second", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	dir := t.TempDir()
+	bundle, mirror = filepath.Join(dir, "repo.bundle"), filepath.Join(dir, "repo.git")
+	for _, args := range [][]string{{"bundle", "create", bundle, "--all"}, {"clone", "-q", "--mirror", repo.Dir, mirror}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo.Dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	return bundle, mirror, first
+}
+
+func TestNativeModulesOnPackedRepositories(t *testing.T) {
+	bundle, mirror, first := packedFixtures(t)
+	db := Connect(t, Memory)
+
+	for _, repo := range []string{bundle, mirror} {
+		var files int
+		if err := db.QueryRow("SELECT count(*) FROM files(?)", repo).Scan(&files); err != nil {
+			t.Fatalf("failed to query the files of %s: %v", repo, err)
+		}
+		if files != 2 {
+			t.Errorf("expected 2 files in %s, got %d", repo, files)
+		}
+
+		var path string
+		var additions, deletions int
+		if err := db.QueryRow("SELECT file_path, additions, deletions FROM stats(?)", repo).Scan(&path, &additions, &deletions); err != nil {
+			t.Fatalf("failed to query the stats of %s: %v", repo, err)
+		}
+		if path != "main.go" || additions != 3 || deletions != 0 {
+			t.Errorf("expected 3 lines added to main.go in %s, got %d added and %d deleted in %s", repo, additions, deletions, path)
+		}
+
+		var lines int
+		var hash string
+		if err := db.QueryRow("SELECT count(*), max(commit_hash) FROM blame(?, '', 'README.md')", repo).Scan(&lines, &hash); err != nil {
+			t.Fatalf("failed to query the blame of %s: %v", repo, err)
+		}
+		if lines != 2 || hash != first {
+			t.Errorf("expected 2 lines of README.md from %s in %s, got %d from %s", first, repo, lines, hash)
+		}
+	}
+}
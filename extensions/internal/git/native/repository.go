@@ -0,0 +1,41 @@
+package native
+
+import (
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	libgit2 "github.com/libgit2/git2go/v34"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	"github.com/pkg/errors"
+)
+
+// openRepository opens the libgit2 equivalent of the given go-git repository, which free releases.
+// The go-git storer's root is the git directory itself (the .git directory of a checkout,
+// or the repository directory of a bare repo / mirror / unpacked bundle), which libgit2 opens as-is.
+// Repositories backed by any other storer (such as an in-memory one) are first copied to disk,
+// and the copy is removed by free. locator.CachedLocator caches such repositories on disk instead,
+// so that they are only copied once rather than every time a table opens them.
+func openRepository(table string, r *git.Repository) (repo *libgit2.Repository, free func(), err error) {
+	if fsStorer, ok := r.Storer.(*filesystem.Storage); ok {
+		if repo, err = libgit2.OpenRepository(fsStorer.Filesystem().Root()); err != nil {
+			return nil, nil, err
+		}
+		return repo, repo.Free, nil
+	}
+
+	var spilled *git.Repository
+	if spilled, err = locator.Spill(r); err != nil {
+		return nil, nil, errors.Wrapf(err, "%s table failed to copy repository to disk", table)
+	}
+
+	var dir = spilled.Storer.(*filesystem.Storage).Filesystem().Root()
+	if repo, err = libgit2.OpenRepository(dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return repo, func() {
+		repo.Free()
+		_ = os.RemoveAll(dir)
+	}, nil
+}
@@ -0,0 +1,79 @@
+package native
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// commitFile writes a file into the in-memory worktree and commits it
+func commitFile(t *testing.T, r *git.Repository, name, contents string) plumbing.Hash {
+	t.Helper()
+	wt, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := wt.Filesystem.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.Write([]byte(contents)); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	if _, err = wt.Add(name); err != nil {
+		t.Fatal(err)
+	}
+
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	hash, err := wt.Commit("add "+name, &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestOpenInMemoryRepository(t *testing.T) {
+	r, err := git.Init(memory.NewStorage(), memfs.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []plumbing.Hash{
+		commitFile(t, r, "README.md", "hello\n"),
+		commitFile(t, r, "main.go", "package main\n"), // later commits must be picked up by the next copy
+	} {
+		repo, free, err := openRepository("test", r)
+		if err != nil {
+			t.Fatalf("failed to open in-memory repository: %v", err)
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatalf("failed to resolve HEAD: %v", err)
+		}
+
+		if head.Target().String() != expected.String() {
+			t.Fatalf("expected HEAD at %s, got %s", expected, head.Target())
+		}
+
+		if _, err = repo.LookupCommit(head.Target()); err != nil {
+			t.Fatalf("failed to lookup HEAD commit: %v", err)
+		}
+
+		// the copy on disk is removed with the repository
+		dir := repo.Path()
+		free()
+		if _, err = os.Stat(dir); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got: %v", dir, err)
+		}
+	}
+}
@@ -1,12 +1,10 @@
 package native
 
 import (
-	"context"
 	"fmt"
 	"io"
 
 	"github.com/augmentable-dev/vtab"
-	"github.com/go-git/go-git/v5/storage/filesystem"
 	libgit2 "github.com/libgit2/git2go/v34"
 	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
 	"go.riyazali.net/sqlite"
@@ -95,21 +93,17 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 		index:    -1,
 	}
 
-	r, err := options.Locator.Open(context.Background(), repoPath)
+	ctx := options.StatementContext()
+	r, err := options.Locator.Open(ctx, repoPath)
 	if err != nil {
 		return nil, err
 	}
 
-	fsStorer, ok := r.Storer.(*filesystem.Storage)
-	if !ok {
-		return nil, fmt.Errorf("stats table only supported on filesystem backed git repos")
-	}
-
-	repo, err := libgit2.OpenRepository(fsStorer.Filesystem().Root())
+	repo, free, err := openRepository("stats", r)
 	if err != nil {
 		return nil, err
 	}
-	defer repo.Free()
+	defer free()
 
 	var fromCommit *libgit2.Commit
 	// if no rev is supplied, use HEAD
@@ -181,44 +175,20 @@ func newStatsIter(options *utils.ModuleOptions, repoPath, rev, toRev string) (*s
 		return nil, err
 	}
 
-	diff, err := repo.DiffTreeToTree(toTree, tree, &diffOpts)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		err := diff.Free()
-		if err != nil {
-			// TODO what should we do here?
-			fmt.Println(err)
-		}
-	}()
-
 	diffFindOpts, err := libgit2.DefaultDiffFindOptions()
 	if err != nil {
 		return nil, err
 	}
 
-	err = diff.FindSimilar(&diffFindOpts)
-	if err != nil {
-		return nil, err
-	}
-
 	iter.stats = make([]*stat, 0)
-	err = diff.ForEach(func(delta libgit2.DiffDelta, progress float64) (libgit2.DiffForEachHunkCallback, error) {
+	err = diffLines(&logger, repo, toTree, tree, &diffOpts, &diffFindOpts, func(delta libgit2.DiffDelta) (libgit2.DiffForEachLineCallback, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		stat := &stat{filePath: delta.NewFile.Path, oldFileMode: gitFileModeObjectTypeFromUint16(delta.OldFile.Mode), newFileMode: gitFileModeObjectTypeFromUint16(delta.NewFile.Mode)}
 		iter.stats = append(iter.stats, stat)
-		return func(hunk libgit2.DiffHunk) (libgit2.DiffForEachLineCallback, error) {
-			return func(line libgit2.DiffLine) error {
-				switch line.Origin {
-				case libgit2.DiffLineAddition:
-					stat.additions++
-				case libgit2.DiffLineDeletion:
-					stat.deletions++
-				}
-				return nil
-			}, nil
-		}, nil
-	}, libgit2.DiffDetailLines)
+		return countLines(&stat.additions, &stat.deletions), nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -1,7 +1,6 @@
 package git
 
 import (
-	"context"
 	"regexp"
 
 	"github.com/go-git/go-git/v5"
@@ -112,7 +111,7 @@ func (cur *gitRefCursor) Filter(_ int, s string, values ...sqlite.Value) (err er
 			}
 		}
 
-		if repo, err = cur.Locator.Open(context.Background(), path); err != nil {
+		if repo, err = cur.Locator.Open(cur.StatementContext(), path); err != nil {
 			return errors.Wrapf(err, "failed to open %q", path)
 		}
 		cur.repo = repo
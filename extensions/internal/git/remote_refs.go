@@ -0,0 +1,127 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"go.riyazali.net/sqlite"
+)
+
+var remoteRefsCols = []vtab.Column{
+	{Name: "name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "type", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "full_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "target", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "url", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewRemoteRefsModule returns the implementation of a table-valued-function for listing
+// the refs of a remote repository (like git ls-remote), without cloning it
+func NewRemoteRefsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("remote_refs", remoteRefsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var url string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 5:
+					url = constraint.Value.Text()
+				}
+			}
+		}
+
+		if url == "" {
+			return nil, fmt.Errorf("remote_refs table requires a remote url")
+		}
+
+		if options.RefLister == nil {
+			return nil, fmt.Errorf("remote_refs table requires a ref lister to be configured")
+		}
+
+		return newRemoteRefsIter(options, url)
+	})
+}
+
+func newRemoteRefsIter(options *utils.ModuleOptions, url string) (*remoteRefsIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-remote-refs").
+		Str("url", url).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating remote refs iterator")
+	}()
+
+	refs, err := options.RefLister.ListRefs(options.StatementContext(), url)
+	if err != nil {
+		return nil, err
+	}
+
+	// the order of advertised refs is not guaranteed, so sort them by name
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Name() < refs[j].Name() })
+
+	// index the hashes so symbolic refs (such as HEAD) can be resolved
+	hashes := make(map[plumbing.ReferenceName]plumbing.Hash, len(refs))
+	for _, ref := range refs {
+		if ref.Type() == plumbing.HashReference {
+			hashes[ref.Name()] = ref.Hash()
+		}
+	}
+
+	return &remoteRefsIter{refs: refs, hashes: hashes, index: -1}, nil
+}
+
+type remoteRefsIter struct {
+	refs   []*plumbing.Reference
+	hashes map[plumbing.ReferenceName]plumbing.Hash
+	index  int
+}
+
+func (i *remoteRefsIter) Column(ctx vtab.Context, c int) error {
+	ref := i.refs[i.index]
+	switch c {
+	case 0:
+		ctx.ResultText(ref.Name().Short())
+	case 1:
+		if ref.Name().IsBranch() || isRemoteBranch(ref.Name()) {
+			ctx.ResultText("branch")
+		} else if ref.Name().IsTag() {
+			ctx.ResultText("tag")
+		} else if ref.Name().IsNote() {
+			ctx.ResultText("note")
+		} else {
+			ctx.ResultNull()
+		}
+	case 2:
+		ctx.ResultText(ref.Name().String())
+	case 3:
+		hash := ref.Hash()
+		if ref.Type() == plumbing.SymbolicReference {
+			hash = i.hashes[ref.Target()]
+		}
+		if hash.IsZero() {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(hash.String())
+		}
+	case 4:
+		if ref.Type() == plumbing.SymbolicReference {
+			ctx.ResultText(ref.Target().String())
+		} else {
+			ctx.ResultNull()
+		}
+	}
+	return nil
+}
+
+func (i *remoteRefsIter) Next() (vtab.Row, error) {
+	i.index++
+	if i.index >= len(i.refs) {
+		return nil, io.EOF
+	}
+	return i, nil
+}
@@ -0,0 +1,93 @@
+package git_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/mergestat/mergestat-lite/internal/fixture"
+)
+
+// remoteFixture returns the file:// url of a fixture repository with two commits on master,
+// a v1 tag on the first one and a feature branch, along with the hashes of the commits
+func remoteFixture(t *testing.T) (url, first, second string) {
+	repo := fixture.NewRepo(t)
+	first = repo.Commit("first", map[string]string{"README.md": "hello"})
+	if _, err := repo.Repo.CreateTag("v1", plumbing.NewHash(first), nil); err != nil {
+		t.Fatal(err)
+	}
+	second = repo.Commit("second", map[string]string{"README.md": "hello world"})
+
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), plumbing.NewHash(first))
+	if err := repo.Repo.Storer.SetReference(ref); err != nil {
+		t.Fatal(err)
+	}
+
+	return "file://" + filepath.ToSlash(repo.Dir), first, second
+}
+
+func TestSelectAllRemoteRefs(t *testing.T) {
+	db := Connect(t, Memory)
+	url, first, second := remoteFixture(t)
+
+	rows, err := db.Query("SELECT * FROM remote_refs(?)", url)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var expected = map[string][2]string{ // type and hash, by full name
+		"HEAD":               {"", second},
+		"refs/heads/feature": {"branch", first},
+		"refs/heads/master":  {"branch", second},
+		"refs/tags/v1":       {"tag", first},
+	}
+
+	var count int
+	var previous string
+	for rows.Next() {
+		var name, _type, fullName, hash, target sql.NullString
+		if err = rows.Scan(&name, &_type, &fullName, &hash, &target); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		want, ok := expected[fullName.String]
+		if !ok {
+			t.Fatalf("unexpected remote ref %q", fullName.String)
+		}
+		if _type.String != want[0] || hash.String != want[1] {
+			t.Fatalf("expected %s to be a %q ref to %s, got a %q ref to %s", fullName.String, want[0], want[1], _type.String, hash.String)
+		}
+		if fullName.String < previous {
+			t.Fatalf("expected refs to be sorted by name, got %s after %s", fullName.String, previous)
+		}
+		previous = fullName.String
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	if count != len(expected) {
+		t.Fatalf("expected %d remote refs, got %d", len(expected), count)
+	}
+}
+
+func TestRemoteRefsHead(t *testing.T) {
+	db := Connect(t, Memory)
+	url, _, second := remoteFixture(t)
+
+	var target, hash sql.NullString
+	if err := db.QueryRow("SELECT target, hash FROM remote_refs(?) WHERE full_name = 'HEAD'", url).Scan(&target, &hash); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if target.String != "refs/heads/master" {
+		t.Fatalf("expected HEAD to target refs/heads/master, got %q", target.String)
+	}
+	if hash.String != second {
+		t.Fatalf("expected HEAD to resolve to %q, got %q", second, hash.String)
+	}
+}
@@ -0,0 +1,172 @@
+package git
+
+import (
+	"time"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+var tagsCols = []vtab.Column{
+	{Name: "name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "full_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "hash", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "target", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "annotated", Type: "BOOLEAN", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "tagger_name", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "tagger_email", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "tagger_when", Type: "DATETIME", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+	{Name: "message", Type: "TEXT", NotNull: false, Hidden: false, Filters: nil, OrderBy: vtab.NONE},
+
+	{Name: "repository", Type: "TEXT", NotNull: true, Hidden: true, Filters: []*vtab.ColumnFilter{{Op: sqlite.INDEX_CONSTRAINT_EQ, OmitCheck: true}}, OrderBy: vtab.NONE},
+}
+
+// NewTagsModule returns the implementation of a table-valued-function for listing the tags of a repository,
+// including the (mailmapped) tagger of annotated tags
+func NewTagsModule(options *utils.ModuleOptions) sqlite.Module {
+	return vtab.NewTableFunc("tags", tagsCols, func(constraints []*vtab.Constraint, order []*sqlite.OrderBy) (vtab.Iterator, error) {
+		var repoPath string
+		for _, constraint := range constraints {
+			if constraint.Op == sqlite.INDEX_CONSTRAINT_EQ {
+				switch constraint.ColIndex {
+				case 9:
+					repoPath = constraint.Value.Text()
+				}
+			}
+		}
+
+		if repoPath == "" {
+			var err error
+			repoPath, err = utils.GetDefaultRepoFromCtx(options.Context)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return newTagsIter(options, repoPath)
+	})
+}
+
+func newTagsIter(options *utils.ModuleOptions, repoPath string) (*tagsIter, error) {
+	logger := options.Logger.With().
+		Str("module", "git-tags").
+		Str("repo-path", repoPath).
+		Logger()
+	defer func() {
+		logger.Debug().Msg("creating tags iterator")
+	}()
+
+	repo, err := options.Locator.Open(options.StatementContext(), repoPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %q", repoPath)
+	}
+
+	// taggers are mapped using the mailmap at HEAD, as git does (an empty repository has no tree to read it from)
+	var tree *object.Tree
+	if head, err := repo.Head(); err == nil {
+		var commit *object.Commit
+		if commit, err = repo.CommitObject(head.Hash()); err != nil {
+			return nil, errors.Wrapf(err, "could not lookup commit")
+		}
+		if tree, err = commit.Tree(); err != nil {
+			return nil, errors.Wrapf(err, "could not lookup tree")
+		}
+	}
+
+	mm, err := utils.LoadMailmap(options.Context, repo, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tagsIter{repo: repo, refs: refs, mm: mm}, nil
+}
+
+type tagsIter struct {
+	repo *git.Repository
+	refs storer.ReferenceIter
+	mm   mailmap.MailMap
+
+	ref    *plumbing.Reference
+	tag    *object.Tag // the annotated tag object, nil for lightweight tags
+	tagger mailmap.NameAndEmail
+}
+
+func (i *tagsIter) Column(ctx vtab.Context, c int) error {
+	switch c {
+	case 0:
+		ctx.ResultText(i.ref.Name().Short())
+	case 1:
+		ctx.ResultText(i.ref.Name().String())
+	case 2:
+		ctx.ResultText(i.ref.Hash().String())
+	case 3:
+		if i.tag == nil {
+			ctx.ResultText(i.ref.Hash().String())
+		} else {
+			ctx.ResultText(i.tag.Target.String())
+		}
+	case 4:
+		if i.tag != nil {
+			ctx.ResultInt(1)
+		} else {
+			ctx.ResultInt(0)
+		}
+	case 5:
+		if i.tag == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(i.tagger.Name)
+		}
+	case 6:
+		if i.tag == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(i.tagger.Email)
+		}
+	case 7:
+		if i.tag == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(i.tag.Tagger.When.Format(time.RFC3339))
+		}
+	case 8:
+		if i.tag == nil {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(i.tag.Message)
+		}
+	}
+	return nil
+}
+
+func (i *tagsIter) Next() (vtab.Row, error) {
+	ref, err := i.refs.Next()
+	if err != nil {
+		return nil, err // io.EOF once all tags are consumed
+	}
+	i.ref = ref
+
+	i.tag, err = i.repo.TagObject(ref.Hash())
+	switch err {
+	case nil:
+		i.tagger = i.mm.Lookup(mailmap.NameAndEmail{Name: i.tag.Tagger.Name, Email: i.tag.Tagger.Email})
+	case plumbing.ErrObjectNotFound: // a lightweight tag, pointing directly at a commit
+		i.tag = nil
+	default:
+		return nil, err
+	}
+
+	return i, nil
+}
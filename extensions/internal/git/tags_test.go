@@ -0,0 +1,48 @@
+package git_test
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSelectAllTags(t *testing.T) {
+	db := Connect(t, Memory)
+	repo := "https://github.com/mergestat/mergestat-lite"
+
+	rows, err := db.Query("SELECT name, full_name, hash, target, annotated, tagger_name, tagger_email, tagger_when FROM tags(?)", repo)
+	if err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		var name, fullName, hash, target string
+		var annotated bool
+		var taggerName, taggerEmail, taggerWhen sql.NullString
+		if err = rows.Scan(&name, &fullName, &hash, &target, &annotated, &taggerName, &taggerEmail, &taggerWhen); err != nil {
+			t.Fatalf("failed to scan resultset: %v", err)
+		}
+
+		if annotated != taggerName.Valid {
+			t.Fatalf("expected tagger to be set only for annotated tags, got annotated=%v tagger=%q", annotated, taggerName.String)
+		}
+
+		t.Logf("tag: name=%q fullName=%q hash=%q target=%q tagger=%s <%s> %s",
+			name, fullName, hash, target, taggerName.String, taggerEmail.String, taggerWhen.String)
+		count++
+	}
+
+	if err = rows.Err(); err != nil {
+		t.Fatalf("failed to fetch results: %v", err.Error())
+	}
+
+	var expected int
+	if err = db.QueryRow("SELECT count(*) FROM refs(?) WHERE type = 'tag'", repo).Scan(&expected); err != nil {
+		t.Fatalf("failed to execute query: %v", err.Error())
+	}
+
+	if count != expected {
+		t.Fatalf("expected %d tags, got %d", expected, count)
+	}
+}
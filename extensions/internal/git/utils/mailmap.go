@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+	"github.com/pkg/errors"
+)
+
+// LoadMailmap returns the mailmap to apply to identities read from repo, reading sources in the same order as git:
+// the .mailmap file at the root of tree (if tree is not nil), then the blob named by the mailmap.blob config
+// and finally the file named by the mailmap.file config. Missing sources are ignored.
+// A nil mailmap (which maps every identity to itself) is returned if the skipMailmap context value is set.
+func LoadMailmap(ctx services.Context, repo *git.Repository, tree *object.Tree) (mailmap.MailMap, error) {
+	if skip, _ := ctx.GetBool("skipMailmap"); skip {
+		return nil, nil
+	}
+
+	var mm = make(mailmap.MailMap)
+
+	if tree != nil {
+		if f, err := tree.File(".mailmap"); err == nil {
+			var contents string
+			if contents, err = f.Contents(); err != nil {
+				return nil, errors.Wrapf(err, "could not retrieve contents of mailmap file")
+			}
+			mm.Add(contents)
+		} else if err != object.ErrFileNotFound {
+			return nil, errors.Wrapf(err, "could not lookup mailmap file")
+		}
+	}
+
+	if blob := configOption(repo, "mailmap", "blob"); blob != "" {
+		contents, err := readMailmapBlob(repo, blob)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read mailmap.blob %q", blob)
+		}
+		mm.Add(contents)
+	}
+
+	if file := configOption(repo, "mailmap", "file"); file != "" {
+		if strings.HasPrefix(file, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				file = filepath.Join(home, file[2:])
+			}
+		} else if !filepath.IsAbs(file) {
+			// git resolves relative paths against the top of the working tree
+			if wt, err := repo.Worktree(); err == nil {
+				file = filepath.Join(wt.Filesystem.Root(), file)
+			}
+		}
+
+		contents, err := os.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrapf(err, "could not read mailmap.file %q", file)
+		}
+		mm.Add(string(contents))
+	}
+
+	return mm, nil
+}
+
+// configOption returns the value of section.key from the repository's config,
+// falling back to the global and then the system config if it isn't set there
+func configOption(repo *git.Repository, section, key string) string {
+	if cfg, err := repo.Config(); err == nil && cfg.Raw.Section(section).HasOption(key) {
+		return cfg.Raw.Section(section).Option(key)
+	}
+
+	for _, scope := range []config.Scope{config.GlobalScope, config.SystemScope} {
+		if cfg, err := config.LoadConfig(scope); err == nil && cfg.Raw.Section(section).HasOption(key) {
+			return cfg.Raw.Section(section).Option(key)
+		}
+	}
+	return ""
+}
+
+// readMailmapBlob returns the contents of the blob named by name, either in <rev>:<path> form
+// (such as HEAD:.mailmap) or as a blob hash. A name that doesn't resolve to an object yields no contents.
+func readMailmapBlob(repo *git.Repository, name string) (string, error) {
+	if rev, path, ok := strings.Cut(name, ":"); ok {
+		hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+		if err != nil {
+			return "", nil
+		}
+
+		var commit *object.Commit
+		if commit, err = repo.CommitObject(*hash); err != nil {
+			return "", err
+		}
+
+		var f *object.File
+		if f, err = commit.File(path); err == object.ErrFileNotFound {
+			return "", nil
+		} else if err != nil {
+			return "", err
+		}
+		return f.Contents()
+	}
+
+	blob, err := repo.BlobObject(plumbing.NewHash(name))
+	if err == plumbing.ErrObjectNotFound {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	r, err := blob.Reader()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var sb strings.Builder
+	_, err = io.Copy(&sb, r)
+	return sb.String(), err
+}
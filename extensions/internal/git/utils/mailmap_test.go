@@ -0,0 +1,106 @@
+package utils_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/mergestat/mergestat-lite/extensions/internal/git/utils"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/pkg/mailmap"
+)
+
+func TestLoadMailmap(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name, contents string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(".mailmap", "Tree Name <jane@example.com>\nTree Only <tree@example.com>\n")
+	write("blob.mailmap", "Blob Name <jane@example.com>\n")
+	write("file.mailmap", "File Name <jane@example.com>\n")
+
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = wt.AddGlob("*"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	hash, err := wt.Commit("add mailmaps", &git.CommitOptions{Author: sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lookup := func(ctx services.Context, email string) string {
+		t.Helper()
+		mm, err := utils.LoadMailmap(ctx, repo, tree)
+		if err != nil {
+			t.Fatalf("failed to load mailmap: %v", err)
+		}
+		return mm.Lookup(mailmap.NameAndEmail{Name: "someone", Email: email}).Name
+	}
+
+	ctx := services.Context{}
+	if name := lookup(ctx, "jane@example.com"); name != "Tree Name" {
+		t.Fatalf("expected the .mailmap in the tree to apply, got %q", name)
+	}
+
+	// mailmap.blob is read after .mailmap, and mailmap.file after that
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Raw.Section("mailmap").SetOption("blob", "HEAD:blob.mailmap")
+	if err = repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if name := lookup(ctx, "jane@example.com"); name != "Blob Name" {
+		t.Fatalf("expected mailmap.blob to take precedence, got %q", name)
+	}
+
+	cfg.Raw.Section("mailmap").SetOption("file", "file.mailmap") // relative to the working tree
+	if err = repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if name := lookup(ctx, "jane@example.com"); name != "File Name" {
+		t.Fatalf("expected mailmap.file to take precedence, got %q", name)
+	}
+	if name := lookup(ctx, "tree@example.com"); name != "Tree Only" {
+		t.Fatalf("expected rules from earlier sources to still apply, got %q", name)
+	}
+
+	// missing sources are ignored
+	cfg.Raw.Section("mailmap").SetOption("blob", "HEAD:missing.mailmap")
+	cfg.Raw.Section("mailmap").SetOption("file", filepath.Join(dir, "missing.mailmap"))
+	if err = repo.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if name := lookup(ctx, "jane@example.com"); name != "Tree Name" {
+		t.Fatalf("expected missing sources to be ignored, got %q", name)
+	}
+
+	if name := lookup(services.Context{"skipMailmap": "true"}, "jane@example.com"); name != "someone" {
+		t.Fatalf("expected no mapping when skipMailmap is set, got %q", name)
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"os"
 
 	"github.com/mergestat/mergestat-lite/extensions/services"
@@ -9,9 +10,14 @@ import (
 
 // ModuleOptions holds common options for all git related modules
 type ModuleOptions struct {
-	Locator services.RepoLocator
-	Context services.Context
-	Logger  *zerolog.Logger
+	Locator   services.RepoLocator
+	RefLister services.RefLister
+	Context   services.Context
+	Logger    *zerolog.Logger
+
+	// StatementContext returns the context of the statement being run, used to cancel the clones
+	// and history walks the modules perform for it
+	StatementContext func() context.Context
 }
 
 // GetDefaultRepoFromCtx looks up the defaultRepoPath key in the supplied context and returns it if set,
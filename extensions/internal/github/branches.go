@@ -72,6 +72,7 @@ func (i *iterBranches) fetchBranches(ctx context.Context, startCursor *githubv4.
 
 type iterBranches struct {
 	*Options
+	ctx     context.Context // of the statement the iterator is for
 	owner   string
 	name    string
 	current int
@@ -105,7 +106,7 @@ func (i *iterBranches) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -119,7 +120,7 @@ func (i *iterBranches) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_branches for %s/%s", i.owner, i.name)
-			results, err := i.fetchBranches(context.Background(), cursor)
+			results, err := i.fetchBranches(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -171,7 +172,7 @@ func NewBranchModule(opts *Options) sqlite.Module {
 			return nil, err
 		}
 
-		iter := &iterBranches{opts, owner, name, -1, nil}
+		iter := &iterBranches{opts, opts.StatementContext(), owner, name, -1, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_branches iterator for %s/%s", owner, name)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
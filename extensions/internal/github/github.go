@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -45,8 +46,9 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 			client := githubv4.NewClient(httpClient)
 			return client
 		},
-		PerPage: GetGitHubPerPageFromCtx(opt.Context),
-		Logger:  opt.Logger,
+		PerPage:          GetGitHubPerPageFromCtx(opt.Context),
+		Logger:           opt.Logger,
+		StatementContext: opt.StatementContext,
 	}
 
 	if opt.GitHubClientGetter != nil {
@@ -95,7 +97,7 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register GitHub tables
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = schema.CreateModule(ext, opt, "github", name, mod); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register GitHub %q module", name)
 		}
 	}
@@ -109,7 +111,7 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register GitHub funcs
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "github", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register GitHub %q function", name)
 		}
 	}
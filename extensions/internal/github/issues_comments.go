@@ -82,6 +82,7 @@ func (i *iterIssuesComments) fetchIssueComments(ctx context.Context, endCursor *
 
 type iterIssuesComments struct {
 	*Options
+	ctx            context.Context // of the statement the iterator is for
 	owner          string
 	name           string
 	issueNumber    int
@@ -142,7 +143,7 @@ func (i *iterIssuesComments) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentComment >= len(i.results.Comments.Comments.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -156,7 +157,7 @@ func (i *iterIssuesComments) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of issue_comments for %s/%s", i.owner, i.name)
-			results, err := i.fetchIssueComments(context.Background(), cursor)
+			results, err := i.fetchIssueComments(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -248,7 +249,7 @@ func NewIssueCommentsModule(opts *Options) sqlite.Module {
 			commentOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterIssuesComments{opts, owner, name, number, -1, commentOrder, nil}
+		iter := &iterIssuesComments{opts, opts.StatementContext(), owner, name, number, -1, commentOrder, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_issues_comment iterator for %s/%s issue: %d", owner, name, number)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
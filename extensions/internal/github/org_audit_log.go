@@ -82,6 +82,7 @@ func (i *iterOrgAuditLogs) fetchOrgAuditRepos(ctx context.Context, startCursor *
 
 type iterOrgAuditLogs struct {
 	*Options
+	ctx          context.Context // of the statement the iterator is for
 	login        string
 	affiliations string
 	current      int
@@ -141,7 +142,7 @@ func (i *iterOrgAuditLogs) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.AuditLogs) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -153,7 +154,7 @@ func (i *iterOrgAuditLogs) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of org audit entries for %s", i.login)
-			results, err := i.fetchOrgAuditRepos(context.Background(), cursor)
+			results, err := i.fetchOrgAuditRepos(i.ctx, cursor)
 			if err != nil {
 				return nil, err
 			}
@@ -212,7 +213,7 @@ func NewOrgAuditModule(opts *Options) sqlite.Module {
 				auditOrder.Direction = &dir
 			}
 		}
-		iter := &iterOrgAuditLogs{opts, login, affiliations, -1, nil, auditOrder}
+		iter := &iterOrgAuditLogs{opts, opts.StatementContext(), login, affiliations, -1, nil, auditOrder}
 		iter.logger().Info().Msgf("starting GitHub audit_log iterator for %s", login)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
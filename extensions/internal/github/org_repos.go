@@ -117,6 +117,7 @@ func (i *iterOrgRepos) fetchOrgRepos(ctx context.Context, startCursor *githubv4.
 
 type iterOrgRepos struct {
 	*Options
+	ctx          context.Context // of the statement the iterator is for
 	login        string
 	affiliations string
 	current      int
@@ -239,7 +240,7 @@ func (i *iterOrgRepos) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.OrgRepos) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -253,7 +254,7 @@ func (i *iterOrgRepos) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of org repos for %s", i.login)
-			results, err := i.fetchOrgRepos(context.Background(), cursor)
+			results, err := i.fetchOrgRepos(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -346,7 +347,7 @@ func NewOrgReposModule(opts *Options) sqlite.Module {
 			}
 			repoOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
-		iter := &iterOrgRepos{opts, login, affiliations, -1, nil, repoOrder}
+		iter := &iterOrgRepos{opts, opts.StatementContext(), login, affiliations, -1, nil, repoOrder}
 		iter.logger().Info().Msgf("starting GitHub org_repos iterator for %s", login)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
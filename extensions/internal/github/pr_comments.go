@@ -82,6 +82,7 @@ func (i *iterPRComments) fetchPRComments(ctx context.Context, endCursor *githubv
 
 type iterPRComments struct {
 	*Options
+	ctx            context.Context // of the statement the iterator is for
 	owner          string
 	name           string
 	prNumber       int
@@ -142,7 +143,7 @@ func (i *iterPRComments) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentComment >= len(i.results.Comments.Comments.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -156,7 +157,7 @@ func (i *iterPRComments) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of pr_comments for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRComments(context.Background(), cursor)
+			results, err := i.fetchPRComments(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -249,7 +250,7 @@ func NewPRCommentsModule(opts *Options) sqlite.Module {
 			commentOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterPRComments{opts, owner, name, number, -1, commentOrder, nil}
+		iter := &iterPRComments{opts, opts.StatementContext(), owner, name, number, -1, commentOrder, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_pr_comment iterator for %s/%s pr : %d", owner, name, number)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
@@ -87,6 +87,7 @@ func (i *iterPRCommits) fetchPRCommits(ctx context.Context, endCursor *githubv4.
 
 type iterPRCommits struct {
 	*Options
+	ctx           context.Context // of the statement the iterator is for
 	owner         string
 	name          string
 	prNumber      int
@@ -151,7 +152,7 @@ func (i *iterPRCommits) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentCommit >= len(i.results.PR.Commits.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -165,7 +166,7 @@ func (i *iterPRCommits) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of pr_commits for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRCommits(context.Background(), cursor)
+			results, err := i.fetchPRCommits(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -251,7 +252,7 @@ func NewPRCommitsModule(opts *Options) sqlite.Module {
 			name = nameOrNumber.Text()
 		}
 
-		iter := &iterPRCommits{opts, owner, name, number, -1, nil}
+		iter := &iterPRCommits{opts, opts.StatementContext(), owner, name, number, -1, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_pr_commit iterator for %s/%s pr : %d", owner, name, number)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
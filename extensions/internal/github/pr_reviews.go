@@ -90,6 +90,7 @@ func (i *iterPRReviews) fetchPRReviews(ctx context.Context, endCursor *githubv4.
 
 type iterPRReviews struct {
 	*Options
+	ctx           context.Context // of the statement the iterator is for
 	owner         string
 	name          string
 	prNumber      int
@@ -173,7 +174,7 @@ func (i *iterPRReviews) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.currentReview >= len(i.results.PullRequest.Reviews.Nodes) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -187,7 +188,7 @@ func (i *iterPRReviews) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of pr_reviews for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRReviews(context.Background(), cursor)
+			results, err := i.fetchPRReviews(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -275,7 +276,7 @@ func NewPRReviewsModule(opts *Options) sqlite.Module {
 			name = nameOrNumber.Text()
 		}
 
-		iter := &iterPRReviews{opts, owner, name, number, -1, nil}
+		iter := &iterPRReviews{opts, opts.StatementContext(), owner, name, number, -1, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_pr_reviews iterator for %s/%s pr : %d", owner, name, number)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
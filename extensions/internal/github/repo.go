@@ -1,7 +1,6 @@
 package github
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -19,7 +18,7 @@ type repoInfo struct {
 func (r *repoInfo) Args() int           { return -1 }
 func (r *repoInfo) Deterministic() bool { return false }
 func (r *repoInfo) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
-	err := r.opts.RateLimiter.Wait(context.Background())
+	err := r.opts.RateLimiter.Wait(r.opts.StatementContext())
 	if err != nil {
 		ctx.ResultError(err)
 		return
@@ -115,7 +114,7 @@ func (r *repoInfo) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 		"owner": githubv4.String(owner),
 		"name":  githubv4.String(name),
 	}
-	err = r.opts.Client().Query(context.Background(), &repoInfoQuery, variables)
+	err = r.opts.Client().Query(r.opts.StatementContext(), &repoInfoQuery, variables)
 
 	r.opts.GitHubPostRequestHook()
 
@@ -87,6 +87,7 @@ func (i *iterProtections) fetchProtections(ctx context.Context, startCursor *git
 
 type iterProtections struct {
 	*Options
+	ctx     context.Context // of the statement the iterator is for
 	owner   string
 	name    string
 	current int
@@ -148,7 +149,7 @@ func (i *iterProtections) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -162,7 +163,7 @@ func (i *iterProtections) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_protections for %s/%s", i.owner, i.name)
-			results, err := i.fetchProtections(context.Background(), cursor)
+			results, err := i.fetchProtections(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -228,7 +229,7 @@ func NewProtectionsModule(opts *Options) sqlite.Module {
 			return nil, err
 		}
 
-		iter := &iterProtections{opts, owner, name, -1, nil}
+		iter := &iterProtections{opts, opts.StatementContext(), owner, name, -1, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_protections iterator for %s/%s", owner, name)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
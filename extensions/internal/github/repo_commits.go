@@ -131,6 +131,7 @@ func (i *iterRepositoryCommits) fetchRepositoryCommits(ctx context.Context, endC
 
 type iterRepositoryCommits struct {
 	*Options
+	ctx           context.Context // of the statement the iterator is for
 	owner         string
 	name          string
 	branch        string
@@ -207,7 +208,7 @@ func (i *iterRepositoryCommits) Next() (vtab.Row, error) {
 	}
 	if i.results == nil || i.currentCommit >= len(current) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -221,7 +222,7 @@ func (i *iterRepositoryCommits) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repository_commits for %s/%s", i.owner, i.name)
-			results, err := i.fetchRepositoryCommits(context.Background(), cursor)
+			results, err := i.fetchRepositoryCommits(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -292,7 +293,7 @@ func NewRepoCommitsModule(opts *Options) sqlite.Module {
 		if name == "" {
 			return nil, fmt.Errorf("please supply a valid owner and repository name")
 		}
-		iter := &iterRepositoryCommits{opts, owner, name, branch, -1, nil}
+		iter := &iterRepositoryCommits{opts, opts.StatementContext(), owner, name, branch, -1, nil}
 		iter.logger().Info().Msgf("starting GitHub repo_commits iterator for %s/%s branch : %s", owner, name, branch)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
@@ -109,6 +109,7 @@ func (i *iterIssues) fetchIssues(ctx context.Context, startCursor *githubv4.Stri
 
 type iterIssues struct {
 	*Options
+	ctx        context.Context // of the statement the iterator is for
 	owner      string
 	name       string
 	current    int
@@ -219,7 +220,7 @@ func (i *iterIssues) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -233,7 +234,7 @@ func (i *iterIssues) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_issues for %s/%s", i.owner, i.name)
-			results, err := i.fetchIssues(context.Background(), cursor)
+			results, err := i.fetchIssues(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -319,7 +320,7 @@ func NewIssuesModule(opts *Options) sqlite.Module {
 			issueOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterIssues{opts, owner, name, -1, nil, issueOrder}
+		iter := &iterIssues{opts, opts.StatementContext(), owner, name, -1, nil, issueOrder}
 		iter.logger().Info().Msgf("starting GitHub repo_issues iterator for %s/%s", owner, name)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
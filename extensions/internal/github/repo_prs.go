@@ -127,6 +127,7 @@ func (i *iterPRs) fetchPRs(ctx context.Context, startCursor *githubv4.String) (*
 
 type iterPRs struct {
 	*Options
+	ctx     context.Context // of the statement the iterator is for
 	owner   string
 	name    string
 	current int
@@ -280,7 +281,7 @@ func (i *iterPRs) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -294,7 +295,7 @@ func (i *iterPRs) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of repo_pull_requests for %s/%s", i.owner, i.name)
-			results, err := i.fetchPRs(context.Background(), cursor)
+			results, err := i.fetchPRs(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -406,7 +407,7 @@ func NewPRModule(opts *Options) sqlite.Module {
 			prOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterPRs{opts, owner, name, -1, nil, prOrder}
+		iter := &iterPRs{opts, opts.StatementContext(), owner, name, -1, nil, prOrder}
 		iter.logger().Info().Msgf("starting GitHub repo_pull_requests iterator for %s/%s", owner, name)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
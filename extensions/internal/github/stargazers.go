@@ -78,6 +78,7 @@ func (i *iterStargazers) fetchStars(ctx context.Context, startCursor *githubv4.S
 
 type iterStargazers struct {
 	*Options
+	ctx       context.Context // of the statement the iterator is for
 	owner     string
 	name      string
 	current   int
@@ -139,7 +140,7 @@ func (i *iterStargazers) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -153,7 +154,7 @@ func (i *iterStargazers) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of stargazers for %s/%s", i.owner, i.name)
-			results, err := i.fetchStars(context.Background(), cursor)
+			results, err := i.fetchStars(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -228,7 +229,7 @@ func NewStargazersModule(opts *Options) sqlite.Module {
 			starOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterStargazers{opts, owner, name, -1, nil, starOrder}
+		iter := &iterStargazers{opts, opts.StatementContext(), owner, name, -1, nil, starOrder}
 		iter.logger().Info().Msgf("starting GitHub stargazers iterator for %s/%s", owner, name)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
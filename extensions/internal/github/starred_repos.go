@@ -73,6 +73,7 @@ func (i *iterStarredRepos) fetchStarredRepos(ctx context.Context, startCursor *g
 
 type iterStarredRepos struct {
 	*Options
+	ctx       context.Context // of the statement the iterator is for
 	login     string
 	current   int
 	results   *fetchStarredReposResults
@@ -134,7 +135,7 @@ func (i *iterStarredRepos) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.Edges) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -148,7 +149,7 @@ func (i *iterStarredRepos) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of starred_repos for %s", i.login)
-			results, err := i.fetchStarredRepos(context.Background(), cursor)
+			results, err := i.fetchStarredRepos(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -209,7 +210,7 @@ func NewStarredReposModule(opts *Options) sqlite.Module {
 			starOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterStarredRepos{opts, login, -1, nil, starOrder}
+		iter := &iterStarredRepos{opts, opts.StatementContext(), login, -1, nil, starOrder}
 		iter.logger().Info().Msgf("starting GitHub starred_repos iterator for %s", login)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
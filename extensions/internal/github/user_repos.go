@@ -117,6 +117,7 @@ func (i *iterUserRepos) fetchUserRepos(ctx context.Context, startCursor *githubv
 
 type iterUserRepos struct {
 	*Options
+	ctx          context.Context // of the statement the iterator is for
 	login        string
 	affiliations string
 	current      int
@@ -239,7 +240,7 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 
 	if i.results == nil || i.current >= len(i.results.UserRepos) {
 		if i.results == nil || i.results.HasNextPage {
-			err := i.RateLimiter.Wait(context.Background())
+			err := i.RateLimiter.Wait(i.ctx)
 			if err != nil {
 				return nil, err
 			}
@@ -253,7 +254,7 @@ func (i *iterUserRepos) Next() (vtab.Row, error) {
 
 			l := i.logger().With().Interface("cursor", cursor).Logger()
 			l.Info().Msgf("fetching page of user_repos for %s", i.login)
-			results, err := i.fetchUserRepos(context.Background(), cursor)
+			results, err := i.fetchUserRepos(i.ctx, cursor)
 
 			i.Options.GitHubPostRequestHook()
 
@@ -344,7 +345,7 @@ func NewUserReposModule(opts *Options) sqlite.Module {
 			repoOrder.Direction = orderByToGitHubOrder(order.Desc)
 		}
 
-		iter := &iterUserRepos{opts, login, affiliations, -1, nil, repoOrder}
+		iter := &iterUserRepos{opts, opts.StatementContext(), login, affiliations, -1, nil, repoOrder}
 		iter.logger().Info().Msgf("starting GitHub user_repos iterator for %s", login)
 		return iter, nil
 	}, vtab.EarlyOrderByConstraintExit(true))
@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"strings"
@@ -22,6 +23,9 @@ type Options struct {
 	// PerPage is the default number of items per page to use when making a paginated GitHub API request
 	PerPage int
 	Logger  *zerolog.Logger
+	// StatementContext returns the context of the statement being run, used for rate limiter waits
+	// and API requests, cancelling it aborts them
+	StatementContext func() context.Context
 }
 
 // GetGitHubTokenFromCtx looks up the githubToken key in the supplied context and returns it if set
@@ -1,6 +1,7 @@
 package golang
 
 import (
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"go.riyazali.net/sqlite"
@@ -13,7 +14,7 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 	}
 
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "golang", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register golang %q function", name)
 		}
 	}
@@ -1,19 +1,21 @@
 package helpers
 
 import (
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"go.riyazali.net/sqlite"
 )
 
 // Register registers helpers as a SQLite extension
-func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode, err error) {
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
 	var fns = map[string]sqlite.Function{
 		"str_split":    &StringSplit{},
 		"toml_to_json": &TomlToJson{},
 		"yaml_to_json": &YamlToJson{},
 		"xml_to_json":  &XmlToJson{},
 		"time_diff":    &TimeDiff{},
+		"to_timezone":  &ToTimezone{},
 		"approx_dur":   &ApproxDuration{},
 	}
 
@@ -21,7 +23,7 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 	fns["yml_to_json"] = fns["yaml_to_json"]
 
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "helpers", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
 		}
 	}
@@ -32,7 +34,7 @@ func Register(ext *sqlite.ExtensionApi, _ *options.Options) (_ sqlite.ErrorCode,
 	}
 
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = schema.CreateModule(ext, opt, "helpers", name, mod); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
 		}
 	}
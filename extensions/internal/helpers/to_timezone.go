@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // embed the IANA time zone database, so zone names resolve on any system
+
+	"go.riyazali.net/sqlite"
+)
+
+// ToTimezone implements to_timezone(datetime, offset_or_iana_name), which converts a datetime
+// to the local (wall clock) time of the given time zone. The zone is either a UTC offset, as found
+// in the author_tz_offset and committer_tz_offset columns of commits (e.g. +02:00, -0500, Z),
+// or an IANA time zone name (e.g. Europe/Berlin). The result is formatted as YYYY-MM-DD HH:MM:SS
+// (without an offset), so SQLite's date and time functions (such as strftime) operate on the local time.
+type ToTimezone struct{}
+
+func (y *ToTimezone) Args() int           { return 2 }
+func (y *ToTimezone) Deterministic() bool { return true }
+
+func (y *ToTimezone) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if value[0].IsNil() || value[1].IsNil() {
+		context.ResultNull()
+		return
+	}
+
+	t, err := parseDatetime(value[0].Text())
+	if err != nil {
+		context.ResultError(err)
+		return
+	}
+
+	loc, err := parseTimezone(value[1].Text())
+	if err != nil {
+		context.ResultError(err)
+		return
+	}
+
+	context.ResultText(t.In(loc).Format("2006-01-02 15:04:05"))
+}
+
+// datetimeLayouts are the layouts accepted for datetime inputs: RFC3339 (as output by the git tables),
+// followed by the formats of SQLite's own date and time functions (which are in UTC)
+var datetimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseDatetime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range datetimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("could not parse datetime %q", s)
+}
+
+var offsetPattern = regexp.MustCompile(`^([+-])(\d{1,2}):?(\d{2})?$`)
+
+func parseTimezone(s string) (*time.Location, error) {
+	s = strings.TrimSpace(s)
+	if s == "Z" || strings.EqualFold(s, "UTC") {
+		return time.UTC, nil
+	}
+
+	if m := offsetPattern.FindStringSubmatch(s); m != nil {
+		var hours, minutes int
+		hours, _ = strconv.Atoi(m[2])
+		if m[3] != "" {
+			minutes, _ = strconv.Atoi(m[3])
+		}
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid UTC offset %q", s)
+		}
+
+		offset := hours*60*60 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(s, offset), nil
+	}
+
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", s)
+	}
+	return loc, nil
+}
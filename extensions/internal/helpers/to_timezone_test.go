@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+)
+
+func TestToTimezone(t *testing.T) {
+	type test struct {
+		datetime, zone string
+		expected       string
+	}
+	tests := []test{
+		// offsets, in the forms found in the commits table and elsewhere
+		{datetime: "2021-06-04T23:30:00Z", zone: "+02:00", expected: "2021-06-05 01:30:00"},
+		{datetime: "2021-06-04T23:30:00Z", zone: "-0500", expected: "2021-06-04 18:30:00"},
+		{datetime: "2021-06-04T23:30:00Z", zone: "+5", expected: "2021-06-05 04:30:00"},
+		{datetime: "2021-06-04T23:30:00Z", zone: "+05:30", expected: "2021-06-05 05:00:00"},
+		{datetime: "2021-06-04T18:30:00-05:00", zone: "Z", expected: "2021-06-04 23:30:00"},
+		// IANA time zone names, including daylight saving time
+		{datetime: "2021-01-15T12:00:00Z", zone: "Europe/Berlin", expected: "2021-01-15 13:00:00"},
+		{datetime: "2021-07-15T12:00:00Z", zone: "Europe/Berlin", expected: "2021-07-15 14:00:00"},
+		{datetime: "2021-07-15T12:00:00Z", zone: "America/New_York", expected: "2021-07-15 08:00:00"},
+		// output from SQLite's own date and time functions is in UTC
+		{datetime: "2021-07-15 12:00:00", zone: "+01:00", expected: "2021-07-15 13:00:00"},
+		{datetime: "2021-07-15", zone: "-01:00", expected: "2021-07-14 23:00:00"},
+	}
+
+	for _, testCase := range tests {
+		rows, err := FixtureDatabase.Query("SELECT to_timezone(?, ?)", testCase.datetime, testCase.zone)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rowNum, contents, err := tools.RowContent(rows)
+		if err != nil {
+			t.Fatalf("err %v at row %d", err, rowNum)
+		}
+
+		if contents[0][0] != testCase.expected {
+			t.Fatalf("expected %s in %s to be %s, got %s", testCase.datetime, testCase.zone, testCase.expected, contents[0][0])
+		}
+	}
+}
+
+func TestToTimezoneLocalWeekday(t *testing.T) {
+	// late on a Friday in New York is already Saturday in UTC
+	var weekday string
+	err := FixtureDatabase.QueryRow("SELECT strftime('%w', to_timezone('2021-06-04T22:30:00-04:00', '-04:00'))").Scan(&weekday)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if weekday != "5" {
+		t.Fatalf("expected weekday 5 (friday), got %s", weekday)
+	}
+}
+
+func TestToTimezoneInvalid(t *testing.T) {
+	for _, args := range [][]interface{}{
+		{"not a date", "+01:00"},
+		{"2021-07-15T12:00:00Z", "Not/AZone"},
+		{"2021-07-15T12:00:00Z", "+25:00"},
+	} {
+		var s string
+		if err := FixtureDatabase.QueryRow("SELECT to_timezone(?, ?)", args...).Scan(&s); err == nil {
+			t.Fatalf("expected an error for %v, got %s", args, s)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package identities
+
+import (
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/identities"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// Register registers the contributor identity functions as a SQLite extension
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	// without any configured identities, every identity resolves to itself
+	var ids *identities.Identities
+	if opt != nil {
+		ids = opt.Identities
+	}
+
+	var fns = map[string]sqlite.Function{
+		"author_identity": &AuthorIdentity{ids},
+		"author_org":      &AuthorOrg{ids},
+		"is_bot":          &IsBot{ids},
+	}
+
+	for name, fn := range fns {
+		if err = schema.CreateFunction(ext, "identities", name, fn); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
+		}
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
+
+// AuthorIdentity implements author_identity(name, email), returning the canonical name of a contributor
+type AuthorIdentity struct{ ids *identities.Identities }
+
+func (f *AuthorIdentity) Args() int           { return 2 }
+func (f *AuthorIdentity) Deterministic() bool { return true }
+
+func (f *AuthorIdentity) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	context.ResultText(f.ids.Resolve(value[0].Text(), value[1].Text()).Name)
+}
+
+// AuthorOrg implements author_org(name, email), returning the organization of a contributor (or NULL if unknown)
+type AuthorOrg struct{ ids *identities.Identities }
+
+func (f *AuthorOrg) Args() int           { return 2 }
+func (f *AuthorOrg) Deterministic() bool { return true }
+
+func (f *AuthorOrg) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if org := f.ids.Resolve(value[0].Text(), value[1].Text()).Org; org != "" {
+		context.ResultText(org)
+	} else {
+		context.ResultNull()
+	}
+}
+
+// IsBot implements is_bot(name, email), returning whether a contributor is a bot
+type IsBot struct{ ids *identities.Identities }
+
+func (f *IsBot) Args() int           { return 2 }
+func (f *IsBot) Deterministic() bool { return true }
+
+func (f *IsBot) Apply(context *sqlite.Context, value ...sqlite.Value) {
+	if f.ids.Resolve(value[0].Text(), value[1].Text()).Bot {
+		context.ResultInt(1)
+	} else {
+		context.ResultInt(0)
+	}
+}
@@ -0,0 +1,75 @@
+package identities
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/identities"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+// FixtureDatabase represents the database connection to run the test against
+var FixtureDatabase *sql.DB
+
+func init() {
+	ids, err := identities.Parse([]byte(`
+people:
+  - name: Jane Doe
+    emails: ["jane@*"]
+orgs:
+  - name: Acme
+    domains: [acme.com]
+`))
+	if err != nil {
+		log.Fatalf("failed to parse identities: %v", err)
+	}
+
+	// register sqlite extension when this package is loaded
+	sqlite.Register(func(ext *sqlite.ExtensionApi) (_ sqlite.ErrorCode, err error) {
+		return Register(ext, &options.Options{Identities: ids})
+	})
+}
+
+func TestMain(m *testing.M) {
+	var err error
+	if FixtureDatabase, err = sql.Open("sqlite3", "file:testing.db?mode=memory"); err != nil {
+		log.Fatalf("failed to open database connection: %v", err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestIdentityFunctions(t *testing.T) {
+	rows, err := FixtureDatabase.Query(`SELECT author_identity(name, email), author_org(name, email), is_bot(name, email)
+		FROM (SELECT 'jd' AS name, 'jane@acme.com' AS email UNION ALL
+			SELECT 'Someone', 'someone@example.com' UNION ALL
+			SELECT 'dependabot[bot]', '49699333+dependabot[bot]@users.noreply.github.com')
+		ORDER BY 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowNum, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("err %v at row %d", err, rowNum)
+	}
+
+	var expected = [][]string{
+		{"Jane Doe", "Acme", "0"},
+		{"Someone", "NULL", "0"},
+		{"dependabot[bot]", "NULL", "1"},
+	}
+
+	for i, row := range expected {
+		for j, v := range row {
+			if contents[i][j] != v {
+				t.Fatalf("expected %q at row %d column %d, got %q", v, i, j, contents[i][j])
+			}
+		}
+	}
+}
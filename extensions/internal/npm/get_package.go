@@ -7,7 +7,10 @@ import (
 	"go.riyazali.net/sqlite"
 )
 
-type GetPackage struct{ *Client }
+type GetPackage struct {
+	*Client
+	statementContext func() context.Context // used to cancel in-flight requests to the registry
+}
 
 func (f *GetPackage) Args() int           { return -1 }
 func (f *GetPackage) Deterministic() bool { return false }
@@ -17,14 +20,14 @@ func (f *GetPackage) Apply(ctx *sqlite.Context, values ...sqlite.Value) {
 		ctx.ResultError(fmt.Errorf("expected a package name"))
 		return
 	case len(values) == 1:
-		if res, err := f.GetPackage(context.Background(), values[0].Text()); err != nil {
+		if res, err := f.GetPackage(f.statementContext(), values[0].Text()); err != nil {
 			ctx.ResultError(err)
 			return
 		} else {
 			ctx.ResultText(string(res))
 		}
 	default:
-		if res, err := f.GetPackageVersion(context.Background(), values[0].Text(), values[1].Text()); err != nil {
+		if res, err := f.GetPackageVersion(f.statementContext(), values[0].Text(), values[1].Text()); err != nil {
 			ctx.ResultError(err)
 			return
 		} else {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -83,11 +84,11 @@ func (c *Client) GetPackageVersion(ctx context.Context, packageName, version str
 // Register registers npm API related functionality as a SQLite extension
 func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
 	var fns = map[string]sqlite.Function{
-		"npm_get_package": &GetPackage{NewClient(opt.NPMHttpClient, opt.Logger)},
+		"npm_get_package": &GetPackage{NewClient(opt.NPMHttpClient, opt.Logger), opt.StatementContext},
 	}
 
 	for name, fn := range fns {
-		if err = ext.CreateFunction(name, fn); err != nil {
+		if err = schema.CreateFunction(ext, "npm", name, fn); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q function", name)
 		}
 	}
@@ -0,0 +1,197 @@
+package schema
+
+// doc documents a table or function: its description and arguments, or the name of the table or function it is an alias of
+type doc struct {
+	description string
+	aliasOf     string
+	args        []Arg
+}
+
+var (
+	repository = Arg{Name: "repository", Description: "path or URL of the repository, the default repository (see --repo) if empty"}
+	owner      = Arg{Name: "owner", Required: true, Description: "owner of the repository, or its full name as owner/name"}
+	reponame   = Arg{Name: "reponame", Description: "name of the repository, if owner is not a full name"}
+	prNumber   = Arg{Name: "pr_number", Required: true, Description: "number of the pull request"}
+)
+
+// tableDocs documents the tables, by name
+var tableDocs = map[string]doc{
+	// git
+	"commits": {description: "Commits of a repository, from a ref", args: []Arg{
+		repository,
+		{Name: "ref", Description: "ref (or revision) to list the history of, HEAD if empty"},
+	}},
+	"refs": {description: "Branches, remote branches and tags of a repository (the hidden tag column is for commit_from_tag)", args: []Arg{repository}},
+	"stats": {description: "Lines added and deleted by file, by a commit or between two revisions", args: []Arg{
+		repository,
+		{Name: "rev", Description: "revision to compare with its first parent, HEAD if empty"},
+		{Name: "to_rev", Description: "revision to compare rev with, instead of its first parent"},
+	}},
+	"files": {description: "Files (and their contents) of the tree of a revision", args: []Arg{
+		repository,
+		{Name: "rev", Description: "revision to list the files of, HEAD if empty"},
+	}},
+	"blame": {description: "Commit that last changed each line of a file", args: []Arg{
+		repository,
+		{Name: "rev", Description: "revision to blame the file at, HEAD if empty"},
+		{Name: "file_path", Required: true, Description: "path of the file to blame"},
+	}},
+	"file_history": {description: "Size of a file or directory at every commit that touched it, newest first", args: []Arg{
+		repository,
+		{Name: "ref", Description: "ref to walk the history of, HEAD if empty"},
+		{Name: "path", Required: true, Description: "path of the file or directory"},
+	}},
+	"hotspots": {description: "Files at HEAD ranked by how often they change and how complex they are", args: []Arg{
+		repository,
+		{Name: "since", Description: "date to count changes from, the whole history if empty"},
+	}},
+	"line_survival": {description: "Every line ever added along the first-parent history, with the commits that added and removed it", args: []Arg{
+		repository,
+		{Name: "ref", Description: "ref to replay the history of, HEAD if empty"},
+		{Name: "path_pattern", Description: "git pathspec limiting the files that are replayed (such as src/ or *.go)"},
+	}},
+	"remote_refs": {description: "Refs of a remote repository (like git ls-remote), without cloning it", args: []Arg{
+		{Name: "url", Required: true, Description: "URL of the remote repository"},
+	}},
+	"tags": {description: "Tags of a repository, with the tagger of annotated tags", args: []Arg{repository}},
+
+	// helpers
+	"grep": {description: "Lines of a text matching a search, with the lines around them", args: []Arg{
+		{Name: "contents", Required: true, Description: "text to search"},
+		{Name: "search", Required: true, Description: "text to search for"},
+		{Name: "preceeding", Description: "number of lines to include before each match"},
+		{Name: "proceeding", Description: "number of lines to include after each match"},
+	}},
+	"str_split": {description: "Parts of a text split on a delimiter, one row per part", args: []Arg{
+		{Name: "contents", Required: true, Description: "text to split"},
+		{Name: "delimiter", Description: "delimiter to split on, a new line if empty"},
+	}},
+
+	// github
+	"github_stargazers": {description: "Users who starred a GitHub repository", args: []Arg{owner, reponame}},
+	"github_starred_repos": {description: "Repositories starred by a GitHub user", args: []Arg{
+		{Name: "login", Required: true, Description: "login of the user"},
+	}},
+	"github_user_repos": {description: "Repositories of a GitHub user", args: []Arg{
+		{Name: "login", Required: true, Description: "login of the user"},
+		{Name: "affiliations", Description: "comma separated affiliations of the user to the repositories (owner, collaborator, organization_member)"},
+	}},
+	"github_org_repos": {description: "Repositories of a GitHub organization", args: []Arg{
+		{Name: "login", Required: true, Description: "login of the organization"},
+		{Name: "affiliations", Description: "comma separated affiliations of the viewer to the repositories (owner, collaborator, organization_member)"},
+	}},
+	"github_repo_issues":             {description: "Issues of a GitHub repository", args: []Arg{owner, reponame}},
+	"github_repo_pull_requests":      {description: "Pull requests of a GitHub repository", args: []Arg{owner, reponame}},
+	"github_repo_branch_protections": {description: "Branch protection rules of a GitHub repository", args: []Arg{owner, reponame}},
+	"github_repo_issue_comments": {description: "Comments of an issue of a GitHub repository", args: []Arg{owner, reponame,
+		{Name: "issue_number", Required: true, Description: "number of the issue"},
+	}},
+	"github_repo_pr_comments": {description: "Comments of a pull request of a GitHub repository", args: []Arg{owner, reponame, prNumber}},
+	"github_repo_branches":    {description: "Branches of a GitHub repository", args: []Arg{owner, reponame}},
+	"github_repo_pr_commits":  {description: "Commits of a pull request of a GitHub repository", args: []Arg{owner, reponame, prNumber}},
+	"github_repo_commits":     {description: "Commits of the default branch of a GitHub repository", args: []Arg{owner, reponame}},
+	"github_repo_pr_reviews":  {description: "Reviews of a pull request of a GitHub repository", args: []Arg{owner, reponame, prNumber}},
+	"github_org_audit_log": {description: "Audit log of a GitHub organization", args: []Arg{
+		{Name: "login", Required: true, Description: "login of the organization"},
+	}},
+
+	"github_issue_comments":     {aliasOf: "github_repo_issue_comments"},
+	"github_pr_comments":        {aliasOf: "github_repo_pr_comments"},
+	"github_issues":             {aliasOf: "github_repo_issues"},
+	"github_pull_requests":      {aliasOf: "github_repo_pull_requests"},
+	"github_prs":                {aliasOf: "github_repo_pull_requests"},
+	"github_repo_prs":           {aliasOf: "github_repo_pull_requests"},
+	"github_branch_protections": {aliasOf: "github_repo_branch_protections"},
+	"github_pr_commits":         {aliasOf: "github_repo_pr_commits"},
+	"github_pr_reviews":         {aliasOf: "github_repo_pr_reviews"},
+	"github_audit_log":          {aliasOf: "github_org_audit_log"},
+
+	// sourcegraph
+	"sourcegraph_search": {description: "Results of a Sourcegraph search", args: []Arg{
+		{Name: "query", Required: true, Description: "Sourcegraph search query"},
+	}},
+
+	// mergestat
+	"mergestat_tables":    {description: "Tables registered by the extensions, with their columns and arguments"},
+	"mergestat_functions": {description: "Functions registered by the extensions, with their arguments"},
+}
+
+var (
+	contributorName  = Arg{Name: "name", Required: true, Description: "name of the contributor"}
+	contributorEmail = Arg{Name: "email", Required: true, Description: "email of the contributor"}
+	filePath         = Arg{Name: "path", Required: true, Description: "path of the file"}
+	fileContents     = Arg{Name: "contents", Required: true, Description: "contents of the file"}
+	githubRepo       = Arg{Name: "repo", Required: true, Description: "owner of the GitHub repository, or its full name as owner/name"}
+)
+
+// functionDocs documents the functions, by name
+var functionDocs = map[string]doc{
+	// git
+	"commit_from_tag": {description: "Hash of the commit a tag (as returned by refs) points to", args: []Arg{
+		{Name: "tag", Required: true, Description: "hidden tag column of refs"},
+	}},
+	"clone": {description: "Opens (or clones) a repository ahead of querying it, returning its path", args: []Arg{repository}},
+
+	// helpers
+	"str_split": {description: "Part of a text split on a separator, by index", args: []Arg{
+		{Name: "input", Required: true},
+		{Name: "separator", Required: true},
+		{Name: "index", Required: true, Description: "index of the part, from 0"},
+	}},
+	"toml_to_json": {description: "Converts TOML to JSON", args: []Arg{{Name: "toml", Required: true}}},
+	"yaml_to_json": {description: "Converts YAML to JSON", args: []Arg{{Name: "yaml", Required: true}}},
+	"yml_to_json":  {aliasOf: "yaml_to_json"},
+	"xml_to_json":  {description: "Converts XML to JSON", args: []Arg{{Name: "xml", Required: true}}},
+	"time_diff": {description: "Time between two times, in words (such as 3 days ago)", args: []Arg{
+		{Name: "time", Required: true, Description: "RFC 3339 time"},
+		{Name: "start", Description: "time to compare with, now if omitted"},
+		{Name: "layout", Description: "Go layout of the times, RFC 3339 if omitted"},
+	}},
+	"to_timezone": {description: "Converts a datetime to the local time of a time zone", args: []Arg{
+		{Name: "datetime", Required: true},
+		{Name: "zone", Required: true, Description: "UTC offset (such as +02:00) or IANA time zone name"},
+	}},
+	"approx_dur": {description: "Approximate duration in years, months and days (such as 2 years 3 months)", args: []Arg{
+		{Name: "days", Required: true, Description: "duration in days"},
+	}},
+
+	// enry
+	"enry_detect_language":  {description: "Language of a file", args: []Arg{filePath, fileContents}},
+	"enry_is_binary":        {description: "Whether contents are binary", args: []Arg{{Name: "contents", Required: true}}},
+	"enry_is_configuration": {description: "Whether a file is a configuration file", args: []Arg{filePath}},
+	"enry_is_documentation": {description: "Whether a file is documentation", args: []Arg{filePath}},
+	"enry_is_dot_file":      {description: "Whether a file is a dot file", args: []Arg{filePath}},
+	"enry_is_generated":     {description: "Whether a file is generated", args: []Arg{filePath, fileContents}},
+	"enry_is_image":         {description: "Whether a file is an image", args: []Arg{filePath}},
+	"enry_is_test":          {description: "Whether a file is a test", args: []Arg{filePath}},
+	"enry_is_vendor":        {description: "Whether a file is vendored", args: []Arg{filePath}},
+
+	// golang
+	"go_mod_to_json": {description: "Converts a go.mod file to JSON", args: []Arg{{Name: "contents", Required: true}}},
+
+	// identities
+	"author_identity": {description: "Canonical name of a contributor (see --identities)", args: []Arg{contributorName, contributorEmail}},
+	"author_org":      {description: "Organization of a contributor, NULL if unknown (see --identities)", args: []Arg{contributorName, contributorEmail}},
+	"is_bot":          {description: "Whether a contributor is a bot (see --identities)", args: []Arg{contributorName, contributorEmail}},
+
+	// github
+	"github_stargazer_count": {description: "Number of stars of a GitHub repository", args: []Arg{githubRepo,
+		{Name: "name", Description: "name of the repository, if repo is not a full name"},
+	}},
+	"github_repo_file_content": {description: "Contents of a file of a GitHub repository", args: []Arg{githubRepo,
+		{Name: "name", Description: "name of the repository, if repo is not a full name"},
+		{Name: "expression", Required: true, Description: "file to get, as rev:path (or path, at HEAD)"},
+	}},
+	"github_user": {description: "Information about a GitHub user, as JSON", args: []Arg{
+		{Name: "login", Required: true, Description: "login of the user"},
+	}},
+	"github_repo": {description: "Information about a GitHub repository, as JSON", args: []Arg{githubRepo,
+		{Name: "name", Description: "name of the repository, if repo is not a full name"},
+	}},
+
+	// npm
+	"npm_get_package": {description: "Metadata of a package of the npm registry, as JSON", args: []Arg{
+		{Name: "package", Required: true, Description: "name of the package"},
+		{Name: "version", Description: "version of the package, all versions if omitted"},
+	}},
+}
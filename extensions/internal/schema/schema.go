@@ -0,0 +1,308 @@
+// Package schema keeps track of the tables and functions registered by the extensions, and describes them
+// with the mergestat_tables and mergestat_functions tables, so that what can be queried is discoverable:
+//
+//	SELECT name, arguments FROM mergestat_tables WHERE extension = 'git'
+//
+// Extensions register their modules and functions with CreateModule and CreateFunction (instead of the
+// methods of sqlite.ExtensionApi), which records the extension they belong to. Columns are read from the
+// schema declared by the modules, while descriptions and arguments are documented in docs.go.
+package schema
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/augmentable-dev/vtab"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/pkg/errors"
+	"go.riyazali.net/sqlite"
+)
+
+// Table describes a table (or table-valued function) registered by an extension
+type Table struct {
+	Name        string   `json:"name"`
+	Extension   string   `json:"extension"`
+	Description string   `json:"description,omitempty"`
+	AliasOf     string   `json:"alias_of,omitempty"`
+	Columns     []Column `json:"columns"`   // declared columns, including the hidden ones
+	Arguments   []Arg    `json:"arguments"` // hidden columns that are arguments of the table-valued function, in order
+}
+
+// Column is a column declared by a module
+type Column struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// Arg is an argument of a table-valued function or of a function
+type Arg struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// Function describes a SQL function registered by an extension
+type Function struct {
+	Name        string `json:"name"`
+	Extension   string `json:"extension"`
+	Description string `json:"description,omitempty"`
+	AliasOf     string `json:"alias_of,omitempty"`
+	NumArgs     int    `json:"num_args"` // -1 for functions with a variable number of arguments
+	Arguments   []Arg  `json:"arguments"`
+}
+
+type module struct {
+	extension string
+	columns   []Column // read from the declared schema, when the module is first registered
+}
+
+type function struct {
+	extension string
+	numArgs   int
+}
+
+// registry holds what has been registered, by name. Extensions are registered on every new connection,
+// with the same modules and functions, so that registering a name again replaces its previous entry.
+var registry = struct {
+	sync.Mutex
+	modules   map[string]*module
+	functions map[string]*function
+}{modules: make(map[string]*module), functions: make(map[string]*function)}
+
+// CreateModule registers mod with ext as the module called name, which belongs to extension.
+// If opt has a tracer, the module reports the activity of its tables to it.
+func CreateModule(ext *sqlite.ExtensionApi, opt *options.Options, extension, name string, mod sqlite.Module, opts ...func(*sqlite.ModuleOptions)) error {
+	var registered = mod
+	if opt != nil && opt.Tracer != nil {
+		registered = &tracedModule{Module: mod, name: name, tracer: opt.Tracer}
+	}
+	if err := ext.CreateModule(name, registered, opts...); err != nil {
+		return err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	var m = &module{extension: extension}
+	if previous, ok := registry.modules[name]; ok && previous.columns != nil {
+		m.columns = previous.columns
+	} else {
+		m.columns = declaredColumns(ext.Connection(), name, mod)
+	}
+	registry.modules[name] = m
+	return nil
+}
+
+// CreateFunction registers fn with ext as the function called name, which belongs to extension
+func CreateFunction(ext *sqlite.ExtensionApi, extension, name string, fn sqlite.Function) error {
+	if err := ext.CreateFunction(name, fn); err != nil {
+		return err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	registry.functions[name] = &function{extension: extension, numArgs: fn.Args()}
+	return nil
+}
+
+// Tables returns the tables that have been registered, sorted by name
+func Tables() []*Table {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var tables = make([]*Table, 0, len(registry.modules))
+	for name, m := range registry.modules {
+		doc := tableDocs[name]
+		var t = &Table{Name: name, Extension: m.extension, Description: doc.description, AliasOf: doc.aliasOf,
+			Columns: m.columns, Arguments: []Arg{}}
+		if doc.aliasOf != "" {
+			t.Description = "Alias of " + doc.aliasOf
+			doc = tableDocs[doc.aliasOf]
+		}
+
+		for _, arg := range doc.args {
+			for _, c := range t.Columns {
+				if c.Name == arg.Name {
+					arg.Type = c.Type
+				}
+			}
+			t.Arguments = append(t.Arguments, arg)
+		}
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// Functions returns the functions that have been registered, sorted by name
+func Functions() []*Function {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var functions = make([]*Function, 0, len(registry.functions))
+	for name, f := range registry.functions {
+		doc := functionDocs[name]
+		var fn = &Function{Name: name, Extension: f.extension, Description: doc.description, AliasOf: doc.aliasOf, NumArgs: f.numArgs}
+		if doc.aliasOf != "" {
+			fn.Description = "Alias of " + doc.aliasOf
+			doc = functionDocs[doc.aliasOf]
+		}
+		fn.Arguments = append([]Arg{}, doc.args...)
+		functions = append(functions, fn)
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions
+}
+
+// declaredColumns returns the columns declared by mod, which is connected (and disconnected) with conn to read them.
+// It is called when a module is first registered, with the connection it is registered on (see CreateModule).
+func declaredColumns(conn *sqlite.Conn, name string, mod sqlite.Module) []Column {
+	var declared string
+	table, err := mod.Connect(conn, []string{name, "main", name}, func(s string) error { declared = s; return nil })
+	if err != nil {
+		return nil
+	}
+	_ = table.Disconnect()
+	return parseDeclaration(declared)
+}
+
+// parseDeclaration returns the columns of a CREATE TABLE statement, as declared by a module:
+// each column has a name, an optional type and is HIDDEN if it is an argument (or hidden output) of the module
+func parseDeclaration(declared string) []Column {
+	start, end := strings.Index(declared, "("), strings.LastIndex(declared, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	// split the definitions on the commas that are not nested in parentheses (as in PRIMARY KEY ( hash ))
+	var definitions []string
+	var depth, from int
+	body := declared[start+1 : end]
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, body[from:i])
+				from = i + 1
+			}
+		}
+	}
+	definitions = append(definitions, body[from:])
+
+	var columns []Column
+	for _, definition := range definitions {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue // table constraints
+		}
+
+		var c = Column{Name: fields[0]}
+		for _, field := range fields[1:] {
+			switch strings.ToUpper(field) {
+			case "HIDDEN":
+				c.Hidden = true
+			case "NOT", "NULL", "PRIMARY", "KEY", "DEFAULT", "UNIQUE":
+				// constraints of the column, which end its type
+			default:
+				if c.Type == "" {
+					c.Type = strings.ToUpper(field)
+				}
+			}
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
+
+var tablesCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "extension", Type: "TEXT"},
+	{Name: "description", Type: "TEXT"},
+	{Name: "alias_of", Type: "TEXT"},
+	{Name: "columns", Type: "JSON"},
+	{Name: "arguments", Type: "JSON"},
+}
+
+var functionsCols = []vtab.Column{
+	{Name: "name", Type: "TEXT"},
+	{Name: "extension", Type: "TEXT"},
+	{Name: "description", Type: "TEXT"},
+	{Name: "alias_of", Type: "TEXT"},
+	{Name: "num_args", Type: "INT"},
+	{Name: "arguments", Type: "JSON"},
+}
+
+// Register registers the mergestat_tables and mergestat_functions tables, which describe
+// the tables and functions registered so far (and those registered later on)
+func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCode, err error) {
+	var modules = map[string]sqlite.Module{
+		"mergestat_tables": vtab.NewTableFunc("mergestat_tables", tablesCols, func([]*vtab.Constraint, []*sqlite.OrderBy) (vtab.Iterator, error) {
+			var rows [][]interface{}
+			for _, t := range Tables() {
+				rows = append(rows, []interface{}{t.Name, t.Extension, t.Description, t.AliasOf, t.Columns, t.Arguments})
+			}
+			return &iter{rows: rows}, nil
+		}),
+		"mergestat_functions": vtab.NewTableFunc("mergestat_functions", functionsCols, func([]*vtab.Constraint, []*sqlite.OrderBy) (vtab.Iterator, error) {
+			var rows [][]interface{}
+			for _, f := range Functions() {
+				rows = append(rows, []interface{}{f.Name, f.Extension, f.Description, f.AliasOf, f.NumArgs, f.Arguments})
+			}
+			return &iter{rows: rows}, nil
+		}),
+	}
+
+	for name, mod := range modules {
+		if err = CreateModule(ext, opt, "mergestat", name, mod); err != nil {
+			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register %q module", name)
+		}
+	}
+
+	return sqlite.SQLITE_OK, nil
+}
+
+// iter iterates over rows of values, which are text (NULL if empty), integers, or encoded as JSON
+type iter struct {
+	rows    [][]interface{}
+	current []interface{}
+}
+
+func (i *iter) Column(ctx vtab.Context, c int) error {
+	switch v := i.current[c].(type) {
+	case string:
+		if v == "" {
+			ctx.ResultNull()
+		} else {
+			ctx.ResultText(v)
+		}
+	case int:
+		ctx.ResultInt(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		ctx.ResultText(string(b))
+	}
+	return nil
+}
+
+func (i *iter) Next() (vtab.Row, error) {
+	if len(i.rows) == 0 {
+		return nil, io.EOF
+	}
+	i.current, i.rows = i.rows[0], i.rows[1:]
+	return i, nil
+}
@@ -0,0 +1,132 @@
+package schema_test
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/extensions"
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
+	"github.com/mergestat/mergestat-lite/extensions/internal/tools"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+	_ "github.com/mergestat/mergestat-lite/pkg/sqlite"
+	"go.riyazali.net/sqlite"
+)
+
+// FixtureDatabase represents the database connection to run the test against
+var FixtureDatabase *sql.DB
+
+func init() {
+	// register sqlite extension when this package is loaded, with every optional extension
+	sqlite.Register(extensions.RegisterFn(
+		options.WithExtraFunctions(), options.WithGitHub(), options.WithSourcegraph(), options.WithNPM(),
+		options.WithRepoLocator(locator.MultiLocator(nil)),
+	))
+}
+
+func TestMain(m *testing.M) {
+	var err error
+	if FixtureDatabase, err = sql.Open("sqlite3", "file:testing.db?mode=memory"); err != nil {
+		log.Fatalf("failed to open database connection: %v", err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestTables(t *testing.T) {
+	rows, err := FixtureDatabase.Query(`SELECT name, extension, alias_of, columns, arguments FROM mergestat_tables WHERE name IN ('grep', 'mergestat_tables') ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowNum, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("err %v at row %d", err, rowNum)
+	}
+
+	var expected = [][]string{
+		{"grep", "helpers", "NULL",
+			`[{"name":"line_no","type":"INT"},{"name":"line","type":"TEXT"},{"name":"contents","type":"TEXT","hidden":true},{"name":"search","type":"TEXT","hidden":true},{"name":"preceeding","type":"INT","hidden":true},{"name":"proceeding","type":"INT","hidden":true}]`,
+			`[{"name":"contents","type":"TEXT","required":true,"description":"text to search"},{"name":"search","type":"TEXT","required":true,"description":"text to search for"},{"name":"preceeding","type":"INT","required":false,"description":"number of lines to include before each match"},{"name":"proceeding","type":"INT","required":false,"description":"number of lines to include after each match"}]`},
+		{"mergestat_tables", "mergestat", "NULL",
+			`[{"name":"name","type":"TEXT"},{"name":"extension","type":"TEXT"},{"name":"description","type":"TEXT"},{"name":"alias_of","type":"TEXT"},{"name":"columns","type":"JSON"},{"name":"arguments","type":"JSON"}]`,
+			`[]`},
+	}
+
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for i, row := range expected {
+		for j, v := range row {
+			if contents[i][j] != v {
+				t.Fatalf("expected %q at row %d column %d, got %q", v, i, j, contents[i][j])
+			}
+		}
+	}
+}
+
+func TestFunctions(t *testing.T) {
+	rows, err := FixtureDatabase.Query(`SELECT name, extension, description, num_args, json_array_length(arguments) FROM mergestat_functions WHERE name IN ('str_split', 'time_diff', 'yml_to_json') ORDER BY name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rowNum, contents, err := tools.RowContent(rows)
+	if err != nil {
+		t.Fatalf("err %v at row %d", err, rowNum)
+	}
+
+	var expected = [][]string{
+		{"str_split", "helpers", "Part of a text split on a separator, by index", "3", "3"},
+		{"time_diff", "helpers", "Time between two times, in words (such as 3 days ago)", "-1", "3"},
+		{"yml_to_json", "helpers", "Alias of yaml_to_json", "1", "1"},
+	}
+
+	if len(contents) != len(expected) {
+		t.Fatalf("expected %d rows, got: %v", len(expected), contents)
+	}
+	for i, row := range expected {
+		for j, v := range row {
+			if contents[i][j] != v {
+				t.Fatalf("expected %q at row %d column %d, got %q", v, i, j, contents[i][j])
+			}
+		}
+	}
+}
+
+func TestDocs(t *testing.T) {
+	// the extensions are registered as the connection is opened
+	if err := FixtureDatabase.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range schema.Tables() {
+		if table.Description == "" {
+			t.Errorf("table %s is not documented", table.Name)
+		}
+		if len(table.Columns) == 0 {
+			t.Errorf("table %s declares no columns", table.Name)
+		}
+
+		for _, arg := range table.Arguments {
+			var hidden bool
+			for _, c := range table.Columns {
+				hidden = hidden || c.Name == arg.Name && c.Hidden
+			}
+			if !hidden {
+				t.Errorf("argument %s of table %s is not a HIDDEN column", arg.Name, table.Name)
+			}
+		}
+	}
+
+	for _, fn := range schema.Functions() {
+		if fn.Description == "" {
+			t.Errorf("function %s is not documented", fn.Name)
+		}
+		if fn.NumArgs >= 0 && len(fn.Arguments) != fn.NumArgs {
+			t.Errorf("function %s takes %d arguments, but %d are documented", fn.Name, fn.NumArgs, len(fn.Arguments))
+		}
+	}
+}
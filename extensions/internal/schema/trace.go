@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/mergestat/mergestat-lite/extensions/trace"
+	"go.riyazali.net/sqlite"
+)
+
+// tracedModule reports the plans offered by the tables of a module, the plans SQLite filters them with
+// and the rows they produce to a tracer
+type tracedModule struct {
+	sqlite.Module
+	name   string
+	tracer *trace.Tracer
+}
+
+func (m *tracedModule) Connect(conn *sqlite.Conn, args []string, declare func(string) error) (sqlite.VirtualTable, error) {
+	var columns []Column
+	table, err := m.Module.Connect(conn, args, func(s string) error {
+		columns = parseDeclaration(s)
+		return declare(s)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTable{VirtualTable: table, name: m.name, columns: columns, tracer: m.tracer}, nil
+}
+
+type tracedTable struct {
+	sqlite.VirtualTable
+	name    string
+	columns []Column
+	tracer  *trace.Tracer
+}
+
+func (t *tracedTable) BestIndex(input *sqlite.IndexInfoInput) (*sqlite.IndexInfoOutput, error) {
+	output, err := t.VirtualTable.BestIndex(input)
+	if err != nil {
+		// tables reject plans with constraints they cannot use, which SQLite then does not consider
+		return output, err
+	}
+
+	var plan = &trace.Plan{
+		IndexNumber:     output.IndexNumber,
+		IndexString:     output.IndexString,
+		EstimatedCost:   output.EstimatedCost,
+		EstimatedRows:   output.EstimatedRows,
+		OrderByConsumed: output.OrderByConsumed,
+	}
+	for i, c := range input.Constraints {
+		var constraint = trace.Constraint{Column: fmt.Sprint(c.ColumnIndex), Op: opName(c.Op), Usable: c.Usable}
+		if c.ColumnIndex >= 0 && c.ColumnIndex < len(t.columns) {
+			constraint.Column = t.columns[c.ColumnIndex].Name
+		}
+		if i < len(output.ConstraintUsage) && output.ConstraintUsage[i] != nil {
+			constraint.Argv, constraint.Omit = output.ConstraintUsage[i].ArgvIndex, output.ConstraintUsage[i].Omit
+		}
+		plan.Constraints = append(plan.Constraints, constraint)
+	}
+	t.tracer.BestIndex(t.name, plan)
+
+	return output, nil
+}
+
+func (t *tracedTable) Open() (sqlite.VirtualCursor, error) {
+	cursor, err := t.VirtualTable.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &tracedCursor{VirtualCursor: cursor, table: t}, nil
+}
+
+type tracedCursor struct {
+	sqlite.VirtualCursor
+	table *tracedTable
+}
+
+func (c *tracedCursor) Filter(indexNumber int, indexString string, values ...sqlite.Value) error {
+	c.table.tracer.Filter(c.table.name, indexNumber, indexString)
+	if err := c.VirtualCursor.Filter(indexNumber, indexString, values...); err != nil {
+		return err
+	}
+	if !c.Eof() {
+		c.table.tracer.Row(c.table.name)
+	}
+	return nil
+}
+
+func (c *tracedCursor) Next() error {
+	if err := c.VirtualCursor.Next(); err != nil {
+		return err
+	}
+	if !c.Eof() {
+		c.table.tracer.Row(c.table.name)
+	}
+	return nil
+}
+
+// opName returns the SQL operator of a constraint
+func opName(op sqlite.ConstraintOp) string {
+	switch op {
+	case sqlite.INDEX_CONSTRAINT_EQ:
+		return "="
+	case sqlite.INDEX_CONSTRAINT_GT:
+		return ">"
+	case sqlite.INDEX_CONSTRAINT_GE:
+		return ">="
+	case sqlite.INDEX_CONSTRAINT_LT:
+		return "<"
+	case sqlite.INDEX_CONSTRAINT_LE:
+		return "<="
+	case sqlite.INDEX_CONSTRAINT_NE:
+		return "!="
+	case sqlite.INDEX_CONSTRAINT_MATCH:
+		return "MATCH"
+	case sqlite.INDEX_CONSTRAINT_LIKE:
+		return "LIKE"
+	case sqlite.INDEX_CONSTRAINT_GLOB:
+		return "GLOB"
+	case sqlite.INDEX_CONSTRAINT_REGEXP:
+		return "REGEXP"
+	case sqlite.INDEX_CONSTRAINT_IS:
+		return "IS"
+	case sqlite.INDEX_CONSTRAINT_ISNOT:
+		return "IS NOT"
+	case sqlite.INDEX_CONSTRAINT_ISNULL:
+		return "IS NULL"
+	case sqlite.INDEX_CONSTRAINT_ISNOTNULL:
+		return "IS NOT NULL"
+	case sqlite.INDEX_CONSTRAINT_LIMIT:
+		return "LIMIT"
+	case sqlite.INDEX_CONSTRAINT_OFFSET:
+		return "OFFSET"
+	}
+	return fmt.Sprintf("op %d", op)
+}
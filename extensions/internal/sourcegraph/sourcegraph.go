@@ -3,6 +3,7 @@ package sourcegraph
 import (
 	"context"
 
+	"github.com/mergestat/mergestat-lite/extensions/internal/schema"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -23,7 +24,8 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 			client := graphql.NewClient(sourcegraphUrl, httpClient)
 			return client
 		},
-		Logger: opt.Logger,
+		Logger:           opt.Logger,
+		StatementContext: opt.StatementContext,
 	}
 
 	if opt.SourcegraphClientGetter != nil {
@@ -41,7 +43,7 @@ func Register(ext *sqlite.ExtensionApi, opt *options.Options) (_ sqlite.ErrorCod
 
 	// register Sourcegraph tables
 	for name, mod := range modules {
-		if err = ext.CreateModule(name, mod); err != nil {
+		if err = schema.CreateModule(ext, opt, "sourcegraph", name, mod); err != nil {
 			return sqlite.SQLITE_ERROR, errors.Wrapf(err, "failed to register Sourcegraph %q module", name)
 		}
 	}
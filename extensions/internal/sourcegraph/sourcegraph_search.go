@@ -145,6 +145,7 @@ func fetchSearch(ctx context.Context, input *fetchSourcegraphOptions) (*searchRe
 
 type iterResults struct {
 	*Options
+	ctx     context.Context // of the statement the iterator is for
 	query   string
 	current int
 	results *searchResults
@@ -222,7 +223,7 @@ func (i *iterResults) Column(ctx vtab.Context, c int) error {
 func (i *iterResults) Next() (vtab.Row, error) {
 	var err error
 	if i.current == -1 {
-		i.results, err = fetchSearch(context.Background(), &fetchSourcegraphOptions{i.Client(), i.query})
+		i.results, err = fetchSearch(i.ctx, &fetchSourcegraphOptions{i.Client(), i.query})
 		if err != nil {
 			return nil, err
 		}
@@ -261,6 +262,6 @@ func NewSourcegraphSearchModule(opts *Options) sqlite.Module {
 			}
 		}
 		opts.Logger.Info().Msgf("running Sourcegraph search: %s", query)
-		return &iterResults{opts, query, -1, nil}, nil
+		return &iterResults{opts, opts.StatementContext(), query, -1, nil}, nil
 	})
 }
@@ -1,6 +1,8 @@
 package sourcegraph
 
 import (
+	"context"
+
 	"github.com/mergestat/mergestat-lite/extensions/services"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/graphql"
@@ -12,6 +14,9 @@ type Options struct {
 	RateLimiter *rate.Limiter
 	PerPage     int
 	Logger      *zerolog.Logger
+	// StatementContext returns the context of the statement being run, used for search requests,
+	// cancelling it aborts them
+	StatementContext func() context.Context
 }
 
 // GetSourcegraphTokenFromCtx looks up the sourcegraphToken key in the supplied context and returns it if set
@@ -5,7 +5,10 @@ import (
 	"net/http"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/mergestat/mergestat-lite/extensions/trace"
+	"github.com/mergestat/mergestat-lite/pkg/identities"
 	"github.com/rs/zerolog"
 	"github.com/shurcooL/githubv4"
 	"github.com/shurcooL/graphql"
@@ -21,10 +24,17 @@ type Options struct {
 	// Locator is how to fetch a repository
 	Locator services.RepoLocator
 
+	// RefLister is how to list the references of a remote repository without fetching it
+	RefLister services.RefLister
+
 	// ExtraFunctions is used to determine whether or not to register the extra utility functions
 	// bundled with this extension
 	ExtraFunctions bool
 
+	// Identities is used by the contributor identity functions (author_identity, author_org and is_bot)
+	// registered alongside the extra functions
+	Identities *identities.Identities
+
 	// GitHub set to true to register the GitHub tables/funcs
 	GitHub bool
 
@@ -57,6 +67,21 @@ type Options struct {
 
 	// Logger is a logger to pass along to the underlying extensions
 	Logger *zerolog.Logger
+
+	// BaseContext is the context the underlying extensions use for long-running or networked
+	// operations, such as cloning repositories, walking commit history, waiting on rate limiters
+	// and making API calls. Cancelling it aborts any of those operations that are in-flight.
+	// Each connection may also be bound to the context of its statements, see extensions.BindContext.
+	BaseContext context.Context
+
+	// StatementContext returns the context of the statement being run on the connection, which the underlying
+	// extensions take when they start a statement (such as when a table is filtered) and use in BaseContext's place.
+	// It is set for each connection by extensions.RegisterFn, and is BaseContext unless a context is bound to the
+	// connection (see extensions.BindContext).
+	StatementContext func() context.Context
+
+	// Tracer, if set, records the plans, filters and rows of the virtual tables (see mergestat explain)
+	Tracer *trace.Tracer
 }
 
 // OptionFn represents any function capable of customising or providing options
@@ -132,6 +157,26 @@ func WithRepoLocator(loc services.RepoLocator) OptionFn {
 	return func(o *Options) { o.Locator = loc }
 }
 
+// RefListerFn is an adapter type that adapts any function with compatible
+// signature to a RefLister instance.
+type RefListerFn func(ctx context.Context, url string) ([]*plumbing.Reference, error)
+
+func (fn RefListerFn) ListRefs(ctx context.Context, url string) ([]*plumbing.Reference, error) {
+	return fn(ctx, url)
+}
+
+// WithIdentities uses the provided identities to resolve
+// contributors to a canonical person and organization.
+func WithIdentities(ids *identities.Identities) OptionFn {
+	return func(o *Options) { o.Identities = ids }
+}
+
+// WithRefLister uses the provided lister implementation
+// for listing the references of remote repositories.
+func WithRefLister(lister services.RefLister) OptionFn {
+	return func(o *Options) { o.RefLister = lister }
+}
+
 // WithContextValue sets a value on the options context.
 // It will override any existing value set with the same key
 func WithContextValue(key, value string) OptionFn {
@@ -147,3 +192,13 @@ func WithContextValue(key, value string) OptionFn {
 func WithLogger(logger *zerolog.Logger) OptionFn {
 	return func(o *Options) { o.Logger = logger }
 }
+
+// WithBaseContext sets the context the underlying extensions use for cancellation
+func WithBaseContext(ctx context.Context) OptionFn {
+	return func(o *Options) { o.BaseContext = ctx }
+}
+
+// WithTracer sets a tracer recording the activity of the virtual tables
+func WithTracer(tracer *trace.Tracer) OptionFn {
+	return func(o *Options) { o.Tracer = tracer }
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// RefLister is a service that the virtual modules rely upon
+// to list the references of a remote repository, without cloning it.
+type RefLister interface {
+	// ListRefs returns the references advertised by the remote repository at url,
+	// including symbolic references (such as HEAD) when the remote advertises them.
+	ListRefs(ctx context.Context, url string) ([]*plumbing.Reference, error)
+}
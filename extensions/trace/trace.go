@@ -0,0 +1,181 @@
+// Package trace records what the virtual tables do while queries run: the plans they offered SQLite for
+// the constraints of a query (which constraints they accepted, with which index and at what cost),
+// the plans SQLite chose, the rows they produced and the repositories they opened.
+// A Tracer is passed to the extensions with options.WithTracer, and is safe for concurrent use.
+package trace
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+)
+
+// Constraint is a constraint of a query on a column of a virtual table, as passed to the table
+type Constraint struct {
+	Column string // name of the column, or its index if unknown
+	Op     string // such as =, > or LIKE
+	Usable bool   // whether SQLite can pass the value of the constraint to the table, in this plan
+
+	// Argv is the position of the value of the constraint among the arguments of the table (from 1),
+	// 0 if the table did not accept the constraint, in which case SQLite checks it on every row
+	Argv int
+	Omit bool // whether SQLite trusts the table to apply the constraint, and does not check it
+}
+
+// Plan is a plan offered by a virtual table for a set of constraints
+type Plan struct {
+	Constraints     []Constraint
+	IndexNumber     int
+	IndexString     string
+	EstimatedCost   float64
+	EstimatedRows   int64
+	OrderByConsumed bool
+
+	Chosen int // number of times SQLite used the plan to filter the table
+}
+
+// accepted reports whether the table accepted any constraint in the plan
+func (p *Plan) accepted() bool {
+	for _, c := range p.Constraints {
+		if c.Argv > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Plan) equal(o *Plan) bool {
+	if p.IndexNumber != o.IndexNumber || p.IndexString != o.IndexString || p.EstimatedCost != o.EstimatedCost ||
+		p.EstimatedRows != o.EstimatedRows || p.OrderByConsumed != o.OrderByConsumed || len(p.Constraints) != len(o.Constraints) {
+		return false
+	}
+	for i := range p.Constraints {
+		if p.Constraints[i] != o.Constraints[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Table is the activity of a virtual table (by module name)
+type Table struct {
+	Name    string
+	Plans   []*Plan // distinct plans offered, in order
+	Filters int     // number of times the table was filtered (scanned)
+	Rows    int64   // number of rows produced
+}
+
+// Repo is a repository opened by the locator
+type Repo struct {
+	Path string
+	Err  error
+}
+
+// Tracer records the activity of the virtual tables
+type Tracer struct {
+	mu     sync.Mutex
+	tables []*Table
+	repos  []Repo
+}
+
+// New returns a new Tracer
+func New() *Tracer { return &Tracer{} }
+
+func (t *Tracer) table(name string) *Table {
+	for _, table := range t.tables {
+		if table.Name == name {
+			return table
+		}
+	}
+	var table = &Table{Name: name}
+	t.tables = append(t.tables, table)
+	return table
+}
+
+// BestIndex records plan as offered by table. Plans identical to one already offered are only recorded once.
+func (t *Tracer) BestIndex(table string, plan *Plan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var tab = t.table(table)
+	for _, p := range tab.Plans {
+		if p.equal(plan) {
+			return
+		}
+	}
+	tab.Plans = append(tab.Plans, plan)
+}
+
+// Filter records that SQLite filtered table with the plan of the given index number and string
+func (t *Tracer) Filter(table string, indexNumber int, indexString string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var tab = t.table(table)
+	tab.Filters++
+
+	// several plans may share an index, in which case the one accepting constraints is the likeliest
+	var chosen *Plan
+	for _, p := range tab.Plans {
+		if p.IndexNumber == indexNumber && p.IndexString == indexString && (chosen == nil || !chosen.accepted()) {
+			chosen = p
+		}
+	}
+	if chosen != nil {
+		chosen.Chosen++
+	}
+}
+
+// Row records that table produced a row
+func (t *Tracer) Row(table string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.table(table).Rows++
+}
+
+// Locator returns a locator that records the repositories opened with rl
+func (t *Tracer) Locator(rl services.RepoLocator) services.RepoLocator {
+	return &locator{tracer: t, rl: rl}
+}
+
+type locator struct {
+	tracer *Tracer
+	rl     services.RepoLocator
+}
+
+func (l *locator) Open(ctx context.Context, path string) (*git.Repository, error) {
+	repo, err := l.rl.Open(ctx, path)
+
+	l.tracer.mu.Lock()
+	defer l.tracer.mu.Unlock()
+	l.tracer.repos = append(l.tracer.repos, Repo{Path: path, Err: err})
+
+	return repo, err
+}
+
+// Tables returns (a copy of) the tables that were used, in the order they were first planned
+func (t *Tracer) Tables() []*Table {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var tables = make([]*Table, len(t.tables))
+	for i, table := range t.tables {
+		var copied = *table
+		copied.Plans = make([]*Plan, len(table.Plans))
+		for j, p := range table.Plans {
+			var plan = *p
+			copied.Plans[j] = &plan
+		}
+		tables[i] = &copied
+	}
+	return tables
+}
+
+// Repos returns the repositories that were opened (or failed to open), in order
+func (t *Tracer) Repos() []Repo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Repo(nil), t.repos...)
+}
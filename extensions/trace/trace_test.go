@@ -0,0 +1,71 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestTracer(t *testing.T) {
+	var tracer = New()
+
+	var scan = &Plan{IndexNumber: 0, EstimatedCost: 1000}
+	var byHash = &Plan{
+		Constraints:   []Constraint{{Column: "hash", Op: "=", Usable: true, Argv: 1, Omit: true}},
+		IndexNumber:   2,
+		EstimatedCost: 1,
+		EstimatedRows: 1,
+	}
+	tracer.BestIndex("commits", scan)
+	tracer.BestIndex("commits", byHash)
+	tracer.BestIndex("commits", &Plan{IndexNumber: 0, EstimatedCost: 1000}) // same as scan
+	tracer.BestIndex("refs", &Plan{})
+
+	tracer.Filter("commits", 2, "")
+	tracer.Row("commits")
+	tracer.Filter("refs", 0, "")
+
+	var tables = tracer.Tables()
+	if len(tables) != 2 || tables[0].Name != "commits" || tables[1].Name != "refs" {
+		t.Fatalf("unexpected tables: %v", tables)
+	}
+
+	var commits = tables[0]
+	if len(commits.Plans) != 2 {
+		t.Fatalf("expected 2 distinct plans, got %d", len(commits.Plans))
+	}
+	if commits.Plans[0].Chosen != 0 || commits.Plans[1].Chosen != 1 {
+		t.Fatalf("expected the hash plan to be chosen, got %d and %d", commits.Plans[0].Chosen, commits.Plans[1].Chosen)
+	}
+	if commits.Filters != 1 || commits.Rows != 1 {
+		t.Fatalf("expected 1 filter and 1 row, got %d and %d", commits.Filters, commits.Rows)
+	}
+
+	// the tables returned are copies
+	commits.Plans[1].Chosen = 10
+	if tracer.Tables()[0].Plans[1].Chosen != 1 {
+		t.Fatal("expected Tables to return a copy")
+	}
+}
+
+type failingLocator struct{}
+
+func (failingLocator) Open(context.Context, string) (*git.Repository, error) {
+	return nil, errors.New("not found")
+}
+
+func TestLocator(t *testing.T) {
+	var tracer = New()
+	var rl = tracer.Locator(failingLocator{})
+
+	if _, err := rl.Open(context.Background(), "some/repo"); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var repos = tracer.Repos()
+	if len(repos) != 1 || repos[0].Path != "some/repo" || repos[0].Err == nil {
+		t.Fatalf("unexpected repos: %v", repos)
+	}
+}
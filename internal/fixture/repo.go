@@ -0,0 +1,96 @@
+// Package fixture creates git repositories with known contents for tests
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Repo is a git repository created on disk for a test, with commits made by Commit
+type Repo struct {
+	t testing.TB
+
+	// Dir is the path of the repository (and its worktree)
+	Dir  string
+	Repo *git.Repository
+
+	// Author is the author (and committer) of the next commits, its time is that of the previous commit plus an hour
+	Author object.Signature
+}
+
+// NewRepo initializes a repository in a temporary directory of t
+func NewRepo(t testing.TB) *Repo {
+	t.Helper()
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("failed to initialize fixture repository: %v", err)
+	}
+
+	return &Repo{t: t, Dir: dir, Repo: repo, Author: object.Signature{
+		Name:  "Jane Doe",
+		Email: "jane@example.com",
+		When:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+}
+
+// Commit writes files (by path, relative to the root of the repository), removes the removed paths,
+// and commits them with message. It returns the hash of the commit.
+func (r *Repo) Commit(message string, files map[string]string, removed ...string) string {
+	r.t.Helper()
+	return r.commit(message, files, removed, nil)
+}
+
+// Merge commits files as Commit does, with the commit with the hash other as its second parent
+// (the first being HEAD). It returns the hash of the merge commit.
+func (r *Repo) Merge(message, other string, files map[string]string) string {
+	r.t.Helper()
+
+	head, err := r.Repo.Head()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return r.commit(message, files, nil, []plumbing.Hash{head.Hash(), plumbing.NewHash(other)})
+}
+
+func (r *Repo) commit(message string, files map[string]string, removed []string, parents []plumbing.Hash) string {
+	r.t.Helper()
+
+	wt, err := r.Repo.Worktree()
+	if err != nil {
+		r.t.Fatal(err)
+	}
+
+	for path, contents := range files {
+		p := filepath.Join(r.Dir, filepath.FromSlash(path))
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			r.t.Fatal(err)
+		}
+		if err = os.WriteFile(p, []byte(contents), 0644); err != nil {
+			r.t.Fatal(err)
+		}
+		if _, err = wt.Add(path); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+	for _, path := range removed {
+		if _, err = wt.Remove(path); err != nil {
+			r.t.Fatal(err)
+		}
+	}
+
+	r.Author.When = r.Author.When.Add(time.Hour)
+	var author = r.Author
+	hash, err := wt.Commit(message, &git.CommitOptions{Author: &author, Committer: &author, Parents: parents})
+	if err != nil {
+		r.t.Fatalf("failed to commit to fixture repository: %v", err)
+	}
+	return hash.String()
+}
@@ -0,0 +1,275 @@
+// Package config loads the configuration of mergestat, which is layered: the global config file
+// (~/.config/mergestat/config.yaml, or under $XDG_CONFIG_HOME), then the .mergestat/config.yaml file of
+// the repository being queried, then environment variables. Each layer overrides the values set by the
+// previous ones, and command line flags override them all. The config file of a repository can only set
+// format, extensions and timeout (see RepoKeys). A config file looks like:
+//
+//	clone_dir: /var/cache/mergestat
+//	format: json
+//	identities: ~/identities.yaml
+//	extensions: [github, npm]
+//	github:
+//	  token: ghp_xxx
+//	  per_page: 50
+//	hosts:
+//	  git.example.com:
+//	    username: ci
+//	    password: xxx
+//
+// Extensions lists the optional extensions to register (github, sourcegraph and npm), all of them if unset.
+// Hosts holds the credentials used to clone over HTTPS, by host (the GitHub token is used for other hosts).
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// Extensions are the optional extensions that can be enabled
+var Extensions = []string{"github", "sourcegraph", "npm"}
+
+// Config is the configuration of mergestat
+type Config struct {
+	// CloneDir is where remote repositories are cloned, a temporary directory if empty
+	CloneDir string `json:"clone_dir"`
+
+	// Format is the default output format of queries
+	Format string `json:"format"`
+
+	// Identities is the path to a file mapping contributors to people, orgs and bots (see pkg/identities)
+	Identities string `json:"identities"`
+
+	// SkipMailmap skips the usage of the mailmap when querying commits, blame and tags
+	SkipMailmap bool `json:"skip_mailmap"`
+
+	// InsecureSkipTLS skips the verification of certificates when cloning (GIT_SSL_NO_VERIFY)
+	InsecureSkipTLS bool `json:"insecure_skip_tls"`
+
+	// Timeout is the maximum duration of the execution of a command, such as 30s or 5m
+	Timeout string `json:"timeout"`
+
+	// Extensions are the optional extensions to register, all of them if nil
+	Extensions []string `json:"extensions"`
+
+	GitHub      GitHub      `json:"github"`
+	Sourcegraph Sourcegraph `json:"sourcegraph"`
+	Postgres    Postgres    `json:"postgres"`
+
+	// Hosts are the credentials to clone repositories with over HTTPS, by host
+	Hosts map[string]Credentials `json:"hosts"`
+
+	// Files are the config files that were read, in order
+	Files []string `json:"-"`
+}
+
+// GitHub configures the GitHub tables and functions
+type GitHub struct {
+	Token     string `json:"token"`      // GITHUB_TOKEN
+	PerPage   int    `json:"per_page"`   // GITHUB_PER_PAGE
+	RateLimit int    `json:"rate_limit"` // GITHUB_RATE_LIMIT, in requests per second
+}
+
+// Sourcegraph configures the Sourcegraph tables and functions
+type Sourcegraph struct {
+	Token string `json:"token"` // SOURCEGRAPH_TOKEN
+}
+
+// Postgres configures the pgsync command
+type Postgres struct {
+	Enabled    bool   `json:"enabled"`    // PGSYNC, whether the pgsync command is available
+	Connection string `json:"connection"` // POSTGRES_CONNECTION
+	Preamble   string `json:"preamble"`   // PGSYNC_PREAMBLE, a statement run before syncing
+}
+
+// Credentials are a username and password (or token) to authenticate with over HTTPS
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RepoKeys are the keys that the config file of a repository can set. As repositories that are queried are not
+// necessarily trusted, it cannot set secrets, credentials, SQL to run or paths, which only the global config file,
+// environment variables and flags can.
+var RepoKeys = []string{"format", "extensions", "timeout"}
+
+// GlobalFile returns the path of the global config file, empty if the home directory of the user is unknown
+func GlobalFile() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome == "" {
+		return ""
+	}
+	return filepath.Join(configHome, "mergestat", "config.yaml")
+}
+
+// RepoFile returns the path of the config file of the repository at repoPath
+func RepoFile(repoPath string) string {
+	return filepath.Join(repoPath, ".mergestat", "config.yaml")
+}
+
+// Load reads the global config file and the config file of the repository at repoPath (if not empty) that exist,
+// and applies the environment variables on top of them. The config file of the repository only sets RepoKeys.
+func Load(repoPath string) (*Config, error) {
+	var cfg = &Config{}
+	if file := GlobalFile(); file != "" {
+		if err := cfg.readFile(file, nil); err != nil {
+			return nil, err
+		}
+	}
+	cfg.CloneDir, cfg.Identities = expandHome(cfg.CloneDir), expandHome(cfg.Identities)
+
+	if repoPath != "" {
+		if err := cfg.readFile(RepoFile(repoPath), RepoKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readFile reads the config file at path, if it exists, into cfg. Its values override the values read so far,
+// and only those. If keys is not nil, the file can only set those keys.
+func (cfg *Config) readFile(path string, keys []string) error {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "could not read config file")
+	}
+
+	if keys != nil {
+		var values map[string]interface{}
+		if err = yaml.Unmarshal(b, &values); err != nil {
+			return errors.Wrapf(err, "could not parse config file %s", path)
+		}
+		for key := range values {
+			var allowed bool
+			for _, k := range keys {
+				allowed = allowed || k == key
+			}
+			if !allowed {
+				return errors.Errorf("config file %s cannot set %s, only %s (other settings go in the global config file)", path, key, strings.Join(keys, ", "))
+			}
+		}
+	}
+
+	if err = yaml.Unmarshal(b, cfg); err != nil {
+		return errors.Wrapf(err, "could not parse config file %s", path)
+	}
+	cfg.Files = append(cfg.Files, path)
+	return nil
+}
+
+// expandHome replaces the leading ~ of path with the home directory of the user
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// ApplyEnv overrides the values of cfg with those of the environment variables that are set (and not empty), as looked up with lookup
+func (cfg *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for name, dest := range map[string]*string{
+		"GITHUB_TOKEN":        &cfg.GitHub.Token,
+		"SOURCEGRAPH_TOKEN":   &cfg.Sourcegraph.Token,
+		"POSTGRES_CONNECTION": &cfg.Postgres.Connection,
+		"PGSYNC_PREAMBLE":     &cfg.Postgres.Preamble,
+	} {
+		if v, _ := lookup(name); v != "" {
+			*dest = v
+		}
+	}
+
+	for name, dest := range map[string]*int{
+		"GITHUB_PER_PAGE":   &cfg.GitHub.PerPage,
+		"GITHUB_RATE_LIMIT": &cfg.GitHub.RateLimit,
+	} {
+		if v, _ := lookup(name); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.Wrapf(err, "invalid value of %s", name)
+			}
+			*dest = i
+		}
+	}
+
+	// these are enabled when set to anything
+	if v, _ := lookup("GIT_SSL_NO_VERIFY"); v != "" {
+		cfg.InsecureSkipTLS = true
+	}
+	if v, _ := lookup("PGSYNC"); v != "" {
+		cfg.Postgres.Enabled = true
+	}
+
+	return nil
+}
+
+// Validate reports values of cfg that are not valid
+func (cfg *Config) Validate() error {
+	for _, ext := range cfg.Extensions {
+		var known bool
+		for _, e := range Extensions {
+			known = known || e == ext
+		}
+		if !known {
+			return errors.Errorf("unknown extension %s, expected one of %s", ext, strings.Join(Extensions, ", "))
+		}
+	}
+	return nil
+}
+
+// ExtensionEnabled reports whether the optional extension called name is to be registered
+func (cfg *Config) ExtensionEnabled(name string) bool {
+	if cfg.Extensions == nil {
+		return true
+	}
+	for _, ext := range cfg.Extensions {
+		if ext == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted returns a copy of cfg with its tokens, passwords and connection string replaced, to be displayed
+func (cfg *Config) Redacted() *Config {
+	var redacted = *cfg
+
+	redact := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return "<redacted>"
+	}
+	redacted.GitHub.Token = redact(cfg.GitHub.Token)
+	redacted.Sourcegraph.Token = redact(cfg.Sourcegraph.Token)
+	redacted.Postgres.Connection = redact(cfg.Postgres.Connection)
+
+	if cfg.Hosts != nil {
+		redacted.Hosts = make(map[string]Credentials, len(cfg.Hosts))
+		for host, creds := range cfg.Hosts {
+			redacted.Hosts[host] = Credentials{Username: creds.Username, Password: redact(creds.Password)}
+		}
+	}
+	return &redacted
+}
@@ -0,0 +1,180 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/config"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	configHome, repoPath := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_PER_PAGE", "")
+	t.Setenv("SOURCEGRAPH_TOKEN", "from-env")
+
+	writeFile(t, filepath.Join(configHome, "mergestat", "config.yaml"), `
+format: json
+clone_dir: /tmp/clones
+github:
+  token: global-token
+  per_page: 50
+hosts:
+  git.example.com:
+    username: ci
+    password: secret
+`)
+	writeFile(t, filepath.Join(repoPath, ".mergestat", "config.yaml"), `
+format: csv
+extensions: [github]
+timeout: 5m
+`)
+
+	cfg, err := config.Load(repoPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cfg.Files) != 2 {
+		t.Fatalf("expected both config files to be read, got: %v", cfg.Files)
+	}
+	if cfg.Format != "csv" || cfg.Timeout != "5m" || cfg.CloneDir != "/tmp/clones" {
+		t.Errorf("expected the repository config to override the global one, got format %q, timeout %q and clone dir %q", cfg.Format, cfg.Timeout, cfg.CloneDir)
+	}
+	if cfg.GitHub.Token != "global-token" || cfg.GitHub.PerPage != 50 {
+		t.Errorf("unexpected GitHub config: %+v", cfg.GitHub)
+	}
+	if cfg.Sourcegraph.Token != "from-env" {
+		t.Errorf("expected the env to override the config files, got: %q", cfg.Sourcegraph.Token)
+	}
+	if cfg.Hosts["git.example.com"].Password != "secret" {
+		t.Errorf("unexpected hosts: %+v", cfg.Hosts)
+	}
+	if !cfg.ExtensionEnabled("github") || cfg.ExtensionEnabled("npm") {
+		t.Errorf("expected only the github extension to be enabled, got: %v", cfg.Extensions)
+	}
+
+	writeFile(t, filepath.Join(repoPath, ".mergestat", "config.yaml"), "extensions: [gitlab]")
+	if _, err = config.Load(repoPath); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
+
+func TestLoadRepoKeys(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("POSTGRES_CONNECTION", "")
+	t.Setenv("PGSYNC_PREAMBLE", "")
+
+	writeFile(t, filepath.Join(configHome, "mergestat", "config.yaml"), `
+github:
+  token: global-token
+postgres:
+  connection: postgres://localhost/mergestat
+`)
+
+	// a repository cannot set secrets, credentials, SQL or paths, only the global config file and env can
+	for _, contents := range []string{
+		"github: {token: repo-token}",
+		"sourcegraph: {token: repo-token}",
+		"postgres: {preamble: DROP TABLE commits}",
+		"postgres: {connection: postgres://attacker.example.com/db}",
+		"hosts: {git.example.com: {username: ci, password: xxx}}",
+		"identities: /etc/passwd",
+		"clone_dir: /tmp/elsewhere",
+		"format: csv\ninsecure_skip_tls: true",
+	} {
+		repoPath := t.TempDir()
+		writeFile(t, filepath.Join(repoPath, ".mergestat", "config.yaml"), contents)
+
+		if _, err := config.Load(repoPath); err == nil {
+			t.Errorf("expected an error for a repository config file with %q", contents)
+		}
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHub.Token != "global-token" || cfg.Postgres.Connection != "postgres://localhost/mergestat" {
+		t.Errorf("expected the global config to be read, got: %+v", cfg)
+	}
+
+	t.Setenv("PGSYNC_PREAMBLE", "SELECT 1")
+	if cfg, err = config.Load(""); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Postgres.Preamble != "SELECT 1" {
+		t.Errorf("expected the preamble to be read from the env, got: %q", cfg.Postgres.Preamble)
+	}
+}
+
+func TestLoadMalformed(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	writeFile(t, filepath.Join(configHome, "mergestat", "config.yaml"), "format: [json")
+	if _, err := config.Load(""); err == nil {
+		t.Error("expected an error for a malformed global config file")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_TOKEN":      "token",
+		"GITHUB_RATE_LIMIT": "2",
+		"GIT_SSL_NO_VERIFY": "1",
+		"PGSYNC_PREAMBLE":   "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	cfg := &config.Config{Postgres: config.Postgres{Preamble: "SET search_path TO mergestat"}}
+	if err := cfg.ApplyEnv(lookup); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GitHub.Token != "token" || cfg.GitHub.RateLimit != 2 || !cfg.InsecureSkipTLS {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Postgres.Preamble != "SET search_path TO mergestat" || cfg.Postgres.Enabled {
+		t.Errorf("expected empty and unset env vars to be ignored, got: %+v", cfg.Postgres)
+	}
+
+	env["GITHUB_PER_PAGE"] = "many"
+	if err := cfg.ApplyEnv(lookup); err == nil {
+		t.Error("expected an error for an invalid integer")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &config.Config{
+		GitHub: config.GitHub{Token: "token"},
+		Hosts:  map[string]config.Credentials{"git.example.com": {Username: "ci", Password: "secret"}},
+	}
+
+	redacted := cfg.Redacted()
+	if redacted.GitHub.Token != "<redacted>" || redacted.Sourcegraph.Token != "" {
+		t.Errorf("unexpected tokens: %q, %q", redacted.GitHub.Token, redacted.Sourcegraph.Token)
+	}
+	if creds := redacted.Hosts["git.example.com"]; creds.Username != "ci" || creds.Password != "<redacted>" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+	if cfg.GitHub.Token != "token" || cfg.Hosts["git.example.com"].Password != "secret" {
+		t.Error("expected the config to be left unchanged")
+	}
+}
@@ -0,0 +1,100 @@
+package display
+
+import (
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+)
+
+// arrowDisplay writes rows in the arrow IPC streaming format, as a schema followed by a record batch per batch of
+// rows (see writeColumnar). Fields are nullable, and buffers are uncompressed.
+func arrowDisplay(rows *sql.Rows, w io.Writer) error {
+	return writeColumnar(rows, &arrowWriter{w: w})
+}
+
+type arrowWriter struct {
+	w      io.Writer
+	schema *arrow.Schema
+	writer *ipc.Writer
+}
+
+func (a *arrowWriter) begin(fields []columnarField) error {
+	a.schema = arrowSchema(fields)
+	a.writer = ipc.NewWriter(a.w, ipc.WithSchema(a.schema))
+	return nil
+}
+
+func (a *arrowWriter) writeBatch(columns [][]interface{}, n int) error {
+	record := arrowRecord(a.schema, columns, n)
+	defer record.Release()
+	return a.writer.Write(record)
+}
+
+func (a *arrowWriter) end() error {
+	// the schema is written with the first record batch, or when closing if there are none
+	return a.writer.Close()
+}
+
+// arrowSchema returns the arrow schema of fields, where all fields are nullable
+func arrowSchema(fields []columnarField) *arrow.Schema {
+	var arrowFields = make([]arrow.Field, len(fields))
+	for i, field := range fields {
+		arrowFields[i] = arrow.Field{Name: field.name, Type: arrowType(field.kind), Nullable: true}
+	}
+	return arrow.NewSchema(arrowFields, nil)
+}
+
+// arrowType returns the arrow type of the values of a column of the given kind
+func arrowType(kind columnKind) arrow.DataType {
+	switch kind {
+	case kindBinary:
+		return arrow.BinaryTypes.Binary
+	case kindInt64:
+		return arrow.PrimitiveTypes.Int64
+	case kindFloat64:
+		return arrow.PrimitiveTypes.Float64
+	case kindBool:
+		return arrow.FixedWidthTypes.Boolean
+	case kindTimestamp:
+		return &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}
+	}
+	return arrow.BinaryTypes.String
+}
+
+// arrowRecord returns a record of n rows of the given schema, with the values of a batch (see columnarWriter)
+func arrowRecord(schema *arrow.Schema, columns [][]interface{}, n int) arrow.Record {
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+
+	for i, values := range columns {
+		field := b.Field(i)
+		field.Reserve(n)
+		for _, value := range values {
+			if value == nil {
+				field.AppendNull()
+				continue
+			}
+			switch f := field.(type) {
+			case *array.StringBuilder:
+				f.Append(value.(string))
+			case *array.BinaryBuilder:
+				f.Append(value.([]byte))
+			case *array.Int64Builder:
+				f.Append(value.(int64))
+			case *array.Float64Builder:
+				f.Append(value.(float64))
+			case *array.BooleanBuilder:
+				f.Append(value.(bool))
+			case *array.TimestampBuilder:
+				f.Append(arrow.Timestamp(value.(time.Time).UnixMicro()))
+			}
+		}
+	}
+
+	return b.NewRecord()
+}
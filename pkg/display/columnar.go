@@ -0,0 +1,307 @@
+package display
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// columnKind is the type of the values of a column, in columnar formats (parquet and arrow)
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindBinary
+	kindInt64
+	kindFloat64
+	kindBool
+	kindTimestamp // in microseconds since the epoch, UTC
+)
+
+func (k columnKind) String() string {
+	return [...]string{"string", "binary", "int64", "float64", "bool", "timestamp"}[k]
+}
+
+const (
+	batchRows  = 64 * 1024 // maximum number of rows of a row group (parquet) or record batch (arrow)
+	batchBytes = 64 << 20  // approximate maximum size of a row group or record batch
+)
+
+// columnarField is a column of the schema of a columnar format
+type columnarField struct {
+	name     string
+	kind     columnKind
+	inferred bool // from the values of the first batch, as the column has no declared type
+}
+
+// columnarWriter writes rows in a columnar format, one batch of rows at a time (see writeColumnar)
+type columnarWriter interface {
+	// begin starts the output, once the schema is known
+	begin(fields []columnarField) error
+
+	// writeBatch writes n rows, where columns[i][j] is the value of field i in row j, nil if NULL.
+	// Values are of the Go type of the kind of the field: string, []byte, int64, float64, bool or time.Time.
+	writeBatch(columns [][]interface{}, n int) error
+
+	// end completes the output
+	end() error
+}
+
+// writeColumnar reads rows in batches, and writes them with w. The kind of each column is derived from its declared
+// type, following the affinity rules of SQLite, or if it has none (such as expressions) from all the values of the
+// first batch (see kindOfValues). Values that cannot be converted to the kind of their column are an error,
+// as the schema cannot change once the first batch is written.
+func writeColumnar(rows *sql.Rows, w columnarWriter) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	types, err := declaredTypes(rows)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+
+	var fields []columnarField
+	var batch = make([][]interface{}, len(columns))
+	var n, size int
+
+	flush := func() error {
+		if fields == nil {
+			fields = columnarFields(columns, types, batch)
+			if err := w.begin(fields); err != nil {
+				return err
+			}
+		}
+		if n == 0 {
+			return nil
+		}
+
+		for i, field := range fields {
+			for j, value := range batch[i] {
+				var err error
+				if batch[i][j], err = convertValue(value, field.kind); err != nil {
+					if field.inferred {
+						return fmt.Errorf("column %s: %v, as its type is inferred from the first rows (CAST it to set it)", field.name, err)
+					}
+					return fmt.Errorf("column %s: %v", field.name, err)
+				}
+			}
+		}
+		if err := w.writeBatch(batch, n); err != nil {
+			return err
+		}
+
+		for i := range batch {
+			batch[i] = batch[i][:0]
+		}
+		n, size = 0, 0
+		return nil
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(values...); err != nil {
+			return err
+		}
+
+		for i := range columns {
+			value := *(values[i].(*interface{}))
+			if b, ok := value.([]byte); ok {
+				// the driver may reuse the memory of blobs on the next call to Next
+				value = append([]byte(nil), b...)
+				size += len(b)
+			} else if s, ok := value.(string); ok {
+				size += len(s)
+			}
+			batch[i] = append(batch[i], value)
+			size += 8
+		}
+		n++
+
+		if n >= batchRows || size >= batchBytes {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if err = flush(); err != nil {
+		return err
+	}
+	return w.end()
+}
+
+// columnarFields returns the schema of columns, see writeColumnar
+func columnarFields(columns, declaredTypes []string, batch [][]interface{}) []columnarField {
+	fields := make([]columnarField, len(columns))
+	for i, column := range columns {
+		kind, ok := kindOfDeclaredType(declaredTypes[i])
+		if !ok {
+			kind = kindOfValues(batch[i])
+		}
+		fields[i] = columnarField{name: column, kind: kind, inferred: !ok}
+	}
+	return fields
+}
+
+// kindOfDeclaredType returns the kind of a column with the given declared type, and false if it cannot be told
+// from the declared type (as for NUMERIC columns, which may hold integers or reals)
+func kindOfDeclaredType(declaredType string) (columnKind, bool) {
+	switch t := declaredType; {
+	case t == "":
+		return 0, false
+	case t == "BOOLEAN" || t == "BOOL":
+		return kindBool, true
+	case t == "DATETIME" || t == "TIMESTAMP" || t == "DATE":
+		return kindTimestamp, true
+	case strings.Contains(t, "INT"):
+		return kindInt64, true
+	case strings.Contains(t, "CHAR") || strings.Contains(t, "CLOB") || strings.Contains(t, "TEXT") || t == "JSON":
+		return kindString, true
+	case strings.Contains(t, "BLOB"):
+		return kindBinary, true
+	case strings.Contains(t, "REAL") || strings.Contains(t, "FLOA") || strings.Contains(t, "DOUB"):
+		return kindFloat64, true
+	}
+	return 0, false
+}
+
+// kindOfValues returns the kind of the values scanned from a column without a declared type: the kind they all have,
+// float64 if they mix integers and reals, or string if they mix any other kinds (or are all NULL)
+func kindOfValues(values []interface{}) columnKind {
+	var kind, seen = kindString, false
+	for _, value := range values {
+		if value == nil {
+			continue
+		}
+		switch k := kindOfValue(value); {
+		case !seen:
+			kind, seen = k, true
+		case k == kind:
+		case (k == kindInt64 || k == kindFloat64) && (kind == kindInt64 || kind == kindFloat64):
+			kind = kindFloat64
+		default:
+			return kindString
+		}
+	}
+	return kind
+}
+
+// kindOfValue returns the kind of a value scanned from a column without a declared type
+func kindOfValue(value interface{}) columnKind {
+	switch value.(type) {
+	case int64:
+		return kindInt64
+	case float64:
+		return kindFloat64
+	case bool:
+		return kindBool
+	case time.Time:
+		return kindTimestamp
+	case []byte:
+		return kindBinary
+	}
+	return kindString
+}
+
+// timestampLayouts are the layouts of the text values of timestamp columns, as accepted by the SQLite date functions
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// convertValue converts a value scanned from the database to the Go type of kind (see columnarWriter)
+func convertValue(value interface{}, kind columnKind) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch kind {
+	case kindString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	case kindBinary:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+	case kindInt64:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case kindFloat64:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+	case kindBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case kindTimestamp:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			for _, layout := range timestampLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t, nil
+				}
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cannot convert %v (%T) to %s", value, value, kind)
+}
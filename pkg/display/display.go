@@ -2,57 +2,97 @@ package display
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"encoding/csv"
 	"encoding/json"
 	"io"
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
 
 	"github.com/jedib0t/go-pretty/table"
 	"golang.org/x/term"
 )
 
+// Options configure how rows are written
+type Options struct {
+	// Interactive is set when rows are written to a terminal, tables are then not wrapped to its width
+	Interactive bool
+
+	// Template is the text/template executed for each row by the template format
+	Template string
+}
+
+// Writer writes rows to w, in a format registered with Register
+type Writer func(rows *sql.Rows, w io.Writer, opts *Options) error
+
+var (
+	writersMu sync.RWMutex
+	writers   = make(map[string]Writer)
+	formats   []string // names of the registered writers, in the order they were registered
+)
+
+func init() {
+	Register("table", func(rows *sql.Rows, w io.Writer, opts *Options) error {
+		return tableDisplay(rows, w, opts.Interactive)
+	})
+	Register("single", func(rows *sql.Rows, w io.Writer, _ *Options) error { return single(rows, w) })
+	Register("csv", func(rows *sql.Rows, w io.Writer, _ *Options) error { return csvDisplay(rows, ',', false, w) })
+	Register("csv-noheader", func(rows *sql.Rows, w io.Writer, _ *Options) error { return csvDisplay(rows, ',', true, w) })
+	Register("tsv", func(rows *sql.Rows, w io.Writer, _ *Options) error { return csvDisplay(rows, '\t', false, w) })
+	Register("tsv-noheader", func(rows *sql.Rows, w io.Writer, _ *Options) error { return csvDisplay(rows, '\t', true, w) })
+	Register("json", func(rows *sql.Rows, w io.Writer, _ *Options) error { return jsonDisplay(rows, w) })
+	Register("ndjson", func(rows *sql.Rows, w io.Writer, _ *Options) error { return ndjsonDisplay(rows, w) })
+	Register("markdown", func(rows *sql.Rows, w io.Writer, _ *Options) error { return markdownDisplay(rows, w) })
+	Register("html", func(rows *sql.Rows, w io.Writer, _ *Options) error { return htmlDisplay(rows, w) })
+	Register("template", templateDisplay)
+	Register("parquet", func(rows *sql.Rows, w io.Writer, _ *Options) error { return parquetDisplay(rows, w) })
+	Register("arrow", func(rows *sql.Rows, w io.Writer, _ *Options) error { return arrowDisplay(rows, w) })
+}
+
+// Register makes writer available as the output format name, replacing the writer already registered
+// under that name, if any. Library users can register their own formats, typically from an init function.
+func Register(name string, writer Writer) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+
+	if writer == nil {
+		panic("display: Register writer is nil")
+	}
+	if _, exists := writers[name]; !exists {
+		formats = append(formats, name)
+	}
+	writers[name] = writer
+}
+
+// Formats returns the names of the registered output formats, in the order they were registered
+func Formats() []string {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+
+	return append([]string(nil), formats...)
+}
+
+// WriteTo writes rows to w in format (see Write). Tables are not wrapped to the width of the terminal when interactive is set.
 func WriteTo(rows *sql.Rows, w io.Writer, format string, interactive bool) error {
-	switch format {
-	case "single":
-		err := single(rows, w)
-		if err != nil {
-			return err
-		}
-	case "csv":
-		err := csvDisplay(rows, ',', false, w)
-		if err != nil {
-			return err
-		}
-	case "csv-noheader":
-		err := csvDisplay(rows, ',', true, w)
-		if err != nil {
-			return err
-		}
-	case "tsv":
-		err := csvDisplay(rows, '\t', false, w)
-		if err != nil {
-			return err
-		}
-	case "tsv-noheader":
-		err := csvDisplay(rows, '\t', true, w)
-		if err != nil {
-			return err
-		}
-	case "json":
-		err := jsonDisplay(rows, w)
-		if err != nil {
-			return err
-		}
-	case "ndjson":
-		err := ndjsonDisplay(rows, w)
-		if err != nil {
-			return err
-		}
-	default:
-		err := tableDisplay(rows, w, interactive)
-		if err != nil {
-			return err
-		}
+	return Write(rows, w, format, &Options{Interactive: interactive})
+}
 
+// Write writes rows to w with the writer registered as format, or as a table if no writer is registered under that name
+func Write(rows *sql.Rows, w io.Writer, format string, opts *Options) error {
+	writersMu.RLock()
+	writer, ok := writers[format]
+	if !ok {
+		writer = writers["table"]
+	}
+	writersMu.RUnlock()
+
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := writer(rows, w, opts); err != nil {
+		return err
 	}
 
 	return rows.Err()
@@ -136,6 +176,11 @@ func ndjsonDisplay(rows *sql.Rows, writer io.Writer) error {
 		return err
 	}
 
+	types, err := declaredTypes(rows)
+	if err != nil {
+		return err
+	}
+
 	values := make([]interface{}, len(columns))
 	for i := range values {
 		values[i] = new(interface{})
@@ -152,7 +197,7 @@ func ndjsonDisplay(rows *sql.Rows, writer io.Writer) error {
 		dest := make(map[string]interface{})
 
 		for i, column := range columns {
-			dest[column] = *(values[i].(*interface{}))
+			dest[column] = jsonValue(*(values[i].(*interface{})), types[i])
 		}
 
 		err := enc.Encode(dest)
@@ -165,6 +210,53 @@ func ndjsonDisplay(rows *sql.Rows, writer io.Writer) error {
 	return nil
 }
 
+// declaredTypes returns the declared type of each column of rows, in upper case, or an empty string for
+// columns without one (such as expressions)
+func declaredTypes(rows *sql.Rows) ([]string, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, len(columnTypes))
+	for i, columnType := range columnTypes {
+		types[i] = strings.ToUpper(columnType.DatabaseTypeName())
+	}
+	return types, nil
+}
+
+// jsonValue converts a value scanned from a column with the given declared type, so that it keeps its type in JSON output.
+// BOOLEAN columns are output as booleans, and JSON columns are nested as is, as are JSON objects and arrays in columns
+// without a declared type (such as the results of yaml_to_json). Blobs are output as text if they are valid UTF-8,
+// base64 encoded otherwise.
+func jsonValue(value interface{}, declaredType string) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if declaredType == "BOOLEAN" || declaredType == "BOOL" {
+			return v != 0
+		}
+	case float64:
+		// NaN and infinities cannot be represented in JSON
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+	case []byte:
+		if !utf8.Valid(v) {
+			return base64.StdEncoding.EncodeToString(v)
+		}
+		return jsonValue(string(v), declaredType)
+	case string:
+		trimmed := strings.TrimSpace(v)
+		isObjectOrArray := strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+		if declaredType == "JSON" || (declaredType == "" && isObjectOrArray) {
+			if trimmed != "" && json.Valid([]byte(trimmed)) {
+				return json.RawMessage(trimmed)
+			}
+		}
+	}
+	return value
+}
+
 func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
 	buffer := make([]interface{}, 0)
 
@@ -173,6 +265,11 @@ func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
 		return err
 	}
 
+	types, err := declaredTypes(rows)
+	if err != nil {
+		return err
+	}
+
 	values := make([]interface{}, len(columns))
 	for i := range values {
 		values[i] = new(interface{})
@@ -187,7 +284,7 @@ func jsonDisplay(rows *sql.Rows, writer io.Writer) error {
 		dest := make(map[string]interface{})
 
 		for i, column := range columns {
-			dest[column] = *(values[i].(*interface{}))
+			dest[column] = jsonValue(*(values[i].(*interface{})), types[i])
 		}
 
 		buffer = append(buffer, dest)
@@ -3,12 +3,23 @@ package display
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/csv"
 	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/ipc"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/pqarrow"
 )
 
 func TestDisplayTable(t *testing.T) {
@@ -281,3 +292,470 @@ func TestDisplaySingle(t *testing.T) {
 		t.Fatalf("expected output to be the only the first column of the first row: %s, got: %s", "1", b.String())
 	}
 }
+
+func TestDisplayJSONTypes(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("count").OfType("INT", int64(0)),
+		sqlmock.NewColumn("is_fork").OfType("BOOLEAN", int64(0)),
+		sqlmock.NewColumn("repo").OfType("JSON", ""),
+		sqlmock.NewColumn("mod").OfType("", ""),
+		sqlmock.NewColumn("message").OfType("TEXT", ""),
+		sqlmock.NewColumn("contents").OfType("BLOB", []byte{}),
+		sqlmock.NewColumn("binary").OfType("BLOB", []byte{}),
+	).AddRow(int64(42), int64(1), `{"name":"mergestat"}`, `["a", "b"]`, "[1]", []byte("hello"), []byte{0xff, 0xfe})
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"binary":"//4=","contents":"hello","count":42,"is_fork":true,"message":"[1]","mod":["a","b"],"repo":{"name":"mergestat"}}]`
+	if strings.TrimSpace(b.String()) != expected {
+		t.Fatalf("expected output to be %s, got: %s", expected, b.String())
+	}
+}
+
+func TestDisplayMarkdown(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name", "value"}).
+		AddRow("1", "name | 1", "value\n1").
+		AddRow("2", "name 2", nil)
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "markdown", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "| id | name | value |\n| --- | --- | --- |\n| 1 | name \\| 1 | value<br>1 |\n| 2 | name 2 |  |\n"
+	if b.String() != expected {
+		t.Fatalf("expected output to be:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	var cases = []struct {
+		text, expected string
+	}{
+		{"plain text", "plain text"},
+		{"__init__.py", `\_\_init\_\_.py`},
+		{"a | b", `a \| b`},
+		{"*bold* `code` [link](url)", "\\*bold\\* \\`code\\` \\[link\\](url)"},
+		{"<b>a & b</b>", "&lt;b&gt;a &amp; b&lt;/b&gt;"},
+		{`C:\path`, `C:\\path`},
+		{"line\r\nbreaks\n", "line<br>breaks<br>"},
+	}
+	for _, c := range cases {
+		if escaped := EscapeMarkdown(c.text); escaped != c.expected {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", c.text, escaped, c.expected)
+		}
+	}
+}
+
+func TestDisplayHTML(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"id", "name"}).
+		AddRow("1", "<b>name</b> & 1")
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "html", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<table>\n<thead>\n<tr><th>id</th><th>name</th></tr>\n</thead>\n<tbody>\n" +
+		"<tr><td>1</td><td>&lt;b&gt;name&lt;/b&gt; &amp; 1</td></tr>\n</tbody>\n</table>\n"
+	if b.String() != expected {
+		t.Fatalf("expected output to be:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestDisplayTemplate(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mockRows := sqlmock.NewRows([]string{"author_email", "count"}).
+		AddRow("alice@example.com", int64(3)).
+		AddRow([]byte("bob@example.com"), int64(1))
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := Write(rows, &b, "template", &Options{Template: "{{.author_email}}: {{.count}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "alice@example.com: 3\nbob@example.com: 1\n"
+	if b.String() != expected {
+		t.Fatalf("expected output to be:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func TestDisplayTemplateMissingColumn(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+
+	mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	if err := Write(rows, &b, "template", &Options{Template: "{{.email}}"}); err == nil {
+		t.Fatal("expected an error for a column missing from the results")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("count", func(rows *sql.Rows, w io.Writer, _ *Options) error {
+		var n int
+		for rows.Next() {
+			n++
+		}
+		_, err := fmt.Fprintf(w, "%d rows", n)
+		return err
+	})
+
+	var found bool
+	for _, format := range Formats() {
+		found = found || format == "count"
+	}
+	if !found {
+		t.Fatalf("expected count to be listed in the formats, got: %v", Formats())
+	}
+
+	db, mock, _ := sqlmock.New()
+
+	mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1").AddRow("2"))
+
+	rows, _ := db.Query("select")
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "count", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.String() != "2 rows" {
+		t.Fatalf("expected output to be written by the registered writer, got: %s", b.String())
+	}
+}
+
+// columnarRows returns n rows with a column of each kind, where every seventh row is NULL, along with the values
+// expected to be read back from a columnar format for each column (nil for NULLs)
+func columnarRows(t *testing.T, n int) (*sql.Rows, [][]interface{}) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mockRows := sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("name").OfType("TEXT", ""),
+		sqlmock.NewColumn("data").OfType("BLOB", []byte(nil)),
+		sqlmock.NewColumn("count").OfType("INT", int64(0)),
+		sqlmock.NewColumn("ratio").OfType("REAL", float64(0)),
+		sqlmock.NewColumn("merged").OfType("BOOLEAN", false),
+		sqlmock.NewColumn("when").OfType("DATETIME", ""),
+	)
+
+	var expected = make([][]interface{}, 6)
+	var start = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		if i%7 == 3 {
+			mockRows.AddRow(nil, nil, nil, nil, nil, nil)
+			for c := range expected {
+				expected[c] = append(expected[c], nil)
+			}
+			continue
+		}
+
+		when := start.Add(time.Duration(i) * time.Minute)
+		row := []interface{}{fmt.Sprintf("name %d", i), []byte{byte(i), byte(i >> 8)}, int64(i), float64(i) / 4, i%2 == 0, when}
+		mockRows.AddRow(row[0], row[1], row[2], row[3], row[4], when.Format("2006-01-02 15:04:05"))
+		for c := range expected {
+			expected[c] = append(expected[c], row[c])
+		}
+	}
+
+	mock.ExpectQuery("select").WillReturnRows(mockRows)
+	rows, err := db.Query("select")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rows, expected
+}
+
+// checkRecord checks the values of the rows of record, which start at row offset of expected (see columnarRows)
+func checkRecord(t *testing.T, record arrow.Record, expected [][]interface{}, offset int) {
+	t.Helper()
+
+	var names = []string{"name", "data", "count", "ratio", "merged", "when"}
+	if int(record.NumCols()) != len(names) {
+		t.Fatalf("expected %d columns, got %d", len(names), record.NumCols())
+	}
+
+	for c, name := range names {
+		if record.ColumnName(c) != name {
+			t.Fatalf("expected column %d to be %s, got %s", c, name, record.ColumnName(c))
+		}
+		if !record.Schema().Field(c).Nullable {
+			t.Fatalf("expected column %s to be nullable", name)
+		}
+
+		column := record.Column(c)
+		for j := 0; j < column.Len(); j++ {
+			var value interface{}
+			if !column.IsNull(j) {
+				switch column := column.(type) {
+				case *array.String:
+					value = column.Value(j)
+				case *array.Binary:
+					value = column.Value(j)
+				case *array.Int64:
+					value = column.Value(j)
+				case *array.Float64:
+					value = column.Value(j)
+				case *array.Boolean:
+					value = column.Value(j)
+				case *array.Timestamp:
+					value = time.UnixMicro(int64(column.Value(j))).UTC()
+				default:
+					t.Fatalf("unexpected type %s of column %s", column.DataType(), name)
+				}
+			}
+
+			if want := expected[c][offset+j]; fmt.Sprint(value) != fmt.Sprint(want) {
+				t.Fatalf("expected %v in row %d of column %s, got %v", want, offset+j, name, value)
+			}
+		}
+	}
+}
+
+func TestDisplayParquet(t *testing.T) {
+	// enough rows for two row groups
+	var n = batchRows + 10
+	rows, expected := columnarRows(t, n)
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "parquet", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := file.NewParquetReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.NumRowGroups() != 2 || reader.NumRows() != int64(n) {
+		t.Fatalf("expected %d rows in 2 row groups, got %d rows in %d", n, reader.NumRows(), reader.NumRowGroups())
+	}
+
+	fileReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{BatchSize: 1000}, memory.DefaultAllocator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := fileReader.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer records.Release()
+
+	var offset int
+	for records.Next() {
+		checkRecord(t, records.Record(), expected, offset)
+		offset += int(records.Record().NumRows())
+	}
+	if offset != n {
+		t.Fatalf("expected to read back %d rows, got %d", n, offset)
+	}
+}
+
+func TestDisplayArrow(t *testing.T) {
+	// enough rows for two record batches
+	var n = batchRows + 10
+	rows, expected := columnarRows(t, n)
+
+	var b bytes.Buffer
+	err := WriteTo(rows, &b, "arrow", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := ipc.NewReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Release()
+
+	var batches, offset int
+	for reader.Next() {
+		checkRecord(t, reader.Record(), expected, offset)
+		offset += int(reader.Record().NumRows())
+		batches++
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if batches != 2 || offset != n {
+		t.Fatalf("expected %d rows in 2 record batches, got %d rows in %d", n, offset, batches)
+	}
+}
+
+func TestDisplayColumnarEmpty(t *testing.T) {
+	for _, format := range []string{"parquet", "arrow"} {
+		db, mock, _ := sqlmock.New()
+		mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("count").OfType("INT", int64(0)),
+		))
+		rows, _ := db.Query("select")
+
+		var b bytes.Buffer
+		if err := WriteTo(rows, &b, format, false); err != nil {
+			t.Fatal(err)
+		}
+
+		var schema *arrow.Schema
+		if format == "parquet" {
+			reader, err := file.NewParquetReader(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if schema, err = pqarrow.FromParquet(reader.MetaData().Schema, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			reader, err := ipc.NewReader(bytes.NewReader(b.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reader.Next() {
+				t.Fatal("expected no record batch")
+			}
+			schema = reader.Schema()
+		}
+
+		if len(schema.Fields()) != 1 || schema.Field(0).Name != "count" || schema.Field(0).Type.ID() != arrow.INT64 {
+			t.Fatalf("expected the %s output to hold the schema, got %v", format, schema)
+		}
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	var tests = []struct {
+		declaredType string
+		value        interface{}
+		expected     interface{}
+	}{
+		{"INTEGER", int64(1), int64(1)},
+		{"INT", "42", int64(42)},
+		{"BOOLEAN", int64(1), true},
+		{"REAL", int64(2), float64(2)},
+		{"VARCHAR(10)", int64(3), "3"},
+		{"TEXT", []byte("text"), "text"},
+		{"BLOB", "blob", []byte("blob")},
+		{"DATETIME", "2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"INT", nil, nil},
+	}
+
+	for _, test := range tests {
+		kind, ok := kindOfDeclaredType(test.declaredType)
+		if !ok {
+			t.Fatalf("expected the kind of %s to be known", test.declaredType)
+		}
+		value, err := convertValue(test.value, kind)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(value) != fmt.Sprint(test.expected) {
+			t.Errorf("convertValue(%v, %s) = %v, want %v", test.value, kind, value, test.expected)
+		}
+	}
+
+	if _, err := convertValue("not a number", kindInt64); err == nil {
+		t.Error("expected an error converting text to an integer")
+	}
+}
+
+func TestColumnarInferredKinds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery("select").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		sqlmock.NewColumn("ints"), sqlmock.NewColumn("numbers"), sqlmock.NewColumn("mixed"), sqlmock.NewColumn("nulls"),
+	).AddRow(int64(1), int64(1), int64(1), nil).AddRow(nil, 0.5, "a", nil).AddRow(int64(3), int64(2), []byte("b"), nil))
+	rows, err := db.Query("select")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err = WriteTo(rows, &b, "arrow", false); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := ipc.NewReader(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Release()
+	if !reader.Next() {
+		t.Fatalf("expected a record batch, got: %v", reader.Err())
+	}
+
+	// integers mixed with reals are widened to reals, and anything else mixed to text
+	record := reader.Record()
+	var expected = []struct {
+		id     arrow.Type
+		values string
+	}{
+		{arrow.INT64, "[1 (null) 3]"},
+		{arrow.FLOAT64, "[1 0.5 2]"},
+		{arrow.STRING, `["1" "a" "b"]`},
+		{arrow.STRING, "[(null) (null) (null)]"},
+	}
+	for c, want := range expected {
+		column := record.Column(c)
+		if column.DataType().ID() != want.id || column.String() != want.values {
+			t.Errorf("expected column %s to be %s %s, got %s %s", record.ColumnName(c), want.id, want.values, column.DataType(), column)
+		}
+	}
+}
+
+func TestColumnarInferredKindOfLaterBatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockRows := sqlmock.NewRowsWithColumnDefinition(sqlmock.NewColumn("n"))
+	for i := 0; i < batchRows; i++ {
+		mockRows.AddRow(int64(i))
+	}
+	mock.ExpectQuery("select").WillReturnRows(mockRows.AddRow(float64(2)).AddRow(0.5))
+	rows, err := db.Query("select")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema is set by the first batch, so that a real that is not an integer cannot be written after
+	err = WriteTo(rows, io.Discard, "arrow", false)
+	if err == nil || !strings.Contains(err.Error(), "cannot convert 0.5") || !strings.Contains(err.Error(), "CAST") {
+		t.Fatalf("expected an error suggesting a CAST, got: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"bufio"
+	"database/sql"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLTable writes an HTML table, to be embedded in a page, one row at a time. Cells are text, which is escaped,
+// with line breaks kept as <br>. Close ends the table.
+type HTMLTable struct {
+	w *bufio.Writer
+}
+
+// NewHTMLTable writes the header of an HTML table with the given columns to w
+func NewHTMLTable(w io.Writer, columns []string) (*HTMLTable, error) {
+	t := &HTMLTable{w: bufio.NewWriter(w)}
+	t.w.WriteString("<table>\n<thead>\n")
+	t.cells("th", columns)
+	_, err := t.w.WriteString("</thead>\n<tbody>\n")
+	return t, err
+}
+
+// cells writes a row of cells with the given tag. As bufio.Writer keeps the first error, it is returned by the last write.
+func (t *HTMLTable) cells(tag string, cells []string) error {
+	t.w.WriteString("<tr>")
+	for _, cell := range cells {
+		t.w.WriteString("<" + tag + ">" + strings.ReplaceAll(html.EscapeString(cell), "\n", "<br>") + "</" + tag + ">")
+	}
+	_, err := t.w.WriteString("</tr>\n")
+	return err
+}
+
+// Row writes a row of the table
+func (t *HTMLTable) Row(cells []string) error {
+	return t.cells("td", cells)
+}
+
+// Close ends the table, and flushes what is left of it to the writer
+func (t *HTMLTable) Close() error {
+	t.w.WriteString("</tbody>\n</table>\n")
+	return t.w.Flush()
+}
+
+// htmlDisplay writes rows as an HTML table, to be embedded in a page. NULL values are left empty.
+func htmlDisplay(rows *sql.Rows, w io.Writer) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	t, err := NewHTMLTable(w, columns)
+	if err != nil {
+		return err
+	}
+	if err = scanStrings(rows, len(columns), t.Row); err != nil {
+		return err
+	}
+	return t.Close()
+}
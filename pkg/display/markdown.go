@@ -0,0 +1,86 @@
+package display
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownEscaper escapes the characters that GitHub-flavored markdown would otherwise interpret, including what would
+// end a cell of a table. Line breaks are kept as <br>.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"&", "&amp;", "<", "&lt;", ">", "&gt;",
+	"\r\n", "<br>", "\n", "<br>", "\r", "<br>",
+)
+
+// EscapeMarkdown escapes text so that it is displayed as is in GitHub-flavored markdown, including in a table cell
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// MarkdownTable writes a GitHub-flavored markdown table, one row at a time. Cells are text, which is escaped
+// (see EscapeMarkdown).
+type MarkdownTable struct {
+	w io.Writer
+}
+
+// NewMarkdownTable writes the header of a markdown table with the given columns to w
+func NewMarkdownTable(w io.Writer, columns []string) (*MarkdownTable, error) {
+	t := &MarkdownTable{w: w}
+	if err := t.Row(columns); err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(columns))); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Row writes a row of the table
+func (t *MarkdownTable) Row(cells []string) error {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = EscapeMarkdown(cell)
+	}
+	_, err := fmt.Fprintf(t.w, "| %s |\n", strings.Join(escaped, " | "))
+	return err
+}
+
+// markdownDisplay writes rows as a GitHub-flavored markdown table, with NULL values left empty
+func markdownDisplay(rows *sql.Rows, w io.Writer) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	t, err := NewMarkdownTable(w, columns)
+	if err != nil {
+		return err
+	}
+	return scanStrings(rows, len(columns), t.Row)
+}
+
+// scanStrings scans each of rows as strings, with NULL values as empty strings, and calls fn with the values of the row
+func scanStrings(rows *sql.Rows, columns int, fn func(values []string) error) error {
+	pointers := make([]interface{}, columns)
+	container := make([]sql.NullString, columns)
+	for i := range pointers {
+		pointers[i] = &container[i]
+	}
+
+	values := make([]string, columns)
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		for i, v := range container {
+			values[i] = v.String
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package display
+
+import (
+	"database/sql"
+	"io"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/parquet"
+	"github.com/apache/arrow/go/v11/parquet/compress"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/pqarrow"
+)
+
+// parquetDisplay writes rows as a parquet file, with a row group per batch of rows (see writeColumnar).
+// Columns are optional (nullable), and their pages compressed with snappy.
+func parquetDisplay(rows *sql.Rows, w io.Writer) error {
+	return writeColumnar(rows, &parquetWriter{w: w})
+}
+
+type parquetWriter struct {
+	w      io.Writer
+	schema *arrow.Schema
+	props  *parquet.WriterProperties
+	writer *pqarrow.FileWriter // created with the first batch
+}
+
+func (p *parquetWriter) begin(fields []columnarField) error {
+	p.schema = arrowSchema(fields)
+	p.props = parquet.NewWriterProperties(
+		parquet.WithCompression(compress.Codecs.Snappy),
+		parquet.WithMaxRowGroupLength(batchRows),
+	)
+	return nil
+}
+
+func (p *parquetWriter) writeBatch(columns [][]interface{}, n int) (err error) {
+	if p.writer == nil {
+		if p.writer, err = pqarrow.NewFileWriter(p.schema, nopCloser{p.w}, p.props, pqarrow.DefaultWriterProps()); err != nil {
+			return err
+		}
+	}
+
+	record := arrowRecord(p.schema, columns, n)
+	defer record.Release()
+	return p.writer.Write(record)
+}
+
+func (p *parquetWriter) end() error {
+	if p.writer != nil {
+		return p.writer.Close()
+	}
+
+	// the arrow writer cannot be closed without having written a batch,
+	// so a file without rows is written as its schema alone
+	schema, err := pqarrow.ToParquet(p.schema, p.props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return err
+	}
+	return file.NewParquetWriter(nopCloser{p.w}, schema.Root(), file.WithWriterProps(p.props)).Close()
+}
+
+// nopCloser keeps the parquet writer from closing the output when it closes, which is up to the caller
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,62 @@
+package display
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"io"
+	"text/template"
+)
+
+// templateDisplay executes opts.Template for each of rows, with the row as a map of column names to values
+// (NULL values are nil), and writes each row on its own line. Referring to a column missing from the
+// results is an error, rather than writing "<no value>".
+func templateDisplay(rows *sql.Rows, w io.Writer, opts *Options) error {
+	if opts.Template == "" {
+		return errors.New("the template format requires a template")
+	}
+
+	tmpl, err := template.New("row").Option("missingkey=error").Parse(opts.Template)
+	if err != nil {
+		return err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+
+	var buf bytes.Buffer
+	for rows.Next() {
+		if err = rows.Scan(values...); err != nil {
+			return err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			value := *(values[i].(*interface{}))
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			row[column] = value
+		}
+
+		buf.Reset()
+		if err = tmpl.Execute(&buf, row); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err = w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
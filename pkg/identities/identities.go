@@ -0,0 +1,185 @@
+// Package identities resolves commit identities (name/email pairs) to a canonical person and organization,
+// and flags identities that belong to bots. It complements mailmap (see pkg/mailmap), which only rewrites
+// identities found in history: the identities configuration is maintained outside of any one repository,
+// and supports patterns so it can be shared across many of them.
+//
+// An identities file is written in YAML (or JSON) and looks like:
+//
+//	people:
+//	  - name: Jane Doe
+//	    org: Acme
+//	    emails: [jane@example.com, "jane@*.local", "*@jane.dev"]
+//	orgs:
+//	  - name: Acme
+//	    domains: [acme.com, "*.acme.io"]
+//	bots:
+//	  - "dependabot*"
+//	  - "renovate@*"
+//
+// Patterns are matched case-insensitively, and * matches any sequence of characters.
+package identities
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// DefaultBots are the patterns that flag an identity as a bot, in addition to any that are configured.
+// They match GitHub apps (such as dependabot[bot]), whose names and emails include a [bot] suffix.
+var DefaultBots = []string{"*[bot]", "*[bot]@*"}
+
+// Person maps a set of email patterns to a canonical name, and optionally an organization
+type Person struct {
+	Name   string   `json:"name"`
+	Org    string   `json:"org"`
+	Emails []string `json:"emails"`
+}
+
+// Org maps a set of email domain patterns to an organization
+type Org struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+}
+
+// Config is the contents of an identities file
+type Config struct {
+	People []Person `json:"people"`
+	Orgs   []Org    `json:"orgs"`
+
+	// Bots are patterns matched against both the name and the email of an identity
+	Bots []string `json:"bots"`
+}
+
+// Identity is the result of resolving a name/email pair
+type Identity struct {
+	// Name is the canonical name of the person, or the name resolved from if no person matched
+	Name string
+
+	// Org is the organization of the person, or the one their email domain belongs to (empty if unknown)
+	Org string
+
+	// Bot is set if the name or email matched one of the bot patterns
+	Bot bool
+}
+
+// Identities resolves identities according to a Config.
+// A nil *Identities is valid, and resolves every identity to itself (only flagging default bots).
+type Identities struct {
+	people []person
+	orgs   []org
+	bots   []*regexp.Regexp
+}
+
+type person struct {
+	Person
+	emails []*regexp.Regexp
+}
+
+type org struct {
+	Org
+	domains []*regexp.Regexp
+}
+
+// Load reads and parses the identities file at path
+func Load(path string) (*Identities, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read identities file")
+	}
+
+	return Parse(b)
+}
+
+// Parse parses the contents of an identities file
+func Parse(b []byte) (*Identities, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, errors.Wrap(err, "could not parse identities file")
+	}
+
+	return New(&cfg)
+}
+
+// New returns an Identities that resolves according to cfg
+func New(cfg *Config) (*Identities, error) {
+	var ids = &Identities{}
+
+	for _, p := range cfg.People {
+		if p.Name == "" {
+			return nil, errors.New("every person in the identities file must have a name")
+		}
+		ids.people = append(ids.people, person{Person: p, emails: compile(p.Emails)})
+	}
+
+	for _, o := range cfg.Orgs {
+		if o.Name == "" {
+			return nil, errors.New("every org in the identities file must have a name")
+		}
+		ids.orgs = append(ids.orgs, org{Org: o, domains: compile(o.Domains)})
+	}
+
+	ids.bots = compile(append(append([]string{}, DefaultBots...), cfg.Bots...))
+	return ids, nil
+}
+
+// Resolve returns the identity for the given name and email.
+// People are matched on their email, in the order they are configured, as are org domains.
+func (ids *Identities) Resolve(name, email string) Identity {
+	if ids == nil {
+		ids = defaults
+	}
+
+	var id = Identity{Name: name}
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	for _, p := range ids.people {
+		if matchAny(p.emails, email) {
+			id.Name, id.Org = p.Name, p.Org
+			break
+		}
+	}
+
+	if id.Org == "" {
+		if at := strings.LastIndexByte(email, '@'); at >= 0 {
+			domain := email[at+1:]
+			for _, o := range ids.orgs {
+				if matchAny(o.domains, domain) {
+					id.Org = o.Name
+					break
+				}
+			}
+		}
+	}
+
+	id.Bot = matchAny(ids.bots, strings.ToLower(name)) || matchAny(ids.bots, email)
+	return id
+}
+
+// defaults is used to resolve identities when no configuration is loaded
+var defaults, _ = New(&Config{})
+
+// compile turns patterns, where * matches any sequence of characters, into case-insensitive regular expressions
+func compile(patterns []string) []*regexp.Regexp {
+	var out = make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		var quoted = strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(strings.TrimSpace(p))), `\*`, `.*`)
+		out = append(out, regexp.MustCompile(`^`+quoted+`$`))
+	}
+	return out
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package identities_test
+
+import (
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/identities"
+)
+
+const config = `
+people:
+  - name: Jane Doe
+    org: Jane Consulting
+    emails: [jane@example.com, "jane@*.local", "*@jane.dev"]
+  - name: Joe Developer
+    emails: [joe@acme.com]
+orgs:
+  - name: Acme
+    domains: [acme.com, "*.acme.io"]
+bots:
+  - "renovate*"
+`
+
+func TestResolve(t *testing.T) {
+	ids, err := identities.Parse([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = []struct {
+		name, email string
+		expected    identities.Identity
+	}{
+		{"jane", "Jane@Example.com", identities.Identity{Name: "Jane Doe", Org: "Jane Consulting"}},
+		{"jd", "jane@laptop.local", identities.Identity{Name: "Jane Doe", Org: "Jane Consulting"}},
+		{"Jane", "hello@jane.dev", identities.Identity{Name: "Jane Doe", Org: "Jane Consulting"}},
+		// a person without an org falls back to the org of their email domain
+		{"joe", "joe@acme.com", identities.Identity{Name: "Joe Developer", Org: "Acme"}},
+		{"Someone", "someone@eu.acme.io", identities.Identity{Name: "Someone", Org: "Acme"}},
+		{"Someone", "someone@example.org", identities.Identity{Name: "Someone"}},
+		{"dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", identities.Identity{Name: "dependabot[bot]", Bot: true}},
+		{"Renovate Bot", "bot@renovateapp.com", identities.Identity{Name: "Renovate Bot", Bot: true}},
+	}
+
+	for _, c := range cases {
+		if id := ids.Resolve(c.name, c.email); id != c.expected {
+			t.Fatalf("unexpected identity for %s <%s>: expected %+v, got %+v", c.name, c.email, c.expected, id)
+		}
+	}
+}
+
+func TestResolveWithoutConfig(t *testing.T) {
+	var ids *identities.Identities
+
+	if id := ids.Resolve("Jane", "jane@example.com"); id != (identities.Identity{Name: "Jane"}) {
+		t.Fatalf("unexpected identity %+v", id)
+	}
+
+	if id := ids.Resolve("github-actions[bot]", "41898282+github-actions[bot]@users.noreply.github.com"); !id.Bot {
+		t.Fatalf("expected a bot, got %+v", id)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	if _, err := identities.Parse([]byte("people:\n  - emails: [jane@example.com]\n")); err == nil {
+		t.Fatalf("expected an error for a person without a name")
+	}
+
+	if _, err := identities.Parse([]byte("people: [")); err == nil {
+		t.Fatalf("expected an error for malformed yaml")
+	}
+}
@@ -0,0 +1,47 @@
+package locator
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+func TestHTTPAuth(t *testing.T) {
+	var defaultAuth = &http.BasicAuth{Username: "token"}
+	var hostAuth = &http.BasicAuth{Username: "ci", Password: "secret"}
+	var o = &MultiLocatorOptions{HTTPAuth: defaultAuth, HostAuth: map[string]*http.BasicAuth{"git.example.com": hostAuth}}
+
+	var cases = []struct {
+		url      string
+		expected *http.BasicAuth
+	}{
+		{"https://github.com/mergestat/mergestat-lite", defaultAuth},
+		{"https://git.example.com/team/repo", hostAuth},
+		{"https://git.example.com:8443/team/repo", hostAuth},
+		{"https://someone@git.example.com/team/repo", nil},
+	}
+	for _, c := range cases {
+		auth, err := o.httpAuth(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != c.expected {
+			t.Errorf("httpAuth(%s) = %v, want %v", c.url, auth, c.expected)
+		}
+	}
+}
+
+func TestSSHEndpoint(t *testing.T) {
+	var cases = []struct {
+		path, endpoint, user string
+	}{
+		{"ssh://git@github.com:mergestat/mergestat-lite", "github.com:mergestat/mergestat-lite", "git"},
+		{"ssh://ci@git.example.com:team/repo.git", "git.example.com:team/repo.git", "ci"},
+		{"ssh://git.example.com:team/repo.git", "git.example.com:team/repo.git", "git"},
+	}
+	for _, c := range cases {
+		if endpoint, user := sshEndpoint(c.path); endpoint != c.endpoint || user != c.user {
+			t.Errorf("sshEndpoint(%s) = %s, %s, want %s, %s", c.path, endpoint, user, c.endpoint, c.user)
+		}
+	}
+}
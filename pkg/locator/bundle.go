@@ -0,0 +1,245 @@
+package locator
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/extensions/services"
+	"github.com/pkg/errors"
+)
+
+// BareLocator is a repo locator implementation that opens a bare on-disk repository
+// (such as one created with git clone --mirror) at the specified path.
+// Unlike DiskLocator, absolute paths listed in objects/info/alternates are resolved
+// against the root of the filesystem, which is how shared mirrors usually reference their object stores.
+func BareLocator() services.RepoLocator {
+	return options.RepoLocatorFn(func(_ context.Context, path string) (*git.Repository, error) {
+		var err error
+		if path, err = filepath.Abs(strings.TrimPrefix(path, "file://")); err != nil {
+			return nil, err
+		}
+
+		if !IsBareRepo(path) {
+			return nil, errors.Errorf("%s is not a bare git repository", path)
+		}
+
+		var storer = filesystem.NewStorageWithOptions(osfs.New(path), cache.NewObjectLRUDefault(),
+			filesystem.Options{AlternatesFS: osfs.New(string(filepath.Separator))})
+		return git.Open(storer, nil)
+	})
+}
+
+// IsBareRepo reports whether the directory at path looks like a bare git repository,
+// that is, it has the git directory layout at its root rather than in a .git subdirectory.
+func IsBareRepo(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, git.GitDirName)); err == nil {
+		return false
+	}
+
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// BundleLocator returns a repo locator capable of unpacking git bundle files
+// (as created by git bundle create) on-demand into a bare repository.
+// If a clone directory is set, the bundle is unpacked into a directory beneath it
+// (and refreshed from the bundle if it already exists), otherwise into a temporary directory (see RemoveTempDirs).
+// It is recommended that you club it with something like CachedLocator to improve performance
+// and remove the need to unpack a single bundle multiple times.
+func BundleLocator(o *MultiLocatorOptions) func() services.RepoLocator {
+	return func() services.RepoLocator {
+		return options.RepoLocatorFn(func(ctx context.Context, path string) (_ *git.Repository, err error) {
+			if path, err = filepath.Abs(strings.TrimPrefix(path, "file://")); err != nil {
+				return nil, err
+			}
+
+			var file *os.File
+			if file, err = os.Open(path); err != nil {
+				return nil, errors.Wrap(err, "failed to open bundle")
+			}
+			defer file.Close()
+
+			var dir string
+			if o.CloneDir == "" {
+				dir, err = mkdirTemp()
+			} else {
+				dir, err = determineBundleDir(path, o.CloneDir)
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "could not determine clone directory")
+			}
+
+			var repo *git.Repository
+			if repo, err = git.PlainOpen(dir); errors.Is(err, git.ErrRepositoryNotExists) {
+				repo, err = git.PlainInit(dir, true)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if err = unbundle(ctx, repo, file); err != nil {
+				return nil, errors.Wrapf(err, "failed to unpack bundle %s", path)
+			}
+			return repo, nil
+		})
+	}
+}
+
+// determineBundleDir returns the path to a directory beneath baseCloneDir where the bundle at path will be unpacked to.
+// The directory is named after the bundle, qualified with a digest of its full path so bundles with the same name don't collide.
+func determineBundleDir(path, baseCloneDir string) (string, error) {
+	var err error
+	if baseCloneDir, err = filepath.Abs(baseCloneDir); err != nil {
+		return "", errors.Wrap(err, "failed to retrieve absolute path for clone directory")
+	}
+
+	var digest = sha1.Sum([]byte(path))
+	var name = fmt.Sprintf("%s-%s", strings.TrimSuffix(filepath.Base(path), ".bundle"), hex.EncodeToString(digest[:4]))
+	var dir = filepath.Join(baseCloneDir, "bundles", name)
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrap(err, "failed to create clone directory")
+	}
+	return dir, nil
+}
+
+// unbundle reads a v2 or v3 git bundle from r, writing its packfile into the repository's
+// object storage and its references into the repository's reference storage, in place of
+// any left from a previous unbundling (so refs deleted since are not kept around).
+// See https://git-scm.com/docs/gitformat-bundle for a description of the format.
+func unbundle(ctx context.Context, repo *git.Repository, r io.Reader) error {
+	var buf = bufio.NewReader(r)
+
+	signature, err := buf.ReadString('\n')
+	if err != nil {
+		return errors.Wrap(err, "failed to read bundle header")
+	}
+	switch signature {
+	case "# v2 git bundle\n", "# v3 git bundle\n":
+	default:
+		return errors.Errorf("unsupported bundle signature %q", strings.TrimSpace(signature))
+	}
+
+	var refs []*plumbing.Reference
+	for {
+		line, err := buf.ReadString('\n')
+		if err != nil {
+			return errors.Wrap(err, "failed to read bundle header")
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" { // an empty line terminates the header
+			break
+		}
+
+		switch {
+		case strings.HasPrefix(line, "@"): // capability (v3 only)
+			if line != "@object-format=sha1" {
+				return errors.Errorf("unsupported bundle capability %q", line)
+			}
+		case strings.HasPrefix(line, "-"): // prerequisite
+			return errors.New("incremental bundles (with prerequisite commits) are not supported")
+		default:
+			var hash, name, found = strings.Cut(line, " ")
+			if !found || !plumbing.IsHash(hash) {
+				return errors.Errorf("malformed bundle reference %q", line)
+			}
+			refs = append(refs, plumbing.NewHashReference(plumbing.ReferenceName(name), plumbing.NewHash(hash)))
+		}
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if err = packfile.UpdateObjectStorage(repo.Storer, buf); err != nil {
+		return errors.Wrap(err, "failed to write bundle packfile")
+	}
+
+	if err = removeReferences(repo); err != nil {
+		return errors.Wrap(err, "failed to remove previous references")
+	}
+
+	var head *plumbing.Reference
+	for _, ref := range refs {
+		if ref.Name() == plumbing.HEAD {
+			head = ref
+			continue
+		}
+		if err = repo.Storer.SetReference(ref); err != nil {
+			return err
+		}
+	}
+
+	return setBundleHead(repo, head, refs)
+}
+
+// removeReferences removes every reference of the repository but HEAD
+func removeReferences(repo *git.Repository) error {
+	iter, err := repo.Storer.IterReferences()
+	if err != nil {
+		return err
+	}
+
+	var names []plumbing.ReferenceName
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name() != plumbing.HEAD {
+			names = append(names, ref.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err = repo.Storer.RemoveReference(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBundleHead points the repository's HEAD at the branch the bundle's HEAD refers to.
+// Bundles only record the hash HEAD resolved to, so the branch is recovered by matching on it.
+// HEAD is left detached when no branch matches, and is left untouched if the bundle didn't include it.
+func setBundleHead(repo *git.Repository, head *plumbing.Reference, refs []*plumbing.Reference) error {
+	if head == nil {
+		return nil
+	}
+
+	// prefer the branch HEAD already points to (by default, as created by git init)
+	if current, err := repo.Storer.Reference(plumbing.HEAD); err == nil && current.Type() == plumbing.SymbolicReference {
+		for _, ref := range refs {
+			if ref.Name() == current.Target() && ref.Hash() == head.Hash() {
+				return nil
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+			return repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, ref.Name()))
+		}
+	}
+
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.HEAD, head.Hash()))
+}
@@ -16,8 +16,12 @@ import (
 	"sync"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/mergestat/mergestat-lite/extensions/options"
 	"github.com/mergestat/mergestat-lite/extensions/services"
 	"github.com/pkg/errors"
@@ -34,6 +38,8 @@ func DiskLocator() services.RepoLocator {
 // CachedLocator is decorator function that takes a RepoLocator instance
 // and returns another one that caches output from the underlying locator
 // using path as the key.
+// Repositories not backed by the filesystem (such as in-memory ones) are cached as a copy on disk (see Spill),
+// which the native (libgit2) tables can open as-is. The copy is a temporary directory, removed with RemoveTempDirs.
 func CachedLocator(rl services.RepoLocator) services.RepoLocator {
 	cache := sync.Map{}
 
@@ -47,13 +53,20 @@ func CachedLocator(rl services.RepoLocator) services.RepoLocator {
 			return nil, err
 		}
 
+		if _, ok := repo.Storer.(*filesystem.Storage); !ok {
+			if repo, err = Spill(repo); err != nil {
+				return nil, errors.Wrapf(err, "failed to copy %q to disk", path)
+			}
+			keepTempDir(repo.Storer.(*filesystem.Storage).Filesystem().Root())
+		}
+
 		cache.Store(path, repo)
 		return repo, nil
 	})
 }
 
 // determineCloneDir returns the path to a directory on disk where a repository will be cloned to
-// given a baseCloneDir. If baseCloneDir == "", a tmp dir will be created, otherwise a directory
+// given a baseCloneDir. If baseCloneDir == "", a tmp dir will be created (removed with RemoveTempDirs), otherwise a directory
 // path will be determined based on the URL (HTTP(s) or SSH) of the provided repository.
 // The bool returned (2nd return val) indicates whether the output dir is in a tmp directory or not.
 func determineCloneDir(path, baseCloneDir string) (string, bool, error) {
@@ -65,8 +78,8 @@ func determineCloneDir(path, baseCloneDir string) (string, bool, error) {
 
 	// if no clone directory is specified, use a tmp dir
 	if baseCloneDir == "" {
-		if baseCloneDir, err = os.MkdirTemp("", "mergestat"); err != nil {
-			return "", false, errors.Wrap(err, "failed to create a temporary directory")
+		if baseCloneDir, err = mkdirTemp(); err != nil {
+			return "", false, err
 		}
 
 		return baseCloneDir, true, nil
@@ -129,7 +142,7 @@ func httpLocatorWithAuth(user, pass string, rl services.RepoLocator) func() serv
 			if parsed, err := url.Parse(path); err != nil {
 				return nil, err
 			} else {
-				pass, passSet := parsed.User.Password()
+				_, passSet := parsed.User.Password()
 				if parsed.User.Username() == "" && !passSet {
 					parsed.User = url.UserPassword(user, pass)
 					path = parsed.String()
@@ -148,20 +161,7 @@ func httpLocatorWithAuth(user, pass string, rl services.RepoLocator) func() serv
 func SSHLocator(o *MultiLocatorOptions) func() services.RepoLocator {
 	return func() services.RepoLocator {
 		return options.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
-			path = strings.TrimPrefix(path, "ssh://")
-
-			// TODO(patrickdevivo) maybe a little hacky instead of properly parsing the url, strip out the username first
-			// if it's set, otherwise default to "git"
-			var user string
-			split := strings.SplitN(path, "@", 2)
-			switch len(split) {
-			case 1:
-				user = "git"
-				path = split[0]
-			case 2:
-				user = split[0]
-				path = split[1]
-			}
+			path, user := sshEndpoint(path)
 
 			var cd string
 			var isTmp bool
@@ -180,10 +180,42 @@ func SSHLocator(o *MultiLocatorOptions) func() services.RepoLocator {
 	}
 }
 
+// sshEndpoint returns the path of an ssh repository without its scheme and user, and the user, "git" if unset
+func sshEndpoint(path string) (endpoint, user string) {
+	path = strings.TrimPrefix(path, "ssh://")
+
+	// TODO(patrickdevivo) maybe a little hacky instead of properly parsing the url, strip out the username first
+	// if it's set, otherwise default to "git"
+	split := strings.SplitN(path, "@", 2)
+	if len(split) == 1 {
+		return split[0], "git"
+	}
+	return split[1], split[0]
+}
+
 type MultiLocatorOptions struct {
 	HTTPAuth        *http.BasicAuth
 	CloneDir        string
 	InsecureSkipTLS bool
+
+	// HostAuth holds the credentials of specific hosts, used instead of HTTPAuth for repositories on those hosts
+	HostAuth map[string]*http.BasicAuth
+}
+
+// httpAuth returns the credentials to use for the repository at the (HTTP or HTTPS) url: those of its host, if any,
+// or HTTPAuth. It returns nil if the url includes credentials of its own.
+func (o *MultiLocatorOptions) httpAuth(rawURL string) (*http.BasicAuth, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid remote url")
+	}
+	if parsed.User != nil {
+		return nil, nil
+	}
+	if auth, ok := o.HostAuth[parsed.Hostname()]; ok {
+		return auth, nil
+	}
+	return o.HTTPAuth, nil
 }
 
 // MultiLocator returns a locator service that work with multiple git protocols
@@ -193,26 +225,69 @@ func MultiLocator(o *MultiLocatorOptions) services.RepoLocator {
 		o = &MultiLocatorOptions{}
 	}
 	var locators = map[string]func() services.RepoLocator{
-		"http": HttpLocator(o),
-		"ssh":  SSHLocator(o),
-		"file": DiskLocator,
+		"http":   HttpLocator(o),
+		"ssh":    SSHLocator(o),
+		"bundle": BundleLocator(o),
+		"bare":   BareLocator,
+		"file":   DiskLocator,
 	}
 
 	return options.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
 		var fn = locators["file"] // file is the default locator
 		if strings.HasPrefix(path, "http") || strings.HasPrefix(path, "https") {
 			fn = locators["http"]
-			if o.HTTPAuth != nil {
-				fn = httpLocatorWithAuth(o.HTTPAuth.Username, o.HTTPAuth.Password, fn())
+			if auth, err := o.httpAuth(path); err != nil {
+				return nil, err
+			} else if auth != nil {
+				fn = httpLocatorWithAuth(auth.Username, auth.Password, fn())
 			}
-		}
-		if strings.HasPrefix(path, "ssh") {
+		} else if strings.HasPrefix(path, "ssh") {
 			fn = locators["ssh"]
+		} else if local := strings.TrimPrefix(path, "file://"); strings.HasSuffix(local, ".bundle") {
+			fn = locators["bundle"]
+		} else if IsBareRepo(local) {
+			fn = locators["bare"]
 		}
 		return fn().Open(ctx, path)
 	})
 }
 
+// RemoteRefLister returns a ref lister that queries a remote repository for its
+// advertised references (much like git ls-remote), without cloning it.
+// If HTTP auth options (or credentials for the host) are supplied, they will be used when listing an https (only https) repo.
+// ssh repositories are listed with the same authentication as SSHLocator clones them with (the ssh agent).
+func RemoteRefLister(o *MultiLocatorOptions) services.RefLister {
+	if o == nil {
+		o = &MultiLocatorOptions{}
+	}
+
+	return options.RefListerFn(func(ctx context.Context, path string) ([]*plumbing.Reference, error) {
+		var listOpts = &git.ListOptions{InsecureSkipTLS: o.InsecureSkipTLS, PeelingOption: git.IgnorePeeled}
+		if strings.HasPrefix(path, "https") {
+			if auth, err := o.httpAuth(path); err != nil {
+				return nil, err
+			} else if auth != nil {
+				listOpts.Auth = auth
+			}
+		} else if strings.HasPrefix(path, "ssh") {
+			var user string
+			path, user = sshEndpoint(path)
+
+			var err error
+			if listOpts.Auth, err = ssh.DefaultAuthBuilder(user); err != nil {
+				return nil, errors.Wrap(err, "failed to create an SSH authentication method")
+			}
+		}
+
+		var remote = git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{path}})
+		refs, err := remote.ListContext(ctx, listOpts)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list remote references")
+		}
+		return refs, nil
+	})
+}
+
 // LoggingLocator returns a locator that logs
 func LoggingLocator(logger *zerolog.Logger, rl services.RepoLocator) services.RepoLocator {
 	return options.RepoLocatorFn(func(ctx context.Context, path string) (*git.Repository, error) {
@@ -0,0 +1,192 @@
+package locator_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+)
+
+// git runs the git binary with the given arguments in dir, skipping the test if git isn't installed
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not available")
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v: %s", args, err, out)
+	}
+}
+
+// fixture creates a repository with a couple of commits and a tag, returning its path
+func fixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git(t, dir, "init", "-q", "-b", "main", "repo")
+
+	repo := filepath.Join(dir, "repo")
+	git(t, repo, "commit", "-q", "--allow-empty", "-m", "first")
+	git(t, repo, "commit", "-q", "--allow-empty", "-m", "second")
+	git(t, repo, "tag", "v1")
+	return repo
+}
+
+func TestBundleLocator(t *testing.T) {
+	repo := fixture(t)
+	bundle := filepath.Join(t.TempDir(), "repo.bundle")
+	git(t, repo, "bundle", "create", bundle, "--all")
+
+	for _, cloneDir := range []string{"", t.TempDir()} {
+		r, err := locator.MultiLocator(&locator.MultiLocatorOptions{CloneDir: cloneDir}).Open(context.Background(), bundle)
+		if err != nil {
+			t.Fatalf("failed to open bundle: %v", err)
+		}
+
+		head, err := r.Head()
+		if err != nil {
+			t.Fatalf("failed to resolve HEAD: %v", err)
+		}
+		if head.Name() != plumbing.NewBranchReferenceName("main") {
+			t.Fatalf("expected HEAD to point to main, got %s", head.Name())
+		}
+
+		commit, err := r.CommitObject(head.Hash())
+		if err != nil {
+			t.Fatalf("failed to read HEAD commit: %v", err)
+		}
+		if commit.Message != "second\n" || commit.NumParents() != 1 {
+			t.Fatalf("unexpected HEAD commit: %q", commit.Message)
+		}
+
+		if _, err = r.Tag("v1"); err != nil {
+			t.Fatalf("failed to find tag: %v", err)
+		}
+
+		// the bundle is unpacked on disk, beneath the clone directory or in a temporary directory,
+		// so that CachedLocator keeps it as is rather than copying it to disk
+		fsStorer, ok := r.Storer.(*filesystem.Storage)
+		if !ok {
+			t.Fatalf("expected the bundle to be unpacked on disk, got a %T", r.Storer)
+		}
+		dir := fsStorer.Filesystem().Root()
+		if cloneDir != "" && !strings.HasPrefix(dir, cloneDir) {
+			t.Fatalf("expected the bundle to be unpacked beneath %s, got %s", cloneDir, dir)
+		}
+
+		// only the temporary directory is removed once done
+		if err = locator.RemoveTempDirs(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = os.Stat(dir); os.IsNotExist(err) != (cloneDir == "") {
+			t.Fatalf("expected %s to be removed (%v), got: %v", dir, cloneDir == "", err)
+		}
+	}
+}
+
+func TestBundleLocatorRefresh(t *testing.T) {
+	repo := fixture(t)
+	git(t, repo, "branch", "feature")
+	bundle := filepath.Join(t.TempDir(), "repo.bundle")
+	git(t, repo, "bundle", "create", bundle, "--all")
+
+	var loc = locator.MultiLocator(&locator.MultiLocatorOptions{CloneDir: t.TempDir()})
+	if _, err := loc.Open(context.Background(), bundle); err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+
+	// the bundle is recreated without the feature branch, and unpacked again into the same directory
+	git(t, repo, "branch", "-D", "feature")
+	git(t, repo, "commit", "-q", "--allow-empty", "-m", "third")
+	git(t, repo, "bundle", "create", bundle, "--all")
+
+	r, err := loc.Open(context.Background(), bundle)
+	if err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+
+	if _, err = r.Reference(plumbing.NewBranchReferenceName("feature"), false); err != plumbing.ErrReferenceNotFound {
+		t.Fatalf("expected the feature branch to be removed, got: %v", err)
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve HEAD: %v", err)
+	}
+	if commit, err := r.CommitObject(head.Hash()); err != nil || commit.Message != "third\n" {
+		t.Fatalf("expected HEAD to be the third commit, got: %v", err)
+	}
+}
+
+func TestBareLocator(t *testing.T) {
+	repo := fixture(t)
+	mirror := filepath.Join(t.TempDir(), "repo.git")
+	git(t, repo, "clone", "-q", "--mirror", repo, mirror)
+
+	// a second mirror that borrows objects from the first via objects/info/alternates
+	shared := filepath.Join(t.TempDir(), "shared.git")
+	git(t, repo, "clone", "-q", "--mirror", "--shared", mirror, shared)
+
+	for _, path := range []string{mirror, "file://" + mirror, shared} {
+		r, err := locator.MultiLocator(nil).Open(context.Background(), path)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", path, err)
+		}
+
+		head, err := r.Head()
+		if err != nil {
+			t.Fatalf("failed to resolve HEAD of %s: %v", path, err)
+		}
+
+		if _, err = r.CommitObject(head.Hash()); err != nil {
+			t.Fatalf("failed to read HEAD commit of %s: %v", path, err)
+		}
+	}
+}
+
+func TestBundleLocatorRejectsIncremental(t *testing.T) {
+	repo := fixture(t)
+	bundle := filepath.Join(t.TempDir(), "incremental.bundle")
+	git(t, repo, "bundle", "create", bundle, "HEAD~1..main")
+
+	if _, err := locator.MultiLocator(nil).Open(context.Background(), bundle); err == nil {
+		t.Fatalf("expected an error opening an incremental bundle")
+	}
+}
+
+func TestRemoteRefLister(t *testing.T) {
+	repo := fixture(t)
+
+	refs, err := locator.RemoteRefLister(nil).ListRefs(context.Background(), "file://"+filepath.ToSlash(repo))
+	if err != nil {
+		t.Fatalf("failed to list refs: %v", err)
+	}
+
+	var names = make(map[plumbing.ReferenceName]*plumbing.Reference)
+	for _, ref := range refs {
+		names[ref.Name()] = ref
+	}
+	main, tag := names[plumbing.NewBranchReferenceName("main")], names[plumbing.NewTagReferenceName("v1")]
+	if main == nil || tag == nil {
+		t.Fatalf("expected the main branch and the v1 tag to be listed, got: %v", refs)
+	}
+	if main.Hash() != tag.Hash() {
+		t.Errorf("expected v1 to point to the head of main, got %s and %s", tag.Hash(), main.Hash())
+	}
+	if head := names[plumbing.HEAD]; head == nil || head.Type() != plumbing.SymbolicReference || head.Target() != main.Name() {
+		t.Errorf("expected HEAD to point to main, got: %v", head)
+	}
+}
@@ -0,0 +1,75 @@
+package locator
+
+import (
+	"io"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/pkg/errors"
+)
+
+// Spill copies the objects and references of r, such as an in-memory repository, into a bare repository
+// in a new temporary directory, and returns it opened from there. The caller removes the directory
+// (the root of the returned repository's storage) once done with it.
+func Spill(r *git.Repository) (_ *git.Repository, err error) {
+	var dir string
+	if dir, err = os.MkdirTemp("", "mergestat"); err != nil {
+		return nil, errors.Wrap(err, "failed to create a temporary directory")
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(dir)
+		}
+	}()
+
+	var repo *git.Repository
+	if repo, err = git.PlainInit(dir, true); err != nil {
+		return nil, err
+	}
+	var dst = repo.Storer.(*filesystem.Storage)
+
+	// all the objects are written as a single packfile
+	iter, err := r.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []plumbing.Hash
+	if err = iter.ForEach(func(obj plumbing.EncodedObject) error {
+		hashes = append(hashes, obj.Hash())
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(hashes) > 0 {
+		pr, pw := io.Pipe()
+		go func() {
+			_, err := packfile.NewEncoder(pw, r.Storer, false).Encode(hashes, 10)
+			_ = pw.CloseWithError(err)
+		}()
+		if err = packfile.WritePackfileToObjectStorage(dst, pr); err != nil {
+			_ = pr.CloseWithError(err)
+			return nil, errors.Wrap(err, "failed to write packfile")
+		}
+	}
+
+	refs, err := r.Storer.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+	if err = refs.ForEach(dst.SetReference); err != nil {
+		return nil, err
+	}
+
+	// go-git's reference iterator doesn't always include HEAD, so copy it explicitly
+	if head, err := r.Storer.Reference(plumbing.HEAD); err == nil {
+		if err = dst.SetReference(head); err != nil {
+			return nil, err
+		}
+	}
+
+	return repo, nil
+}
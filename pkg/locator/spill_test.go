@@ -0,0 +1,64 @@
+package locator_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/mergestat/mergestat-lite/extensions/options"
+	"github.com/mergestat/mergestat-lite/pkg/locator"
+)
+
+func TestCachedLocatorSpillsInMemoryRepositories(t *testing.T) {
+	// an in-memory clone of a local repository
+	var opens int
+	inMemory := options.RepoLocatorFn(func(ctx context.Context, path string) (*gogit.Repository, error) {
+		opens++
+		return gogit.CloneContext(ctx, memory.NewStorage(), memfs.New(), &gogit.CloneOptions{URL: path})
+	})
+
+	path := fixture(t)
+	cached := locator.CachedLocator(inMemory)
+
+	repo, err := cached.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsStorer, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		t.Fatalf("expected the repository to be copied to disk, got a %T", repo.Storer)
+	}
+
+	// the copy on disk has the commits and references of the repository
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = repo.CommitObject(head.Hash()); err != nil {
+		t.Fatalf("failed to lookup HEAD commit: %v", err)
+	}
+	if _, err = repo.Reference(plumbing.NewTagReferenceName("v1"), false); err != nil {
+		t.Fatalf("expected the tag to be copied: %v", err)
+	}
+	if _, err = gogit.PlainOpen(fsStorer.Filesystem().Root()); err != nil {
+		t.Fatalf("failed to open the copy on disk: %v", err)
+	}
+
+	// and is only made once
+	if again, err := cached.Open(context.Background(), path); err != nil || again != repo || opens != 1 {
+		t.Fatalf("expected the cached copy, got %v (%v), opened %d times", again, err, opens)
+	}
+
+	// the copy is a temporary directory, removed once done with the repositories
+	if err = locator.RemoveTempDirs(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(fsStorer.Filesystem().Root()); !os.IsNotExist(err) {
+		t.Fatalf("expected the copy to be removed, got: %v", err)
+	}
+}
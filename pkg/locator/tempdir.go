@@ -0,0 +1,48 @@
+package locator
+
+import (
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// tempDirs holds the temporary directories the locators of this package leave repositories in, see RemoveTempDirs
+var tempDirs struct {
+	sync.Mutex
+	paths []string
+}
+
+// mkdirTemp creates a temporary directory, which is removed with RemoveTempDirs
+func mkdirTemp() (string, error) {
+	dir, err := os.MkdirTemp("", "mergestat")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a temporary directory")
+	}
+	keepTempDir(dir)
+	return dir, nil
+}
+
+// keepTempDir adds dir to the temporary directories removed with RemoveTempDirs
+func keepTempDir(dir string) {
+	tempDirs.Lock()
+	defer tempDirs.Unlock()
+	tempDirs.paths = append(tempDirs.paths, dir)
+}
+
+// RemoveTempDirs removes the temporary directories that the locators of this package cloned, unpacked or copied
+// (see CachedLocator) repositories to. The repositories opened from them can no longer be used after,
+// so it is meant to be called once done with all of them, such as when the program exits.
+func RemoveTempDirs() error {
+	tempDirs.Lock()
+	defer tempDirs.Unlock()
+
+	var failed error
+	for _, dir := range tempDirs.paths {
+		if err := os.RemoveAll(dir); err != nil && failed == nil {
+			failed = errors.Wrap(err, "failed to remove a temporary directory")
+		}
+	}
+	tempDirs.paths = nil
+	return failed
+}
@@ -1,4 +1,5 @@
-// Package mailmap implements a basic git mailmap parser. See this page: https://git-scm.com/docs/gitmailmap for additional context.
+// Package mailmap implements a git mailmap parser. See this page: https://git-scm.com/docs/gitmailmap for additional context.
+// Parsing and lookups follow the behaviour of git's own implementation (mailmap.c), including its precedence rules.
 package mailmap
 
 import "strings"
@@ -9,76 +10,114 @@ type NameAndEmail struct {
 }
 
 // MailMap maps names and emails found in commits to "proper" names and emails.
-// The map key is the "proper" pair, coresponding to a list of pairs to be matched.
-type MailMap map[NameAndEmail][]NameAndEmail
+// The map key is the (lowercased) email found in commits, as all matching starts with the email.
+type MailMap map[string]*entry
+
+// entry holds the replacements for a single commit email
+type entry struct {
+	// proper is used when the commit name isn't listed in names (from rules without a commit name).
+	// Empty fields are left unchanged by a lookup.
+	proper NameAndEmail
+
+	// names maps (lowercased) commit names to their replacement (from rules with a commit name)
+	names map[string]NameAndEmail
+}
 
 // Parse takes an input mailmap string and parses it into a MailMap struct
 func Parse(input string) (MailMap, error) {
-	// someone smarter can and should probably implement a better approach here.
-	// this implementation uses a bunch of string splitting on the characters: < and >
-	// and might have bugs for weird edge cases. See the tests for what's supported.
-	// See also: https://github.com/libgit2/libgit2/blob/main/src/mailmap.c
 	out := make(MailMap)
+	out.Add(input)
+	return out, nil
+}
+
+// Add parses an input mailmap string and adds its rules to mm. As with git, rules read later
+// take precedence over earlier ones, so call Add for each source in the order git reads them
+// (.mailmap, then mailmap.blob, then mailmap.file).
+func (mm MailMap) Add(input string) {
 	for _, line := range strings.Split(input, "\n") {
-		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "#") { // ignore comments
 			continue
 		}
 
-		var properName, properEmail, commitName, commitEmail string
-		s := strings.FieldsFunc(line, func(r rune) bool {
-			return r == '<' || r == '>'
-		})
 		// Proper Name <commit@email>
+		// <proper@email> <commit@email>
 		// Proper Name <proper@email> <commit@email>
 		// Proper Name <proper@email> Commit Name <commit@email>
-		switch len(s) {
-		case 2:
-			properName = strings.TrimSpace(s[0])
-			commitEmail = strings.TrimSpace(s[1])
-		case 4:
-			properName = strings.TrimSpace(s[0])
-			properEmail = strings.TrimSpace(s[1])
-			commitName = strings.TrimSpace(s[2])
-			commitEmail = strings.TrimSpace(s[3])
-		default:
+		name1, email1, rest, ok := parseNameAndEmail(line, false)
+		if !ok {
 			continue
 		}
 
-		if properName == "" && properEmail == "" {
-			continue
+		name2, email2, _, hasCommit := parseNameAndEmail(rest, true)
+		if !hasCommit { // the only email present is the one to match on
+			mm.add(name1, "", "", email1)
+		} else {
+			mm.add(name1, email1, name2, email2)
 		}
+	}
+}
 
-		proper := NameAndEmail{Name: properName, Email: properEmail}
-		if _, ok := out[proper]; !ok {
-			out[proper] = make([]NameAndEmail, 0)
-		}
+// parseNameAndEmail extracts an optional name followed by an <email> from the start of s,
+// returning whatever follows the email. The name is trimmed of whitespace, but the email is used verbatim.
+func parseNameAndEmail(s string, allowEmptyEmail bool) (name, email, rest string, ok bool) {
+	left := strings.IndexByte(s, '<')
+	if left < 0 {
+		return "", "", "", false
+	}
 
-		out[proper] = append(out[proper], NameAndEmail{Name: commitName, Email: commitEmail})
+	right := strings.IndexByte(s[left+1:], '>')
+	if right < 0 || (right == 0 && !allowEmptyEmail) {
+		return "", "", "", false
 	}
+	right += left + 1
 
-	return out, nil
+	return strings.TrimSpace(s[:left]), s[left+1 : right], s[right+1:], true
 }
 
-// Lookup receives a name/email pair and finds the first proper name/email pair
-func (mm MailMap) Lookup(commitLookup NameAndEmail) NameAndEmail {
-	for proper, commits := range mm {
-		for _, commit := range commits {
-			// case insensitive match
-
-			namesMatch := strings.EqualFold(commit.Name, commitLookup.Name)
-			emailsMatch := strings.EqualFold(commit.Email, commitLookup.Email)
-
-			// if the name to match on is unset, then short-circuit the name check
-			// because the email may still match
-			if commit.Name == "" {
-				namesMatch = true
-			}
-
-			if (namesMatch) && emailsMatch {
-				return proper
-			}
+func (mm MailMap) add(properName, properEmail, commitName, commitEmail string) {
+	key := strings.ToLower(commitEmail)
+	e, ok := mm[key]
+	if !ok {
+		e = &entry{}
+		mm[key] = e
+	}
+
+	if commitName == "" {
+		// only replace the fields this rule sets, leaving what earlier rules set otherwise
+		if properName != "" {
+			e.proper.Name = properName
+		}
+		if properEmail != "" {
+			e.proper.Email = properEmail
 		}
+		return
+	}
+
+	if e.names == nil {
+		e.names = make(map[string]NameAndEmail)
+	}
+	e.names[strings.ToLower(commitName)] = NameAndEmail{Name: properName, Email: properEmail}
+}
+
+// Lookup receives a name/email pair and returns the proper name/email pair for it.
+// Emails and names are matched case-insensitively, and a rule matching on both name and email
+// takes precedence over one matching on email alone. Fields the matching rule doesn't set are returned as-is.
+func (mm MailMap) Lookup(commitLookup NameAndEmail) NameAndEmail {
+	e, ok := mm[strings.ToLower(commitLookup.Email)]
+	if !ok {
+		return commitLookup
+	}
+
+	proper, ok := e.names[strings.ToLower(commitLookup.Name)]
+	if !ok {
+		proper = e.proper
+	}
+
+	if proper.Name != "" {
+		commitLookup.Name = proper.Name
+	}
+	if proper.Email != "" {
+		commitLookup.Email = proper.Email
 	}
 	return commitLookup
 }
@@ -73,3 +73,92 @@ Russell Belfer <rb@github.com> <arrbee@arrbee.com>
 		t.Fatalf("unexpected lookup result %s", l)
 	}
 }
+
+func TestForms(t *testing.T) {
+	m := `
+# Proper Name <commit@email>
+Jane Doe <jane@example.com>
+# <proper@email> <commit@email>
+<joe@example.com> <joe@laptop.(none)>
+# Proper Name <proper@email> <commit@email>
+Patrick D <patrick@some-email.com> <some-other@email.com>
+# Proper Name <proper@email> Commit Name <commit@email>
+Patrick DeVivo <patrick@some-email.com> Pat Dev <some@email.com>
+Other Name <other@example.com> Someone Else <some@email.com> # trailing comment
+`
+
+	mm, err := mailmap.Parse(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = []struct {
+		commit, proper mailmap.NameAndEmail
+	}{
+		{mailmap.NameAndEmail{Name: "jane", Email: "jane@example.com"}, mailmap.NameAndEmail{Name: "Jane Doe", Email: "jane@example.com"}},
+		{mailmap.NameAndEmail{Name: "Joe", Email: "joe@laptop.(none)"}, mailmap.NameAndEmail{Name: "Joe", Email: "joe@example.com"}},
+		{mailmap.NameAndEmail{Name: "pd", Email: "some-other@email.com"}, mailmap.NameAndEmail{Name: "Patrick D", Email: "patrick@some-email.com"}},
+		{mailmap.NameAndEmail{Name: "Pat Dev", Email: "some@email.com"}, mailmap.NameAndEmail{Name: "Patrick DeVivo", Email: "patrick@some-email.com"}},
+		{mailmap.NameAndEmail{Name: "Someone Else", Email: "some@email.com"}, mailmap.NameAndEmail{Name: "Other Name", Email: "other@example.com"}},
+		// commit name not listed for this email, and no email-only rule exists for it
+		{mailmap.NameAndEmail{Name: "Nobody", Email: "some@email.com"}, mailmap.NameAndEmail{Name: "Nobody", Email: "some@email.com"}},
+		// emails and names are matched case-insensitively
+		{mailmap.NameAndEmail{Name: "PAT DEV", Email: "Some@Email.com"}, mailmap.NameAndEmail{Name: "Patrick DeVivo", Email: "patrick@some-email.com"}},
+		{mailmap.NameAndEmail{Name: "Unknown", Email: "unknown@example.com"}, mailmap.NameAndEmail{Name: "Unknown", Email: "unknown@example.com"}},
+	}
+
+	for _, c := range cases {
+		if l := mm.Lookup(c.commit); l != c.proper {
+			t.Fatalf("unexpected lookup result for %v: expected %v, got %v", c.commit, c.proper, l)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	mm, err := mailmap.Parse(`
+Old Name <jane@example.com>
+<jane@proper.com> <jane@example.com>
+Jane Doe <jane@example.com>
+Work Jane <jane@work.com> Jane <jane@example.com>
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a later rule replaces the name set by an earlier one, but keeps the email it didn't set
+	if l := mm.Lookup(mailmap.NameAndEmail{Name: "J", Email: "jane@example.com"}); l.Name != "Jane Doe" || l.Email != "jane@proper.com" {
+		t.Fatalf("unexpected lookup result %v", l)
+	}
+
+	// a rule matching on name and email takes precedence over one matching on email alone
+	if l := mm.Lookup(mailmap.NameAndEmail{Name: "jane", Email: "jane@example.com"}); l.Name != "Work Jane" || l.Email != "jane@work.com" {
+		t.Fatalf("unexpected lookup result %v", l)
+	}
+
+	// sources read later take precedence
+	mm.Add("Janet <jane@example.com>")
+	if l := mm.Lookup(mailmap.NameAndEmail{Name: "J", Email: "jane@example.com"}); l.Name != "Janet" || l.Email != "jane@proper.com" {
+		t.Fatalf("unexpected lookup result %v", l)
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	mm, err := mailmap.Parse(`
+No Email Here
+Unterminated <jane@example.com
+Empty Email <>
+Proper <proper@example.com> <>
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := mm.Lookup(mailmap.NameAndEmail{Name: "jane", Email: "jane@example.com"}); l.Name != "jane" {
+		t.Fatalf("unexpected lookup result %v", l)
+	}
+
+	// an empty commit email is allowed as the second email
+	if l := mm.Lookup(mailmap.NameAndEmail{Name: "nobody", Email: ""}); l.Name != "Proper" || l.Email != "proper@example.com" {
+		t.Fatalf("unexpected lookup result %v", l)
+	}
+}
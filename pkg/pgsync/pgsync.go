@@ -20,6 +20,7 @@ type SyncOptions struct {
 	SchemaName string
 	TableName  string
 	Query      string
+	Args       []interface{} // arguments of the query, such as sql.Named parameters
 	Logger     *zerolog.Logger
 }
 
@@ -34,7 +35,7 @@ func Sync(ctx context.Context, options *SyncOptions) error {
 		return ctx.Err()
 	}
 
-	rows, err := options.MergeStat.QueryContext(ctx, options.Query)
+	rows, err := options.MergeStat.QueryContext(ctx, options.Query, options.Args...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,278 @@
+// Package query holds preset queries: the built in ones, and libraries of presets loaded from .sql files,
+// so that teams can share vetted queries (for instance by committing them to a repository).
+//
+// A preset file holds a single query, optionally preceded by a YAML front-matter between --- lines:
+//
+//	---
+//	name: commits-since
+//	description: Count commits per author since a date
+//	params:
+//	  - name: since
+//	    description: date to count commits from
+//	    default: "2023-01-01"
+//	  - name: limit
+//	    type: int
+//	    default: 10
+//	---
+//	SELECT author_email, count(*) AS commits FROM commits
+//	WHERE author_when > :since GROUP BY author_email ORDER BY commits DESC LIMIT :limit
+//
+// The name defaults to the name of the file, without its .sql extension. Parameters are bound to the
+// :name (or $name, @name) placeholders of the query, and those without a default are required.
+// The type of a parameter is text (the default), int or json.
+package query
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// Preset is a named query, built in or loaded from a file
+type Preset struct {
+	Name        string
+	Description string
+	Params      []Param
+	SQL         string
+
+	// Source is the path of the file the preset was loaded from, empty for built in presets
+	Source string
+}
+
+// Param is a parameter of a preset
+type Param struct {
+	Name        string
+	Description string
+	Type        string  // text, int or json
+	Default     *string // nil if the parameter is required
+}
+
+// frontMatter is the YAML front-matter of a preset file
+type frontMatter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Params      []struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Type        string          `json:"type"`
+		Default     json.RawMessage `json:"default"`
+	} `json:"params"`
+}
+
+// ParseValue converts the text value of a parameter to its type: text, int or json (which is bound as compacted text)
+func ParseValue(typ, value string) (interface{}, error) {
+	switch typ {
+	case "", "text":
+		return value, nil
+	case "int":
+		return strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case "json":
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(value)); err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	}
+	return nil, fmt.Errorf("unknown type %s, expected text, int or json", typ)
+}
+
+// ParsePreset parses the contents of a preset file, whose name (used if the front-matter does not set one) is name
+func ParsePreset(name string, b []byte) (*Preset, error) {
+	var preset = &Preset{Name: name, SQL: strings.TrimSpace(string(b))}
+
+	// the front-matter starts with a --- line, as the first line that is not blank, and ends with the next one
+	var front []byte
+	var lines = strings.SplitAfter(strings.TrimPrefix(string(b), "\ufeff"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.TrimSpace(line) != "---" {
+			break
+		}
+
+		var end = -1
+		for j := i + 1; j < len(lines) && end < 0; j++ {
+			if strings.TrimSpace(lines[j]) == "---" {
+				end = j
+			}
+		}
+		if end < 0 {
+			return nil, errors.New("front-matter is not closed with a --- line")
+		}
+		front = []byte(strings.Join(lines[i+1:end], ""))
+		preset.SQL = strings.TrimSpace(strings.Join(lines[end+1:], ""))
+		break
+	}
+
+	var fm frontMatter
+	if err := yaml.Unmarshal(front, &fm); err != nil {
+		return nil, errors.Wrap(err, "could not parse front-matter")
+	}
+	if fm.Name != "" {
+		preset.Name = fm.Name
+	}
+	preset.Description = fm.Description
+
+	for _, p := range fm.Params {
+		if p.Name == "" {
+			return nil, errors.New("every parameter must have a name")
+		}
+		var param = Param{Name: p.Name, Description: p.Description, Type: p.Type}
+		if param.Type == "" {
+			param.Type = "text"
+		}
+
+		if len(p.Default) > 0 && string(p.Default) != "null" {
+			// defaults are written as text, though YAML numbers and objects (for json parameters) are accepted as well
+			var value string
+			if err := json.Unmarshal(p.Default, &value); err != nil {
+				value = string(p.Default)
+			}
+			if _, err := ParseValue(param.Type, value); err != nil {
+				return nil, errors.Wrapf(err, "invalid default of parameter %s", p.Name)
+			}
+			param.Default = &value
+		}
+		preset.Params = append(preset.Params, param)
+	}
+
+	if preset.SQL == "" {
+		return nil, errors.New("preset has no query")
+	}
+	return preset, nil
+}
+
+// Bind returns the arguments to run the preset with: args (named arguments, such as sql.Named parameters), with text values
+// converted to the type of the parameter they set, followed by the defaults of the parameters missing from args.
+// A missing parameter without a default is an error.
+func (p *Preset) Bind(args []interface{}) ([]interface{}, error) {
+	var bound = make([]interface{}, 0, len(args)+len(p.Params))
+	var set = make(map[string]bool)
+
+	for _, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			set[named.Name] = true
+			if s, ok := named.Value.(string); ok {
+				if param := p.param(named.Name); param != nil {
+					v, err := ParseValue(param.Type, s)
+					if err != nil {
+						return nil, fmt.Errorf("invalid value of parameter %s: %v", named.Name, err)
+					}
+					arg = sql.Named(named.Name, v)
+				}
+			}
+		}
+		bound = append(bound, arg)
+	}
+
+	for _, param := range p.Params {
+		if set[param.Name] {
+			continue
+		}
+		if param.Default == nil {
+			return nil, fmt.Errorf("preset %s requires the parameter %s", p.Name, param.Name)
+		}
+		v, err := ParseValue(param.Type, *param.Default)
+		if err != nil {
+			return nil, err
+		}
+		bound = append(bound, sql.Named(param.Name, v))
+	}
+
+	return bound, nil
+}
+
+func (p *Preset) param(name string) *Param {
+	for i := range p.Params {
+		if p.Params[i].Name == name {
+			return &p.Params[i]
+		}
+	}
+	return nil
+}
+
+// Library is a set of presets, by name
+type Library struct {
+	presets map[string]*Preset
+}
+
+// DefaultDirs returns the directories presets are loaded from by default: the global ~/.config/mergestat/queries
+// (or under $XDG_CONFIG_HOME), and the .mergestat/queries directory of the repository at repoPath
+func DefaultDirs(repoPath string) []string {
+	var dirs []string
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, "mergestat", "queries"))
+	}
+
+	if repoPath != "" {
+		dirs = append(dirs, filepath.Join(repoPath, ".mergestat", "queries"))
+	}
+	return dirs
+}
+
+// Load returns the library of the built in presets, and of the presets in the .sql files of dirs.
+// Presets loaded later override the presets of the same name, so that dirs are listed from the most general
+// to the most specific. Directories that do not exist are skipped.
+func Load(dirs ...string) (*Library, error) {
+	var library = &Library{presets: make(map[string]*Preset)}
+	for _, p := range builtins {
+		library.presets[p.Name] = p
+	}
+
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not read preset file")
+			}
+
+			preset, err := ParsePreset(strings.TrimSuffix(filepath.Base(file), ".sql"), b)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not load preset %s", file)
+			}
+			preset.Source = file
+			library.presets[preset.Name] = preset
+		}
+	}
+
+	return library, nil
+}
+
+// Find returns the preset called name
+func (l *Library) Find(name string) (*Preset, bool) {
+	p, ok := l.presets[name]
+	return p, ok
+}
+
+// Presets returns the presets of the library, sorted by name
+func (l *Library) Presets() []*Preset {
+	var presets = make([]*Preset, 0, len(l.presets))
+	for _, p := range l.presets {
+		presets = append(presets, p)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
@@ -0,0 +1,115 @@
+package query_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mergestat/mergestat-lite/pkg/query"
+)
+
+const preset = `
+---
+name: commits-since
+description: Count commits per author since a date
+params:
+  - name: since
+    description: date to count commits from
+  - name: limit
+    type: int
+    default: 10
+  - name: filter
+    type: json
+    default: {"bots": false}
+---
+SELECT author_email, count(*) AS commits FROM commits
+WHERE author_when > :since GROUP BY author_email ORDER BY commits DESC LIMIT :limit
+`
+
+func TestParsePreset(t *testing.T) {
+	p, err := query.ParsePreset("file-name", []byte(preset))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "commits-since" || p.Description != "Count commits per author since a date" {
+		t.Fatalf("unexpected name or description: %q, %q", p.Name, p.Description)
+	}
+	if p.SQL[:6] != "SELECT" {
+		t.Fatalf("expected the query to follow the front-matter, got: %q", p.SQL)
+	}
+	if len(p.Params) != 3 || p.Params[0].Default != nil || *p.Params[1].Default != "10" || p.Params[1].Type != "int" {
+		t.Fatalf("unexpected parameters: %+v", p.Params)
+	}
+
+	if _, err = p.Bind(nil); err == nil {
+		t.Fatal("expected an error for a missing required parameter")
+	}
+
+	args, err := p.Bind([]interface{}{sql.Named("since", "2023-01-01"), sql.Named("limit", "5")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprint([]interface{}{sql.Named("since", "2023-01-01"), sql.Named("limit", int64(5)), sql.Named("filter", `{"bots":false}`)})
+	if fmt.Sprint(args) != expected {
+		t.Fatalf("expected arguments %s, got: %s", expected, fmt.Sprint(args))
+	}
+
+	if _, err = p.Bind([]interface{}{sql.Named("since", "2023-01-01"), sql.Named("limit", "ten")}); err == nil {
+		t.Fatal("expected an error for a parameter of the wrong type")
+	}
+}
+
+func TestParsePresetWithoutFrontMatter(t *testing.T) {
+	p, err := query.ParsePreset("all-commits", []byte("-- every commit\nSELECT * FROM commits\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "all-commits" || p.SQL != "-- every commit\nSELECT * FROM commits" || len(p.Params) != 0 {
+		t.Fatalf("unexpected preset: %+v", p)
+	}
+
+	if _, err = query.ParsePreset("unclosed", []byte("---\nname: unclosed\nSELECT 1")); err == nil {
+		t.Fatal("expected an error for a front-matter that is not closed")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	global, local := t.TempDir(), t.TempDir()
+	for path, contents := range map[string]string{
+		filepath.Join(global, "commit-info.sql"): "SELECT hash FROM commits",
+		filepath.Join(global, "shared.sql"):      "SELECT 'global'",
+		filepath.Join(local, "shared.sql"):       "SELECT 'local'",
+		filepath.Join(local, "notes.txt"):        "not a preset",
+	} {
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	library, err := query.Load(global, local, filepath.Join(local, "missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = []struct{ name, sql, source string }{
+		{"commit-info", "SELECT hash FROM commits", filepath.Join(global, "commit-info.sql")},
+		{"shared", "SELECT 'local'", filepath.Join(local, "shared.sql")},
+		{"commits-per-author", "", ""},
+	}
+	for _, c := range cases {
+		p, found := library.Find(c.name)
+		if !found {
+			t.Fatalf("expected to find preset %s", c.name)
+		}
+		if (c.sql != "" && p.SQL != c.sql) || p.Source != c.source {
+			t.Errorf("unexpected preset %s: %q from %q", c.name, p.SQL, p.Source)
+		}
+	}
+
+	if _, found := library.Find("notes"); found {
+		t.Error("expected only .sql files to be loaded")
+	}
+}
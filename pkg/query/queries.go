@@ -1,35 +1,73 @@
 package query
 
-var queries = map[string]string{
-	// show all commit information from repository, in the current directory
-	"commit-info": "SELECT * FROM commits",
-
-	// list all distinct author information from commits in current directory
-	"distinct-author-emails": "SELECT DISTINCT( author_email ) FROM commits",
-
-	// list all count of commits, grouping by authors, in descending author
-	"commits-per-author": `SELECT 
+// builtins are the presets available without any file, which presets loaded from files may override
+var builtins = []*Preset{
+	{
+		Name:        "commit-info",
+		Description: "Show all commit information from the repository",
+		SQL:         "SELECT * FROM commits",
+	},
+	{
+		Name:        "distinct-author-emails",
+		Description: "List the distinct author emails of commits",
+		SQL:         "SELECT DISTINCT( author_email ) FROM commits",
+	},
+	{
+		Name:        "commits-per-author",
+		Description: "Count commits per author email, most commits first",
+		SQL: `SELECT 
 		author_email, count(*) 
 		FROM commits GROUP BY author_email 
 		ORDER BY count(*) DESC`,
-
-	"author-stats": `SELECT count(DISTINCT commits.hash) AS commits, SUM(additions) AS additions, SUM(deletions) AS deletions, author_email
+	},
+	{
+		Name:        "commits-per-identity",
+		Description: "Count commits per person (see --identities), excluding bots",
+		SQL: `SELECT
+		author_identity(author_name, author_email) AS author, author_org(author_name, author_email) AS org, count(*) AS commits
+		FROM commits WHERE NOT is_bot(author_name, author_email)
+		GROUP BY author ORDER BY commits DESC`,
+	},
+	{
+		Name:        "commits-per-org",
+		Description: "Count commits and distinct authors per organization (see --identities), excluding bots",
+		SQL: `SELECT
+		author_org(author_name, author_email) AS org, count(*) AS commits,
+		count(DISTINCT author_identity(author_name, author_email)) AS authors
+		FROM commits WHERE NOT is_bot(author_name, author_email)
+		GROUP BY org ORDER BY commits DESC`,
+	},
+	{
+		Name:        "author-stats",
+		Description: "Count commits, additions and deletions per author email, excluding merge commits",
+		SQL: `SELECT count(DISTINCT commits.hash) AS commits, SUM(additions) AS additions, SUM(deletions) AS deletions, author_email
 		FROM commits, stats('', commits.hash)
 		WHERE commits.parents < 2
 		GROUP BY author_email ORDER BY commits`,
-
-	"author-commits-dow": `SELECT
+	},
+	{
+		Name:        "author-commits-dow",
+		Description: "Count of commits per day of the week, in the author's local time",
+		SQL: `SELECT
 			count(*) AS commits,
-			count(CASE WHEN strftime('%w',author_when)='0' THEN 1 END) AS sunday,
-			count(CASE WHEN strftime('%w',author_when)='1' THEN 1 END) AS monday,
-			count(CASE WHEN strftime('%w',author_when)='2' THEN 1 END) AS tuesday,
-			count(CASE WHEN strftime('%w',author_when)='3' THEN 1 END) AS wednesday,
-			count(CASE WHEN strftime('%w',author_when)='4' THEN 1 END) AS thursday,
-			count(CASE WHEN strftime('%w',author_when)='5' THEN 1 END) AS friday,
-			count(CASE WHEN strftime('%w',author_when)='6' THEN 1 END) AS saturday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='0' THEN 1 END) AS sunday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='1' THEN 1 END) AS monday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='2' THEN 1 END) AS tuesday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='3' THEN 1 END) AS wednesday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='4' THEN 1 END) AS thursday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='5' THEN 1 END) AS friday,
+			count(CASE WHEN strftime('%w',to_timezone(author_when,author_tz_offset))='6' THEN 1 END) AS saturday,
 			author_email
 		FROM commits GROUP BY author_email ORDER BY commits`,
+	},
 }
 
-// Find finds and return the named query
-func Find(name string) (string, bool) { q, ok := queries[name]; return q, ok }
+// Find finds and return the named query, among the built in presets
+func Find(name string) (string, bool) {
+	for _, p := range builtins {
+		if p.Name == name {
+			return p.SQL, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,141 @@
+package shell
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// catalog lists what can be queried in the shell: the tables and views of the database,
+// the tables registered as (eponymous) virtual table modules, such as commits, and SQL functions
+type catalog struct {
+	tables    []*table // sorted by name
+	functions []string // sorted
+}
+
+type table struct {
+	name    string
+	sql     string // CREATE statement, for tables and views of the database only
+	columns []*column
+}
+
+type column struct {
+	name, typ string
+
+	// hidden is set for the hidden columns of virtual tables, which are the arguments of table-valued functions,
+	// as in commits(repository, rev)
+	hidden bool
+}
+
+// queryer is implemented by *sql.DB and *sql.Conn
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func loadCatalog(ctx context.Context, db queryer) (*catalog, error) {
+	var c catalog
+	var err error
+
+	var schema = make(map[string]string)
+	if err = queryStrings(ctx, db, func(values ...string) { schema[values[0]] = values[1] },
+		"SELECT name, sql FROM sqlite_schema WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' "+
+			"UNION ALL SELECT name, sql FROM sqlite_temp_schema WHERE type IN ('table', 'view')"); err != nil {
+		return nil, err
+	}
+
+	// modules that are not eponymous (such as fts5) have to be created first, so they fail below and are left out,
+	// and so are the pragma_* and sqlite_* modules built into SQLite
+	var names []string
+	if err = queryStrings(ctx, db, func(values ...string) { names = append(names, values[0]) },
+		"SELECT name FROM pragma_module_list WHERE name NOT LIKE 'pragma_%' AND name NOT LIKE 'sqlite_%'"); err != nil {
+		return nil, err
+	}
+	for name := range schema {
+		names = append(names, name)
+	}
+
+	var seen = make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		var t = &table{name: name, sql: schema[name]}
+		if err := queryStrings(ctx, db, func(values ...string) {
+			t.columns = append(t.columns, &column{name: values[0], typ: values[1], hidden: values[2] == "1"})
+		}, "SELECT name, type, hidden FROM pragma_table_xinfo(?)", name); err != nil || len(t.columns) == 0 {
+			continue
+		}
+		c.tables = append(c.tables, t)
+	}
+	sort.Slice(c.tables, func(i, j int) bool { return c.tables[i].name < c.tables[j].name })
+
+	if err = queryStrings(ctx, db, func(values ...string) { c.functions = append(c.functions, values[0]) },
+		"SELECT DISTINCT name FROM pragma_function_list ORDER BY name"); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// queryStrings calls fn with the values of each row returned by query, as strings (NULL is empty)
+func queryStrings(ctx context.Context, db queryer, fn func(values ...string), query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	var container = make([]sql.NullString, len(columns))
+	var pointers = make([]interface{}, len(columns))
+	for i := range pointers {
+		pointers[i] = &container[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		var values = make([]string, len(columns))
+		for i, value := range container {
+			values[i] = value.String
+		}
+		fn(values...)
+	}
+	return rows.Err()
+}
+
+// lookup returns the table with the given name (case insensitive), or nil
+func (c *catalog) lookup(name string) *table {
+	for _, t := range c.tables {
+		if strings.EqualFold(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// schema returns the CREATE statement of the table. Virtual tables are described as they are declared
+// by their module, with the arguments of table-valued functions as HIDDEN columns.
+func (t *table) schema() string {
+	if t.sql != "" {
+		return t.sql + ";"
+	}
+
+	var columns = make([]string, len(t.columns))
+	for i, c := range t.columns {
+		columns[i] = strings.TrimSpace(fmt.Sprintf("%s %s", c.name, c.typ))
+		if c.hidden {
+			columns[i] += " HIDDEN"
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n);", t.name, strings.Join(columns, ",\n  "))
+}
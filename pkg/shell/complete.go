@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+)
+
+// keywords are the SQL keywords offered for completion, alongside the names in the catalog
+var keywords = []string{
+	"ALL", "AND", "AS", "ASC", "BEGIN", "BETWEEN", "BY", "CASE", "CAST", "COMMIT", "CREATE", "CROSS", "DELETE",
+	"DESC", "DISTINCT", "DROP", "ELSE", "END", "EXCEPT", "EXISTS", "EXPLAIN", "FROM", "GLOB", "GROUP", "HAVING",
+	"IN", "INDEX", "INNER", "INSERT", "INTERSECT", "INTO", "IS", "JOIN", "LEFT", "LIKE", "LIMIT", "NOT", "NULL",
+	"OFFSET", "ON", "OR", "ORDER", "OUTER", "OVER", "PARTITION", "PRAGMA", "RECURSIVE", "ROLLBACK", "SELECT",
+	"SET", "TABLE", "TEMP", "THEN", "UNION", "UPDATE", "USING", "VALUES", "VIEW", "WHEN", "WHERE", "WINDOW", "WITH",
+}
+
+// complete returns the candidates to complete the word ending the input with, and where that word starts in input.
+// SQL is completed with keywords, and the names of tables, columns (including hidden ones) and functions,
+// once the first character of the word is typed.
+// Meta-commands, and their arguments, are completed as well.
+func (c *catalog) complete(input string) (candidates []string, start int) {
+	start = len(input)
+	for start > 0 && isWordByte(input[start-1]) {
+		start--
+	}
+	word := input[start:]
+
+	var names []string
+	if fields := strings.Fields(input); strings.HasPrefix(input, ".") && len(fields) > 0 {
+		switch {
+		case len(fields) == 1 && start > 0 && !strings.HasSuffix(input, " "):
+			// the meta-command itself, including its leading dot
+			start, word = 0, input
+			for _, m := range metaCommands {
+				names = append(names, m.name)
+			}
+		case fields[0] == ".schema":
+			for _, t := range c.tables {
+				names = append(names, t.name)
+			}
+		case fields[0] == ".format":
+			names = display.Formats()
+		case fields[0] == ".timer":
+			names = []string{"on", "off"}
+		}
+	} else if word != "" {
+		names = append(names, keywords...)
+		names = append(names, c.functions...)
+		for _, t := range c.tables {
+			names = append(names, t.name)
+			for _, col := range t.columns {
+				names = append(names, col.name)
+			}
+		}
+	}
+
+	// names are matched regardless of case, and completed in lowercase if that is what was typed
+	var lower = strings.ToLower(word) == word
+	var seen = make(map[string]bool)
+	for _, name := range names {
+		if lower {
+			name = strings.ToLower(name)
+		}
+		if !seen[name] && len(name) > len(word) && strings.EqualFold(name[:len(word)], word) {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+
+	return candidates, start
+}
+
+// commonPrefix returns the longest prefix shared by all of candidates
+func commonPrefix(candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	prefix := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
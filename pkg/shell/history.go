@@ -0,0 +1,174 @@
+package shell
+
+import (
+	"os"
+	"strings"
+)
+
+// maxHistory is the number of entries of history kept, and loaded from the history file
+const maxHistory = 100
+
+// history holds what was entered in the shell, most recent last, and where the user is when going through it
+// with the up and down keys. It replaces the history of golang.org/x/term, which cannot be loaded from a file
+// but by typing the lines again.
+type history struct {
+	entries []string
+	index   int    // of the entry shown, len(entries) while on the line being typed
+	pending string // the line being typed, shown again when going down past the most recent entry
+}
+
+// add appends an entry, and goes back to the line being typed
+func (h *history) add(entry string) {
+	if h.entries = append(h.entries, entry); len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+	}
+	h.reset()
+}
+
+// reset goes back to the line being typed, as when a new line is read
+func (h *history) reset() { h.index, h.pending = len(h.entries), "" }
+
+// previous returns the entry before the one shown, or false if the oldest one is.
+// line is what is being edited, kept to be shown again if it is the line being typed.
+func (h *history) previous(line string) (string, bool) {
+	if h.index == 0 {
+		return "", false
+	}
+	if h.index == len(h.entries) {
+		h.pending = line
+	}
+	h.index--
+	return h.entries[h.index], true
+}
+
+// next returns the entry after the one shown (or the line being typed, after the most recent one),
+// or false if the line being typed is shown
+func (h *history) next() (string, bool) {
+	if h.index >= len(h.entries) {
+		return "", false
+	}
+	if h.index++; h.index == len(h.entries) {
+		return h.pending, true
+	}
+	return h.entries[h.index], true
+}
+
+// loadHistory loads the last entries of the history file, one per line (see escapeEntry),
+// and cuts the file back to them, as saveHistory only ever appends to it
+func (s *Shell) loadHistory() {
+	if s.opts.HistoryFile == "" {
+		return
+	}
+	b, err := os.ReadFile(s.opts.HistoryFile)
+	if err != nil {
+		return
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+		_ = os.WriteFile(s.opts.HistoryFile, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+
+	for _, line := range lines {
+		s.history.add(unescapeEntry(line))
+	}
+}
+
+// saveHistory adds what was entered (a meta-command, or the lines of one or more statements) to the history,
+// joined on a single line (see oneLine), and appends it to the history file if there is one.
+// History is only kept in a terminal.
+func (s *Shell) saveHistory(entry string) {
+	if entry = oneLine(entry); s.history == nil || entry == "" {
+		return
+	}
+	s.history.add(entry)
+
+	if s.opts.HistoryFile == "" {
+		return
+	}
+	f, err := os.OpenFile(s.opts.HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.WriteString(escapeEntry(entry) + "\n")
+}
+
+// escapeEntry returns entry as a line of the history file, with its backslashes and line breaks escaped
+func escapeEntry(entry string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(entry)
+}
+
+// unescapeEntry returns the entry of a line of the history file (see escapeEntry). Any other backslash
+// is kept as it is, as are those of the lines saved before entries were escaped.
+func unescapeEntry(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && (line[i+1] == '\\' || line[i+1] == 'n') {
+			if i++; line[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte('\\')
+			}
+			continue
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
+
+// oneLine joins the lines of text with single spaces, for statements typed over several lines to be recalled
+// as a single line, which is all the terminal edits. Line comments are dropped, as they would comment out
+// the lines joined after them, and line breaks in block comments become spaces. Those in strings and quoted
+// identifiers are part of the statement, so they are kept (and escaped in the history file, see escapeEntry).
+func oneLine(text string) string {
+	var b []byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[' || (c == '/' && strings.HasPrefix(text[i:], "/*")):
+			start, closing := i+1, string(c)
+			switch c {
+			case '[':
+				closing = "]"
+			case '/':
+				start, closing = i+2, "*/"
+			}
+			end := len(text)
+			if n := strings.Index(text[start:], closing); n >= 0 {
+				end = start + n + len(closing)
+			}
+			if c == '/' {
+				b = append(b, strings.ReplaceAll(text[i:end], "\n", " ")...)
+			} else {
+				b = append(b, text[i:end]...)
+			}
+			i = end - 1
+
+		case c == '-' && strings.HasPrefix(text[i:], "--"):
+			if n := strings.IndexByte(text[i:], '\n'); n >= 0 {
+				i += n - 1 // the line break is joined as any other
+			} else {
+				i = len(text)
+			}
+
+		case c == '\n':
+			b = []byte(strings.TrimRight(string(b), " \t\r"))
+			for i+1 < len(text) && (text[i+1] == ' ' || text[i+1] == '\t') {
+				i++
+			}
+			if len(b) > 0 {
+				b = append(b, ' ')
+			}
+
+		default:
+			b = append(b, c)
+		}
+	}
+	return strings.TrimSpace(string(b))
+}
@@ -0,0 +1,242 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOneLine(t *testing.T) {
+	var cases = []struct {
+		text, expected string
+	}{
+		{".tables\n", ".tables"},
+		{"SELECT 1;", "SELECT 1;"},
+		{"SELECT\tname\n", "SELECT\tname"},
+		{"SELECT *\n  FROM people\n\n\tWHERE age > 30;\n", "SELECT * FROM people WHERE age > 30;"},
+		{"SELECT * -- everyone\nFROM people; -- done\n", "SELECT * FROM people;"},
+		{"SELECT '--not a comment',\n\"a\nb\" FROM people;", "SELECT '--not a comment', \"a\nb\" FROM people;"},
+		{"SELECT 'one\n  two'\n  FROM t;", "SELECT 'one\n  two' FROM t;"},
+		{"SELECT [--]\nFROM t; /* it's\n-- a comment */", "SELECT [--] FROM t; /* it's -- a comment */"},
+		{"SELECT '--\n", "SELECT '--"},
+	}
+	for _, c := range cases {
+		if line := oneLine(c.text); line != c.expected {
+			t.Errorf("oneLine(%q) = %q, want %q", c.text, line, c.expected)
+		}
+	}
+}
+
+func TestEscapeEntry(t *testing.T) {
+	var cases = []struct {
+		entry, line string
+	}{
+		{"SELECT 1;", "SELECT 1;"},
+		{"SELECT 'a\nb';", `SELECT 'a\nb';`},
+		{`SELECT '\n', '\';`, `SELECT '\\n', '\\';`},
+		{"SELECT '\\\n';", `SELECT '\\\n';`},
+	}
+	for _, c := range cases {
+		if line := escapeEntry(c.entry); line != c.line {
+			t.Errorf("escapeEntry(%q) = %q, want %q", c.entry, line, c.line)
+		}
+		if entry := unescapeEntry(c.line); entry != c.entry {
+			t.Errorf("unescapeEntry(%q) = %q, want %q", c.line, entry, c.entry)
+		}
+	}
+
+	// the backslashes of lines saved before entries were escaped are kept
+	if entry := unescapeEntry(`SELECT '\t', '\'`); entry != `SELECT '\t', '\'` {
+		t.Errorf("expected other backslashes to be kept, got %q", entry)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	var h history
+	if _, ok := h.previous("typed"); ok {
+		t.Fatal("expected no previous entry in an empty history")
+	}
+
+	h.add("one")
+	h.add("two")
+
+	var steps = []struct {
+		previous bool
+		entry    string
+		ok       bool
+	}{
+		{true, "two", true},
+		{true, "one", true},
+		{true, "", false},
+		{false, "two", true},
+		{false, "typed", true}, // the line being typed is kept
+		{false, "", false},
+	}
+	for i, step := range steps {
+		var entry string
+		var ok bool
+		if step.previous {
+			entry, ok = h.previous("typed")
+		} else {
+			entry, ok = h.next()
+		}
+		if entry != step.entry || ok != step.ok {
+			t.Fatalf("step %d: expected %q (%v), got %q (%v)", i, step.entry, step.ok, entry, ok)
+		}
+	}
+
+	// a new line starts from the most recent entry again
+	h.previous("typed")
+	h.reset()
+	if entry, _ := h.previous(""); entry != "two" {
+		t.Fatalf("expected two after a reset, got %q", entry)
+	}
+
+	for i := 0; i < maxHistory; i++ {
+		h.add("more")
+	}
+	if len(h.entries) != maxHistory || h.entries[0] != "more" {
+		t.Fatalf("expected the %d most recent entries, got %d", maxHistory, len(h.entries))
+	}
+}
+
+func TestHistoryKeysComplete(t *testing.T) {
+	s, _ := newTestShell(t)
+	s.history = &history{}
+	s.history.add("SELECT 'é';")
+	s.history.add(".tables")
+
+	var steps = []struct {
+		key      rune
+		expected string
+	}{
+		{keyPrevious, ".tables"},
+		{keyPrevious, "SELECT 'é';"},
+		{keyPrevious, "SELECT 'é';"}, // the oldest entry stays
+		{keyNext, ".tables"},
+		{keyNext, "SEL"},
+		{keyNext, "SEL"},
+	}
+	// SEL is being typed, and the cursor goes to the end of each entry shown
+	var line, pos = "SEL", 3
+	for i, step := range steps {
+		var ok bool
+		if line, pos, ok = s.autoComplete(line, pos, step.key); line != step.expected || pos != len(step.expected) || !ok {
+			t.Fatalf("step %d: expected %q, got %q at %d (%v)", i, step.expected, line, pos, ok)
+		}
+	}
+}
+
+func TestShellHistory(t *testing.T) {
+	var file, initial = filepath.Join(t.TempDir(), "history"), "SELECT\t1;\n\n.tables\n"
+	if err := os.WriteFile(file, []byte(initial), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := newTestShell(t)
+	s.opts.HistoryFile, s.history = file, &history{}
+
+	// loading the history runs nothing, and tabs are kept as they are
+	s.loadHistory()
+	if expected := []string{"SELECT\t1;", ".tables"}; !reflect.DeepEqual(s.history.entries, expected) {
+		t.Fatalf("expected %q to be loaded, got %q", expected, s.history.entries)
+	}
+
+	run(t, s,
+		"CREATE TABLE people (name TEXT);",
+		"INSERT INTO people",
+		"  VALUES ('alice'); SELECT",
+		"",
+		"  count(*) FROM people;",
+		"SELECT 'a",
+		"b\\';",
+		".format csv",
+	)
+
+	var expected = []string{
+		"SELECT\t1;",
+		".tables",
+		"CREATE TABLE people (name TEXT);",
+		"INSERT INTO people VALUES ('alice'); SELECT count(*) FROM people;",
+		"SELECT 'a\nb\\';",
+		".format csv",
+	}
+	if !reflect.DeepEqual(s.history.entries, expected) {
+		t.Fatalf("expected history %q, got %q", expected, s.history.entries)
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines = []string{expected[2], expected[3], `SELECT 'a\nb\\';`, expected[5]}
+	if saved := string(b); saved != initial+strings.Join(lines, "\n")+"\n" {
+		t.Fatalf("expected every statement to be saved on a line, got %q", saved)
+	}
+
+	// the line breaks in strings are loaded back
+	s.history = &history{}
+	s.loadHistory()
+	if !reflect.DeepEqual(s.history.entries, expected) {
+		t.Fatalf("expected history %q to be loaded, got %q", expected, s.history.entries)
+	}
+}
+
+func TestShellHistoryFileCut(t *testing.T) {
+	var file, lines = filepath.Join(t.TempDir(), "history"), make([]string, maxHistory+10)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("SELECT %d;", i)
+	}
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, _ := newTestShell(t)
+	s.opts.HistoryFile, s.history = file, &history{}
+	s.loadHistory()
+
+	// only the entries loaded are kept in the file
+	var kept = lines[10:]
+	if !reflect.DeepEqual(s.history.entries, kept) {
+		t.Fatalf("expected the last %d entries to be loaded, got %d", maxHistory, len(s.history.entries))
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved := string(b); saved != strings.Join(kept, "\n")+"\n" {
+		t.Fatalf("expected the file to be cut to the last %d entries, got %q", maxHistory, saved)
+	}
+}
+
+func TestShellHistoryWithoutTerminal(t *testing.T) {
+	var file = filepath.Join(t.TempDir(), "history")
+
+	s, _ := newTestShell(t)
+	s.opts.HistoryFile = file
+
+	run(t, s, "SELECT 1;")
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("expected no history to be saved without a terminal, got: %v", err)
+	}
+}
+
+func TestHistoryKeys(t *testing.T) {
+	r, w := io.Pipe()
+	in := newInput(r)
+	go func() {
+		_, _ = w.Write([]byte("a\x1b[A\x1b[B\x10\x0e\x1b[Cb"))
+		_ = w.Close()
+	}()
+
+	b, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "a" + string([]rune{keyPrevious, keyNext, keyPrevious, keyNext}) + "\x1b[Cb"; string(b) != expected {
+		t.Fatalf("expected %q, got %q", expected, b)
+	}
+}
@@ -0,0 +1,105 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
+const keyCtrlC = 3
+
+// keyPrevious and keyNext are read in place of the up and down keys (and of ctrl+p and ctrl+n), for the shell to go
+// through its own history (see Shell.autoComplete) rather than golang.org/x/term. They are in the private use area
+// of Unicode, as the keys of the same name of macOS are.
+const (
+	keyPrevious = '\uf700'
+	keyNext     = '\uf701'
+)
+
+var historyKeys = strings.NewReplacer("\x1b[A", string(keyPrevious), "\x10", string(keyPrevious), "\x1b[B", string(keyNext), "\x0e", string(keyNext))
+
+// input reads the terminal in the background, so that ctrl+c interrupts a running statement rather than
+// being left unread until the next line is read. While a line is read, ctrl+c discards it instead of
+// ending the terminal, as golang.org/x/term would.
+type input struct {
+	chunks      chan []byte
+	buf         []byte // read but not consumed yet
+	interrupted bool   // set when ctrl+c discards a line
+}
+
+func newInput(r io.Reader) *input {
+	var in = &input{chunks: make(chan []byte)}
+	go func() {
+		defer close(in.chunks)
+		for {
+			var chunk = make([]byte, 256)
+			n, err := r.Read(chunk)
+			if n > 0 {
+				in.chunks <- chunk[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return in
+}
+
+func (in *input) Read(p []byte) (int, error) {
+	if len(in.buf) == 0 {
+		chunk, ok := <-in.chunks
+		if !ok {
+			return 0, io.EOF
+		}
+		in.buf = replaceHistoryKeys(chunk)
+	}
+
+	n := copy(p, in.buf)
+	in.buf = in.buf[n:]
+
+	// ctrl+c ends the line, which is then discarded
+	for i := range p[:n] {
+		if p[i] == keyCtrlC {
+			p[i], in.interrupted = '\r', true
+		}
+	}
+	return n, nil
+}
+
+// watch returns a context cancelled when ctrl+c is pressed, until stop is called.
+// Other keys pressed in the meantime are kept, to be read with the next line.
+func (in *input) watch(ctx context.Context) (_ context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	var done, stopped = make(chan struct{}), make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case chunk, ok := <-in.chunks:
+				if !ok {
+					return
+				}
+				if bytes.IndexByte(chunk, keyCtrlC) >= 0 {
+					cancel()
+					continue
+				}
+				in.buf = append(in.buf, replaceHistoryKeys(chunk)...)
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(done)
+		<-stopped
+		cancel()
+	}
+}
+
+// replaceHistoryKeys replaces the up and down keys of chunk with keyPrevious and keyNext
+func replaceHistoryKeys(chunk []byte) []byte {
+	return []byte(historyKeys.Replace(string(chunk)))
+}
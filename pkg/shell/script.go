@@ -0,0 +1,185 @@
+package shell
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+)
+
+// Statement is a statement of a script, with the line it starts on (from 1)
+type Statement struct {
+	SQL  string
+	Line int
+}
+
+// ParseScript splits script into statements (see SplitStatements). The text after the last semicolon is
+// a statement of its own, so that the semicolon ending a script is optional. Statements that are empty,
+// or only hold comments, are left out.
+func ParseScript(script string) []Statement {
+	statements, rest := SplitStatements(script)
+	if strings.TrimSpace(rest) != "" {
+		statements = append(statements, strings.TrimSpace(rest))
+	}
+
+	var parsed []Statement
+	var offset int // of the end of the previous statement, as statements are found in order
+	for _, statement := range statements {
+		// statements are trimmed, the line is the one of their first token (after any leading comment)
+		at := offset + strings.Index(script[offset:], statement)
+		offset = at + len(statement)
+
+		start := skipComments(statement)
+		if start == len(statement) || statement[start:] == ";" {
+			continue
+		}
+		parsed = append(parsed, Statement{SQL: statement, Line: 1 + strings.Count(script[:at+start], "\n")})
+	}
+	return parsed
+}
+
+// skipComments returns the position of the first character of s that is neither a space nor part of a comment
+func skipComments(s string) int {
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 1
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 4
+		case s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return len(s)
+}
+
+// StatementError is the error of a statement of a script
+type StatementError struct {
+	Statement Statement
+	Err       error
+}
+
+func (e *StatementError) Error() string { return fmt.Sprintf("line %d: %v", e.Statement.Line, e.Err) }
+
+func (e *StatementError) Unwrap() error { return e.Err }
+
+// ScriptOptions configures RunScript
+type ScriptOptions struct {
+	// Format is the output format of the results (see display.Write), table if empty
+	Format string
+
+	// Template is the template of the template format (see display.Options)
+	Template string
+
+	// Args are bound to the statements, which may each use any of them (or none)
+	Args []interface{}
+
+	// Transaction wraps the script in a transaction, which is rolled back if a statement fails
+	// (unless ContinueOnError is set, in which case what succeeded is committed)
+	Transaction bool
+
+	// ContinueOnError runs the statements that follow a failed one, reporting its error to Errors.
+	// RunScript then returns an error once the script has run, if any statement failed.
+	ContinueOnError bool
+
+	// Errors is where the errors of statements are reported with ContinueOnError, errors are not reported if nil
+	Errors io.Writer
+}
+
+// RunScript runs statements in order, on a single connection of db (so that temporary tables created by a statement
+// can be queried by the following ones), writing the results of each statement that returns rows to out.
+// The first statement to fail stops the script, with a *StatementError.
+func RunScript(ctx context.Context, db *sql.DB, statements []Statement, out io.Writer, opts ScriptOptions) (err error) {
+	var conn *sql.Conn
+	if conn, err = db.Conn(ctx); err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var q queryer = conn
+	if opts.Transaction {
+		var tx *sql.Tx
+		if tx, err = conn.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil && !opts.ContinueOnError {
+				_ = tx.Rollback()
+			} else if commitErr := tx.Commit(); commitErr != nil && err == nil {
+				err = fmt.Errorf("failed to commit the transaction: %v", commitErr)
+			}
+		}()
+		q = tx
+	}
+
+	var failed int
+	var results int // result sets written so far, which are separated by a blank line
+	for _, statement := range statements {
+		var wrote bool
+		if wrote, err = runStatement(ctx, q, statement.SQL, out, opts, results > 0); wrote {
+			results++
+		}
+		if err == nil {
+			continue
+		}
+
+		err = &StatementError{Statement: statement, Err: err}
+		if !opts.ContinueOnError || ctx.Err() != nil {
+			return err
+		}
+		if opts.Errors != nil {
+			fmt.Fprintf(opts.Errors, "Error: %v\n", err)
+		}
+		failed++
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d statements failed", failed, len(statements))
+	}
+	return nil
+}
+
+// runStatement runs statement with q and writes its results to out, if it returns rows. It reports whether it did.
+func runStatement(ctx context.Context, q queryer, statement string, out io.Writer, opts ScriptOptions, separate bool) (wrote bool, err error) {
+	rows, err := q.QueryContext(ctx, statement, opts.Args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+
+	// statements without results (such as CREATE TABLE) still have to be stepped through to run
+	if len(columns) == 0 {
+		for rows.Next() {
+		}
+		return false, rows.Err()
+	}
+
+	if separate {
+		if _, err = fmt.Fprintln(out); err != nil {
+			return false, err
+		}
+	}
+	var format = opts.Format
+	if format == "" {
+		format = "table"
+	}
+	return true, display.Write(rows, out, format, &display.Options{Template: opts.Template})
+}
@@ -0,0 +1,118 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestParseScript(t *testing.T) {
+	var script = "-- a script\nCREATE TEMP TABLE t (n INT);\n\n/* seed */ INSERT INTO t\nVALUES (1);\n-- SELECT 0;\n;\nSELECT * FROM t -- no semicolon\n"
+
+	var expected = []Statement{
+		{SQL: "-- a script\nCREATE TEMP TABLE t (n INT);", Line: 2},
+		{SQL: "/* seed */ INSERT INTO t\nVALUES (1);", Line: 4},
+		{SQL: "SELECT * FROM t -- no semicolon", Line: 8},
+	}
+	if statements := ParseScript(script); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("ParseScript() = %q, want %q", statements, expected)
+	}
+
+	if statements := ParseScript("  -- only a comment\n"); len(statements) != 0 {
+		t.Fatalf("expected no statements, got %q", statements)
+	}
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	// the database is shared by the connections of the pool, to check what was committed
+	db, err := sql.Open("sqlite3", "file:"+t.Name()+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRunScript(t *testing.T) {
+	db := newTestDB(t)
+
+	var script = `CREATE TEMP TABLE people (name TEXT, age INT);
+INSERT INTO people VALUES ('alice', 30), ('bob', 40);
+SELECT name FROM people WHERE age > :age ORDER BY name;
+SELECT count(*) AS n FROM people`
+
+	var out bytes.Buffer
+	opts := ScriptOptions{Format: "csv", Args: []interface{}{sql.Named("age", 35)}}
+	if err := RunScript(context.Background(), db, ParseScript(script), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "name\nbob\n\nn\n2\n"; out.String() != expected {
+		t.Fatalf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestRunScriptErrors(t *testing.T) {
+	var script = "CREATE TABLE a (n INT);\nINSERT INTO a VALUES (1);\n\nSELECT * FROM missing;\nINSERT INTO a VALUES (2);\n"
+
+	count := func(db *sql.DB) (n int) {
+		t.Helper()
+		if err := db.QueryRow("SELECT count(*) FROM a").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		db := newTestDB(t)
+		err := RunScript(context.Background(), db, ParseScript(script), &bytes.Buffer{}, ScriptOptions{})
+
+		var statementErr *StatementError
+		if !errors.As(err, &statementErr) || statementErr.Statement.Line != 4 {
+			t.Fatalf("expected an error at line 4, got %v", err)
+		}
+		if n := count(db); n != 1 {
+			t.Fatalf("expected the statements after the error not to run, got %d rows", n)
+		}
+	})
+
+	t.Run("continue", func(t *testing.T) {
+		db := newTestDB(t)
+		var errs bytes.Buffer
+		err := RunScript(context.Background(), db, ParseScript(script), &bytes.Buffer{}, ScriptOptions{ContinueOnError: true, Errors: &errs})
+
+		if err == nil || err.Error() != "1 of 4 statements failed" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.HasPrefix(errs.String(), "Error: line 4: no such table: missing") {
+			t.Fatalf("unexpected errors reported: %q", errs.String())
+		}
+		if n := count(db); n != 2 {
+			t.Fatalf("expected the statements after the error to run, got %d rows", n)
+		}
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		db := newTestDB(t)
+		if _, err := db.Exec("CREATE TABLE a (n INT)"); err != nil {
+			t.Fatal(err)
+		}
+
+		// the second statement fails, so the first one is rolled back
+		var script = "INSERT INTO a VALUES (1);\nINSERT INTO missing VALUES (2);\n"
+		if err := RunScript(context.Background(), db, ParseScript(script), &bytes.Buffer{}, ScriptOptions{Transaction: true}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if n := count(db); n != 0 {
+			t.Fatalf("expected the transaction to be rolled back, got %d rows", n)
+		}
+	})
+}
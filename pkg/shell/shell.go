@@ -0,0 +1,431 @@
+// Package shell implements an interactive SQL shell, reading statements from a terminal and rendering
+// their results with pkg/display. Statements run on a single connection, so that temporary tables and
+// what the extension caches (such as the repositories opened by the locator) live as long as the shell.
+// Scripts of several statements are run the same way (without a terminal) with RunScript.
+package shell
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mergestat/mergestat-lite/pkg/display"
+	"golang.org/x/term"
+)
+
+const (
+	prompt             = "mergestat> "
+	continuationPrompt = "      ...> "
+)
+
+// metaCommands are the commands handled by the shell itself (see Shell.meta), rather than by SQLite
+var metaCommands = []struct{ name, args, help string }{
+	{".format", "[FORMAT]", "show or set the output format, one of " + strings.Join(display.Formats(), ", ") +
+		" (.format template TEXT outputs each row with a Go template, such as {{.name}}: {{.count}})"},
+	{".help", "", "show this message"},
+	{".quit", "", "exit the shell (or ctrl+d), also .exit"},
+	{".schema", "[TABLE]", "show the CREATE statement of a table, or of every table and view of the database"},
+	{".tables", "[PATTERN]", "list the tables (including table-valued functions) matching a LIKE pattern"},
+	{".timer", "on|off", "show how long each statement takes to run"},
+}
+
+// Options configures a Shell
+type Options struct {
+	// Format is the initial output format (see display.WriteTo), table if empty
+	Format string
+
+	// Template is the initial template of the template format (see display.Options)
+	Template string
+
+	// HistoryFile is where what is entered is saved (a line per statement, or meta-command), and loaded from
+	// when the shell starts. History is not saved if empty.
+	HistoryFile string
+
+	// Timeout is the maximum duration of each statement, 0 means no limit
+	Timeout time.Duration
+
+	// BindContext, if set, is called with the context of each statement before it runs, and the connection of the shell,
+	// so that interrupting the statement (or its timeout) also aborts what the extensions do for it, such as cloning
+	// repositories. The returned unbind function is called once the statement has run.
+	BindContext func(ctx context.Context, conn *sql.Conn) (unbind func(), err error)
+}
+
+// Shell reads and runs statements and meta-commands, see Run
+type Shell struct {
+	conn    *sql.Conn
+	opts    Options
+	format  string
+	tmpl    string // template of the template format
+	timer   bool
+	catalog *catalog // loaded when needed, and reset after each statement as it may change the schema
+
+	out, errOut io.Writer
+	term        *term.Terminal // nil unless running in a terminal
+	in          *input
+	history     *history // nil unless running in a terminal
+}
+
+// New returns a Shell running statements on a connection of db, which it holds until it is closed
+func New(ctx context.Context, db *sql.DB, opts Options) (*Shell, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var format = opts.Format
+	if format == "" {
+		format = "table"
+	}
+
+	return &Shell{conn: conn, opts: opts, format: format, tmpl: opts.Template}, nil
+}
+
+// Close releases the connection of the shell
+func (s *Shell) Close() error { return s.conn.Close() }
+
+// Run reads statements and meta-commands from in, until it ends or .quit is entered, writing results to out.
+// If in is a terminal, lines are edited with history and tab completion, and ctrl+c interrupts the running statement.
+func (s *Shell) Run(ctx context.Context, in, out *os.File) error {
+	if !term.IsTerminal(int(in.Fd())) {
+		s.out, s.errOut = out, os.Stderr
+		scanner := bufio.NewScanner(in)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		return s.loop(ctx, func(string) (string, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		})
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = term.Restore(int(in.Fd()), state) }()
+
+	s.in = newInput(in)
+	s.term = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{s.in, out}, prompt)
+	s.term.AutoCompleteCallback = s.autoComplete
+	if width, height, err := term.GetSize(int(out.Fd())); err == nil && width > 0 {
+		_ = s.term.SetSize(width, height)
+	}
+	s.out, s.errOut = s.term, s.term
+
+	s.history = &history{}
+	s.loadHistory()
+
+	fmt.Fprintln(s.out, `Enter ".help" for usage hints. Statements end with a semicolon, and may span several lines.`)
+
+	return s.loop(ctx, func(p string) (string, error) {
+		s.term.SetPrompt(p)
+		line, err := s.term.ReadLine()
+		s.history.reset()
+		if errors.Is(err, term.ErrPasteIndicator) {
+			err = nil
+		}
+		return line, err
+	})
+}
+
+// loop runs the statements and meta-commands read with readLine, which is passed the prompt to display
+func (s *Shell) loop(ctx context.Context, readLine func(prompt string) (string, error)) error {
+	var pending string // lines of an incomplete statement
+	var entry string   // lines entered since the last complete statement, saved to the history as a whole
+	for {
+		p := prompt
+		if pending != "" {
+			p = continuationPrompt
+		}
+
+		line, err := readLine(p)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		// ctrl+c discards what was entered so far
+		if s.in != nil && s.in.interrupted {
+			s.in.interrupted, pending, entry = false, "", ""
+			continue
+		}
+
+		if pending == "" && strings.HasPrefix(strings.TrimSpace(line), ".") {
+			s.saveHistory(line)
+			if quit := s.meta(ctx, strings.Fields(line)); quit {
+				return nil
+			}
+			continue
+		}
+
+		entry += line + "\n"
+		statements, rest := SplitStatements(pending + line + "\n")
+		if pending = rest; strings.TrimSpace(rest) == "" {
+			s.saveHistory(entry)
+			pending, entry = "", ""
+		}
+		for _, statement := range statements {
+			s.execute(ctx, statement)
+		}
+	}
+}
+
+// execute runs a statement and displays its results, if any
+func (s *Shell) execute(ctx context.Context, statement string) {
+	// the schema may be changed by the statement
+	defer func() { s.catalog = nil }()
+
+	if s.in != nil {
+		var stop func()
+		ctx, stop = s.in.watch(ctx)
+		defer stop()
+	}
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	if s.opts.BindContext != nil {
+		unbind, err := s.opts.BindContext(ctx, s.conn)
+		if err != nil {
+			fmt.Fprintf(s.errOut, "Error: %v\n", err)
+			return
+		}
+		defer unbind()
+	}
+
+	var start = time.Now()
+	if err := s.query(ctx, statement); err != nil {
+		if ctx.Err() == context.Canceled {
+			err = errors.New("interrupted")
+		}
+		fmt.Fprintf(s.errOut, "Error: %v\n", err)
+	}
+	if s.timer {
+		fmt.Fprintf(s.out, "Run Time: %s\n", time.Since(start).Round(time.Millisecond))
+	}
+}
+
+func (s *Shell) query(ctx context.Context, statement string) error {
+	rows, err := s.conn.QueryContext(ctx, statement)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	// statements without results (such as CREATE TABLE) still have to be stepped through to run
+	if len(columns) == 0 {
+		for rows.Next() {
+		}
+		return rows.Err()
+	}
+
+	var w = &lineWriter{w: s.out}
+	if err := display.Write(rows, w, s.format, &display.Options{Interactive: true, Template: s.tmpl}); err != nil {
+		return err
+	}
+	return w.endLine()
+}
+
+// meta runs a meta-command, as split into fields, and reports whether the shell should quit
+func (s *Shell) meta(ctx context.Context, fields []string) (quit bool) {
+	var err error
+	switch fields[0] {
+	case ".quit", ".exit":
+		return true
+	case ".help":
+		for _, m := range metaCommands {
+			fmt.Fprintf(s.out, "%-28s %s\n", strings.TrimSpace(m.name+" "+m.args), m.help)
+		}
+	case ".format":
+		err = s.setFormat(fields[1:])
+	case ".timer":
+		if len(fields) != 2 || (fields[1] != "on" && fields[1] != "off") {
+			err = errors.New("usage: .timer on|off")
+		} else {
+			s.timer = fields[1] == "on"
+		}
+	case ".tables":
+		err = s.tables(ctx, fields[1:])
+	case ".schema":
+		err = s.schema(ctx, fields[1:])
+	default:
+		err = fmt.Errorf("unknown command %s, enter \".help\" for the list of commands", fields[0])
+	}
+
+	if err != nil {
+		fmt.Fprintf(s.errOut, "Error: %v\n", err)
+	}
+	return false
+}
+
+func (s *Shell) setFormat(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(s.out, s.format)
+		return nil
+	}
+
+	var formats = display.Formats()
+	for _, format := range formats {
+		if args[0] != format {
+			continue
+		}
+		if format == "template" {
+			// the template is the rest of the line, with its fields joined by single spaces
+			if len(args) == 1 && s.tmpl == "" {
+				return errors.New("usage: .format template TEXT")
+			} else if len(args) > 1 {
+				s.tmpl = strings.Join(args[1:], " ")
+			}
+		}
+		s.format = format
+		return nil
+	}
+	return fmt.Errorf("unknown format %s, expected one of %s", args[0], strings.Join(formats, ", "))
+}
+
+func (s *Shell) tables(ctx context.Context, args []string) error {
+	var pattern = "%"
+	if len(args) > 0 {
+		pattern = args[0]
+	}
+
+	c, err := s.loadCatalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	var names = make([]string, len(c.tables))
+	for i, t := range c.tables {
+		names[i] = t.name
+	}
+	b, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+
+	// LIKE is left to SQLite, for patterns to match as they do in queries
+	query := "SELECT value FROM json_each(?) WHERE value LIKE ?"
+	return queryStrings(ctx, s.conn, func(values ...string) { fmt.Fprintln(s.out, values[0]) }, query, string(b), pattern)
+}
+
+func (s *Shell) schema(ctx context.Context, args []string) error {
+	c, err := s.loadCatalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		for _, t := range c.tables {
+			if t.sql != "" {
+				fmt.Fprintln(s.out, t.schema())
+			}
+		}
+		return nil
+	}
+
+	var t = c.lookup(args[0])
+	if t == nil {
+		return fmt.Errorf("no such table: %s", args[0])
+	}
+	fmt.Fprintln(s.out, t.schema())
+	return nil
+}
+
+func (s *Shell) loadCatalog(ctx context.Context) (*catalog, error) {
+	if s.catalog == nil {
+		c, err := loadCatalog(ctx, s.conn)
+		if err != nil {
+			return nil, err
+		}
+		s.catalog = c
+	}
+	return s.catalog, nil
+}
+
+// autoComplete completes the word before the cursor on tab. If there is more than one candidate,
+// their common prefix is completed, or they are listed if there is nothing more in common.
+// It also goes through the history, on the keys input reads in place of the up and down keys.
+func (s *Shell) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key == keyPrevious || key == keyNext {
+		var entry string
+		var ok bool
+		if key == keyPrevious {
+			entry, ok = s.history.previous(line)
+		} else {
+			entry, ok = s.history.next()
+		}
+		if !ok {
+			return line, pos, true
+		}
+		return entry, len(entry), true
+	}
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	c, err := s.loadCatalog(context.Background())
+	if err != nil {
+		return "", 0, false
+	}
+
+	candidates, start := c.complete(line[:pos])
+	if len(candidates) == 0 {
+		return line, pos, true
+	}
+
+	completed := commonPrefix(candidates)
+	if len(candidates) == 1 {
+		completed += " "
+	}
+	if len(completed) > pos-start {
+		return line[:start] + completed + line[pos:], start + len(completed), true
+	}
+
+	// the line is drawn again after the candidates
+	fmt.Fprintln(s.term, strings.Join(candidates, "  "))
+	return line, pos, true
+}
+
+// lineWriter keeps track of whether what was written to it ends with a newline,
+// as some formats (such as json) do not end with one, and the prompt should start on a line of its own
+type lineWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		l.last = p[len(p)-1]
+	}
+	return l.w.Write(p)
+}
+
+// endLine writes a newline, unless what was written ends with one already
+func (l *lineWriter) endLine() error {
+	if l.last == 0 || l.last == '\n' {
+		return nil
+	}
+	_, err := l.w.Write([]byte("\n"))
+	return err
+}
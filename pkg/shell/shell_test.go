@@ -0,0 +1,152 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestShell(t *testing.T) (*Shell, *bytes.Buffer) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s, err := New(context.Background(), db, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	var out bytes.Buffer
+	s.out, s.errOut = &out, &out
+	return s, &out
+}
+
+// run runs the shell on lines, as if they were typed one by one
+func run(t *testing.T, s *Shell, lines ...string) {
+	t.Helper()
+	if err := s.loop(context.Background(), func(string) (string, error) {
+		if len(lines) == 0 {
+			return "", io.EOF
+		}
+		line := lines[0]
+		lines = lines[1:]
+		return line, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShell(t *testing.T) {
+	s, out := newTestShell(t)
+
+	run(t, s,
+		"CREATE TABLE people (name TEXT, age INT);",
+		"INSERT INTO people VALUES",
+		"  ('alice', 30), ('bob; the builder', 40);",
+		".format csv",
+		"SELECT * FROM people ORDER BY age; SELECT count(*) AS n FROM people;",
+		".schema people",
+		".tables peo%",
+		".quit",
+		"SELECT 'not run';",
+	)
+
+	for _, expected := range []string{
+		"name,age\nalice,30\nbob; the builder,40\n",
+		"n\n2\n",
+		"CREATE TABLE people (name TEXT, age INT);\n",
+		"people\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "not run") {
+		t.Errorf("expected statements after .quit not to run, got:\n%s", out.String())
+	}
+}
+
+func TestShellErrors(t *testing.T) {
+	s, out := newTestShell(t)
+
+	run(t, s, "SELECT * FROM missing;", ".format xml", ".timer maybe", ".nope", ".format json", "SELECT 1 AS one;")
+
+	for _, expected := range []string{
+		"Error: no such table: missing",
+		"Error: unknown format xml",
+		"Error: usage: .timer on|off",
+		"Error: unknown command .nope",
+		`[{"one":1}]` + "\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestShellTemplate(t *testing.T) {
+	s, out := newTestShell(t)
+
+	run(t, s, ".format template", ".format template {{.name}} is {{.age}}", "SELECT 'alice' AS name, 30 AS age;")
+
+	for _, expected := range []string{"Error: usage: .format template TEXT", "alice is 30\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s, _ := newTestShell(t)
+	run(t, s, "CREATE TABLE people (name TEXT, age INT);")
+
+	c, err := s.loadCatalog(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		input    string
+		expected []string
+		start    int
+	}{
+		{"sel", []string{"select"}, 0},
+		{"SEL", []string{"SELECT"}, 0},
+		{"SELECT na", []string{"name"}, 7},
+		{"SELECT * FROM peo", []string{"people"}, 14},
+		{"SELECT * FROM json_each WHERE roo", []string{"root"}, 30}, // a hidden column
+		{"SELECT json_ext", []string{"json_extract"}, 7},
+		{"SELECT ", nil, 7},
+		{".sc", []string{".schema"}, 0},
+		{".t", []string{".tables", ".timer"}, 0},
+		{".schema peo", []string{"people"}, 8},
+		{".format js", []string{"json"}, 8},
+		{".timer o", []string{"off", "on"}, 7},
+	}
+
+	for _, test := range tests {
+		candidates, start := c.complete(test.input)
+		if strings.Join(candidates, ",") != strings.Join(test.expected, ",") || start != test.start {
+			t.Errorf("complete(%q) = %q, %d, want %q, %d", test.input, candidates, start, test.expected, test.start)
+		}
+	}
+}
+
+func TestSchemaOfTableValuedFunction(t *testing.T) {
+	s, out := newTestShell(t)
+	run(t, s, ".schema json_each")
+
+	if !strings.Contains(out.String(), "json HIDDEN") {
+		t.Errorf("expected the arguments of json_each to be hidden columns, got:\n%s", out.String())
+	}
+}
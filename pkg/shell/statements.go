@@ -0,0 +1,90 @@
+package shell
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SplitStatements splits script into complete SQL statements, each ending with a semicolon (which is kept),
+// and returns the incomplete text left after the last one (e.g. a statement being typed over several lines).
+// Semicolons in strings, quoted identifiers and comments do not end a statement,
+// nor do the ones inside the BEGIN ... END body of a CREATE TRIGGER statement.
+func SplitStatements(script string) (statements []string, rest string) {
+	var start int      // of the current statement
+	var words []string // leading words of the current statement, to detect triggers
+	var lastWord string
+
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			// a doubled quote is an escaped one, and is skipped over as two consecutive quoted sections
+			end := strings.IndexByte(script[i+1:], closing)
+			if end < 0 {
+				return statements, script[start:]
+			}
+			i += end + 1
+			lastWord = ""
+
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end
+			}
+
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return statements, script[start:]
+			}
+			i += end + 3
+
+		case isWordByte(c):
+			end := i
+			for end < len(script) && isWordByte(script[end]) {
+				end++
+			}
+			lastWord = strings.ToUpper(script[i:end])
+			if len(words) < 4 {
+				words = append(words, lastWord)
+			}
+			i = end - 1
+
+		case c == ';':
+			// the statements of the body of a trigger end with a semicolon, only END; ends the trigger itself
+			if isCreateTrigger(words) && lastWord != "END" {
+				lastWord = ""
+				continue
+			}
+			if statement := strings.TrimSpace(script[start : i+1]); statement != ";" {
+				statements = append(statements, statement)
+			}
+			start, words, lastWord = i+1, nil, ""
+
+		case !unicode.IsSpace(rune(c)):
+			lastWord = ""
+		}
+	}
+
+	return statements, script[start:]
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// isCreateTrigger reports whether the leading words of a statement are those of CREATE [TEMP|TEMPORARY] TRIGGER
+func isCreateTrigger(words []string) bool {
+	if len(words) < 2 || words[0] != "CREATE" {
+		return false
+	}
+	if words[1] == "TEMP" || words[1] == "TEMPORARY" {
+		return len(words) > 2 && words[2] == "TRIGGER"
+	}
+	return words[1] == "TRIGGER"
+}
@@ -0,0 +1,43 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	var tests = []struct {
+		script     string
+		statements []string
+		rest       string
+	}{
+		{"SELECT 1;", []string{"SELECT 1;"}, ""},
+		{"SELECT 1; SELECT 2;\n", []string{"SELECT 1;", "SELECT 2;"}, "\n"},
+		{"SELECT 1", nil, "SELECT 1"},
+		{"SELECT 1;\nSELECT *\nFROM commits", []string{"SELECT 1;"}, "\nSELECT *\nFROM commits"},
+		{"SELECT ';' AS semi;", []string{"SELECT ';' AS semi;"}, ""},
+		{"SELECT 'it''s; ok';", []string{"SELECT 'it''s; ok';"}, ""},
+		{"SELECT 'unterminated;", nil, "SELECT 'unterminated;"},
+		{`SELECT "a;b", [c;d], ` + "`e;f`;", []string{`SELECT "a;b", [c;d], ` + "`e;f`;"}, ""},
+		{"SELECT 1 -- a comment; still a comment\n;", []string{"SELECT 1 -- a comment; still a comment\n;"}, ""},
+		{"SELECT /* ; */ 1;", []string{"SELECT /* ; */ 1;"}, ""},
+		{"SELECT /* ; ", nil, "SELECT /* ; "},
+		{";;SELECT 1;", []string{"SELECT 1;"}, ""},
+		{
+			"CREATE TRIGGER t AFTER INSERT ON a BEGIN INSERT INTO b VALUES (1); DELETE FROM c; END; SELECT 1;",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN INSERT INTO b VALUES (1); DELETE FROM c; END;", "SELECT 1;"},
+			"",
+		},
+		{"create temp trigger t after insert on a begin select 1;", nil, "create temp trigger t after insert on a begin select 1;"},
+	}
+
+	for _, test := range tests {
+		statements, rest := SplitStatements(test.script)
+		if !reflect.DeepEqual(statements, test.statements) {
+			t.Errorf("SplitStatements(%q) statements = %q, want %q", test.script, statements, test.statements)
+		}
+		if rest != test.rest {
+			t.Errorf("SplitStatements(%q) rest = %q, want %q", test.script, rest, test.rest)
+		}
+	}
+}
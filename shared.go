@@ -28,6 +28,7 @@ func init() {
 	sqlite.Register(extensions.RegisterFn(
 		options.WithExtraFunctions(),
 		options.WithRepoLocator(locator.CachedLocator(locator.MultiLocator(multiLocOpt))),
+		options.WithRefLister(locator.RemoteRefLister(multiLocOpt)),
 		options.WithGitHub(),
 		options.WithContextValue("githubToken", githubToken),
 		options.WithContextValue("githubPerPage", os.Getenv("GITHUB_PER_PAGE")),